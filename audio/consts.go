@@ -90,6 +90,10 @@ var AudioConfig = Config{
 	PulseThreatThreshold: 0.25,
 }
 
+// BossMusicPreset is the preset of the first phase of a boss fight; each
+// further phase uses the next one. They are numbered clear of the levels.
+const BossMusicPreset = 100
+
 // LevelMusicPresets contains presets for each level style
 var LevelMusicPresets = map[int]*LevelMusicPreset{
 	// Level 1: A minor - mysterious, cinematic (Blade Runner)
@@ -271,6 +275,66 @@ var LevelMusicPresets = map[int]*LevelMusicPreset{
 		ArpTempo:  720,
 		BassTempo: 1440,
 	},
+
+	// Boss phase 1: C minor - the mothership arrives
+	BossMusicPreset: {
+		Name:        "C Minor - Mothership",
+		DroneFreqs:  []float64{65.41, 98.0, 130.81, 196.0}, // C2, G2, C3, G3
+		SubBassFreq: 32.7,                                  // C1
+
+		BassNotes: []float64{65.41, 65.41, 51.91, 51.91, 58.27, 58.27, 65.41, 49}, // Cm-Ab-Bb-Cm
+
+		ArpNotes: []float64{130.81, 155.56, 174.61, 196, 233.08, 261.63, 311.13, 349.23}, // C minor pentatonic
+
+		ShimmerFreqs:        []float64{523.25, 784, 1046.5, 1568},   // C5 harmonics
+		PadFreqs:            []float64{103.83, 130.81, 155.56, 196}, // Ab maj7: Ab, C, Eb, G
+		TensionBaseFreq:     523.25,                                 // C5
+		SirenBaseFreq:       784,                                    // G5
+		SubBassReactiveFreq: 43.65,                                  // F1
+
+		ArpTempo:  640,
+		BassTempo: 1280,
+	},
+
+	// Boss phase 2: C# minor - shield generator down, a semitone of panic
+	BossMusicPreset + 1: {
+		Name:        "C# Minor - Shield Down",
+		DroneFreqs:  []float64{69.3, 103.83, 138.59, 207.65}, // C#2, G#2, C#3, G#3
+		SubBassFreq: 34.65,                                   // C#1
+
+		BassNotes: []float64{69.3, 69.3, 55, 55, 61.74, 61.74, 69.3, 51.91}, // C#m-A-B-C#m
+
+		ArpNotes: []float64{138.59, 164.81, 185, 207.65, 246.94, 277.18, 329.63, 369.99}, // C# minor pentatonic
+
+		ShimmerFreqs:        []float64{554.37, 830.61, 1108.73, 1661.22}, // C#5 harmonics
+		PadFreqs:            []float64{110, 138.59, 164.81, 207.65},      // A maj7: A, C#, E, G#
+		TensionBaseFreq:     554.37,                                      // C#5
+		SirenBaseFreq:       830.61,                                      // G#5
+		SubBassReactiveFreq: 46.25,                                       // F#1
+
+		ArpTempo:  560,
+		BassTempo: 1120,
+	},
+
+	// Boss phase 3: D minor - every turret gone, the core fights alone
+	BossMusicPreset + 2: {
+		Name:        "D Minor - Last Stand",
+		DroneFreqs:  []float64{73.42, 110.0, 146.83, 220.0}, // D2, A2, D3, A3
+		SubBassFreq: 36.71,                                  // D1
+
+		BassNotes: []float64{73.42, 73.42, 58.27, 58.27, 65.41, 65.41, 73.42, 55}, // Dm-Bb-C-Dm
+
+		ArpNotes: []float64{146.83, 174.61, 196, 220, 261.63, 293.66, 349.23, 392}, // D minor pentatonic
+
+		ShimmerFreqs:        []float64{587.33, 880, 1174.66, 1760},  // D5 harmonics
+		PadFreqs:            []float64{116.54, 146.83, 174.61, 220}, // Bb maj7: Bb, D, F, A
+		TensionBaseFreq:     587.33,                                 // D5
+		SirenBaseFreq:       880,                                    // A5
+		SubBassReactiveFreq: 49,                                     // G1
+
+		ArpTempo:  480,
+		BassTempo: 960,
+	},
 }
 
 // SfxData contains sound effect parameter strings for jsfxr.
@@ -323,6 +387,14 @@ var SfxData = []string{
 	"1,.0099,.15,,.2299,.45,,.1799,.48,.5099,.4599,-.4399,.6299,,,,,.0099,.6599,.0099,,.1699,,.4",
 	// 23 = Ship thrust (magnetic hum sound)
 	"2,.01,.12,.03,.15,.18,,,.02,,.08,.12,.45,,,,,.08,.6,.15,.4,.03,,.3",
+	// 24 = Missile launch (whooshing rise)
+	"3,.02,.21,.18,.31,.12,,.24,.08,,,,,,,,.11,-.06,1,,,,,.3",
+	// 25 = Laser beam (buzzing hum)
+	"1,,.09,.04,.12,.32,.05,,,,,,,.55,-.21,.4,,,1,,,.12,,.2",
+	// 26 = Charge up (rising whine)
+	"0,.31,.42,.02,.05,.18,,.31,.02,,,,,.24,,,,,1,,,,,.2",
+	// 27 = Weapon switch (short click)
+	"0,,.05,,.09,.61,,,,,,,,.41,,,,,1,,,.1,,.3",
 }
 
 // SoundEffectLibrary contains all structured sound effects
@@ -351,4 +423,8 @@ var SoundEffectLibrary []*SoundEffect = []*SoundEffect{
 	ParseJsfxrString(21, "Intro", "UI", "Title screen intro", SfxData[21]),
 	ParseJsfxrString(22, "Target Lock", "Player", "Target locking sound", SfxData[22]),
 	ParseJsfxrString(23, "Thrust", "Player", "Ship engine thrust", SfxData[23]),
+	ParseJsfxrString(24, "Missile Launch", "Player", "Homing missiles fired", SfxData[24]),
+	ParseJsfxrString(25, "Laser Beam", "Player", "Laser beam burns a target", SfxData[25]),
+	ParseJsfxrString(26, "Charge Up", "Player", "Charge shot charging", SfxData[26]),
+	ParseJsfxrString(27, "Weapon Switch", "Player", "Weapon selected", SfxData[27]),
 }
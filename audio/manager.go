@@ -450,6 +450,9 @@ func (am *AudioManager) StartSynthMusic(seed uint32, level int) {
 
 	am.synthPlaying = true
 
+	// Music has its own stream so it never consumes gameplay randomness
+	am.synthRNG.SetSeed(common.LevelSeed(seed, level))
+
 	// Get the music preset for this level
 	am.currentPreset = GetLevelPreset(level)
 
@@ -0,0 +1,62 @@
+//go:build !js
+// +build !js
+
+// Command sorades-replay verifies a recorded replay by running the game
+// simulation natively, without a browser. It prints the difficulty, the
+// final score, the ship's health, the tick count and the state hash.
+//
+// Usage:
+//
+//	sorades-replay [-expect-hash HEX] FILE.srpl
+//
+// With -expect-hash the command exits with status 1 when the replay does
+// not end in the given state, which is how leaderboard submissions are
+// checked.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+
+	"github.com/simukka/starship-sorades-13k/game"
+)
+
+func main() {
+	expectHash := flag.String("expect-hash", "", "Fail unless the final state hash matches (hex)")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "Usage: sorades-replay [-expect-hash HEX] FILE.srpl")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	data, err := os.ReadFile(flag.Arg(0))
+	if err != nil {
+		log.Fatal(err)
+	}
+	replay, err := game.DecodeReplay(data)
+	if err != nil {
+		log.Fatalf("%s: %v", flag.Arg(0), err)
+	}
+
+	result := game.RunReplay(replay)
+	fmt.Print(result)
+
+	if *expectHash != "" {
+		want, err := strconv.ParseUint(*expectHash, 16, 32)
+		if err != nil {
+			log.Fatalf("invalid -expect-hash %q: %v", *expectHash, err)
+		}
+		if uint32(want) != result.Hash {
+			fmt.Fprintf(os.Stderr, "hash mismatch: got %08x, want %08x\n", result.Hash, want)
+			os.Exit(1)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+//go:build !js
+// +build !js
+
+// Command sorades-soak runs long unattended sessions flown by the autopilot
+// to shake out crashes and balance problems. Every session is simulated
+// natively with its own seed until the ship dies or the tick limit is hit.
+//
+// Usage:
+//
+//	sorades-soak [-sessions N] [-ticks N] [-seed N] [-out DIR] [-v]
+//
+// A session that panics or ends in an invalid state is reported with its
+// seed and tick, and its replay is written to DIR so the failure can be
+// reproduced with sorades-replay. The command exits with status 1 if any
+// session failed.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+
+	"github.com/simukka/starship-sorades-13k/game"
+)
+
+// result describes one soak session.
+type result struct {
+	seed  uint32
+	ticks uint32
+	died  bool
+	score int
+	err   error
+}
+
+func main() {
+	sessions := flag.Int("sessions", 100, "Number of sessions to run")
+	ticks := flag.Int("ticks", 30*60*10, "Tick limit per session (30 ticks per second)")
+	seed := flag.Uint("seed", 1, "Seed of the first session; later sessions count up")
+	out := flag.String("out", ".", "Directory for replays of failed sessions")
+	verbose := flag.Bool("v", false, "Print every session")
+	flag.Parse()
+
+	var failed, died int
+	var totalTicks, totalScore int
+	minTicks, maxTicks := math.MaxInt32, 0
+
+	for i := 0; i < *sessions; i++ {
+		r, replay := soak(uint32(*seed)+uint32(i), uint32(*ticks))
+
+		if r.err != nil {
+			failed++
+			file := filepath.Join(*out, fmt.Sprintf("soak-%d.srpl", r.seed))
+			if data, err := replay.MarshalBinary(); err == nil {
+				if err := os.WriteFile(file, data, 0o644); err != nil {
+					log.Print(err)
+				}
+			}
+			fmt.Printf("FAIL seed %d tick %d: %v (replay: %s)\n", r.seed, r.ticks, r.err, file)
+			continue
+		}
+
+		if r.died {
+			died++
+		}
+		totalTicks += int(r.ticks)
+		totalScore += r.score
+		if int(r.ticks) < minTicks {
+			minTicks = int(r.ticks)
+		}
+		if int(r.ticks) > maxTicks {
+			maxTicks = int(r.ticks)
+		}
+		if *verbose {
+			fmt.Printf("seed %d: ticks %d, died %t, score %d\n", r.seed, r.ticks, r.died, r.score)
+		}
+	}
+
+	fmt.Printf("sessions: %d\nfailed:   %d\ndied:     %d\n", *sessions, failed, died)
+	if completed := *sessions - failed; completed > 0 {
+		fmt.Printf("ticks:    min %d, mean %d, max %d\nscore:    mean %d\n",
+			minTicks, totalTicks/completed, maxTicks, totalScore/completed)
+	}
+
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// soak flies one session and returns its result and replay. Panics are
+// recovered and reported as errors.
+func soak(seed, maxTicks uint32) (r result, replay *game.Replay) {
+	g := game.NewHeadlessGame(seed)
+	g.Ship.Input = game.NewAutopilot()
+	r.seed = seed
+	replay = g.Recording
+
+	defer func() {
+		if p := recover(); p != nil {
+			r.ticks = g.Tick
+			r.err = fmt.Errorf("panic: %v\n%s", p, debug.Stack())
+		}
+	}()
+
+	for g.Tick < maxTicks && g.Ship.IsAlive() {
+		g.Step(g.PollInputs())
+		if err := check(g); err != nil {
+			r.ticks = g.Tick
+			r.err = err
+			return r, replay
+		}
+	}
+
+	r.ticks = g.Tick
+	r.died = !g.Ship.IsAlive()
+	r.score = g.Level.P + g.Ship.Points
+	return r, replay
+}
+
+// check reports simulation state that should never happen.
+func check(g *game.Game) error {
+	s := g.Ship
+	for _, v := range []float64{s.X, s.Y, s.VelX, s.VelY, s.Angle} {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return errors.New("ship position or velocity is not finite")
+		}
+	}
+	if s.E < 0 || s.E > s.MaxEnergy() {
+		return fmt.Errorf("ship energy %d out of range", s.E)
+	}
+	if g.Bullets.ActiveCount > g.Bullets.MaxSize || g.Bonuses.ActiveCount > g.Bonuses.MaxSize {
+		return errors.New("object pool overflow")
+	}
+	for _, e := range g.Enemies {
+		if math.IsNaN(e.X) || math.IsNaN(e.Y) {
+			return fmt.Errorf("%s position is not finite", game.EnemyKindNames[e.Kind])
+		}
+	}
+	return nil
+}
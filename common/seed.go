@@ -21,6 +21,12 @@ func (r *SeededRNG) SetSeed(seed uint32) {
 	r.initialSeed = seed
 }
 
+// State returns the current internal state. Two generators with the same
+// state produce the same sequence from here on.
+func (r *SeededRNG) State() uint32 {
+	return r.state
+}
+
 // Reset resets the generator to its initial seed.
 func (r *SeededRNG) Reset() {
 	r.state = r.initialSeed
@@ -46,9 +52,28 @@ func (r *SeededRNG) RandomFloat(min, max float64) float64 {
 	return r.Random()*(max-min) + min
 }
 
-// LevelSeed generates a deterministic seed for a specific level.
-func LevelSeed(baseSeed uint32, levelNumber int) uint32 {
-	seed := baseSeed ^ (uint32(levelNumber) * 2654435761)
+// FxSeed derives the seed of the cosmetic random stream from a game seed.
+// Keeping cosmetic randomness (explosions, music) on its own stream means
+// effects can draw any number of values without shifting the gameplay stream.
+func FxSeed(baseSeed uint32) uint32 {
+	return LevelSeed(baseSeed^0x9E3779B9, 0)
+}
+
+// LevelSeed generates a deterministic seed for a specific level. Further
+// numbers, such as the coordinates of a world chunk, are mixed in one after
+// another, so LevelSeed(seed, x, y) differs from LevelSeed(seed, y, x).
+func LevelSeed(baseSeed uint32, levelNumber int, more ...int) uint32 {
+	seed := mixSeed(baseSeed, levelNumber)
+	for _, n := range more {
+		seed = mixSeed(seed, n)
+	}
+	return seed
+}
+
+// mixSeed mixes n into seed with a multiplicative hash and a murmur3
+// finalizer.
+func mixSeed(seed uint32, n int) uint32 {
+	seed ^= uint32(n) * 2654435761
 	seed = (seed ^ (seed >> 16)) * 0x85ebca6b
 	seed = (seed ^ (seed >> 13)) * 0xc2b2ae35
 	return seed ^ (seed >> 16)
@@ -0,0 +1,226 @@
+package game
+
+import "math"
+
+// Autopilot tuning.
+const (
+	// AutopilotLowHealth sends the autopilot back to a base for repairs.
+	AutopilotLowHealth = 35
+	// AutopilotRepairedHealth is the energy at which it leaves the base again.
+	AutopilotRepairedHealth = 90
+	// AutopilotDodgeTicks is how far ahead torpedo paths are checked.
+	AutopilotDodgeTicks = 24
+	// AutopilotRange is the distance kept to the hunted enemy: close enough
+	// to stay on screen for target lock, far enough to dodge its torpedoes.
+	AutopilotRange = HEIGHT / 3
+)
+
+// Autopilot is an InputSource that flies a ship by producing the same key
+// bitmask a player sends (see KeyLeft...KeyLock), so its input goes through
+// Step, is recorded in replays and behaves identically on every run with the
+// same seed. It only reads the Game state and never changes it.
+//
+// In order of priority it dodges incoming torpedoes, returns to the nearest
+// base when its energy is low and otherwise hunts the nearest enemy: it
+// closes in to AutopilotRange, locks on and fires while locked.
+type Autopilot struct {
+	repairing bool   // Heading to or waiting in a base until repaired
+	prevKeys  uint16 // Keys returned on the previous tick
+}
+
+// NewAutopilot creates an autopilot. Assign it to Ship.Input to let it fly.
+func NewAutopilot() *Autopilot {
+	return &Autopilot{}
+}
+
+// Poll implements InputSource.
+func (a *Autopilot) Poll(g *Game, s *Ship) ControlState {
+	return ControlState{Keys: a.keys(g, s)}
+}
+
+// keys returns the key bitmask flying s for the next tick.
+func (a *Autopilot) keys(g *Game, s *Ship) uint16 {
+	if s == nil || !s.IsAlive() {
+		a.prevKeys = 0
+		return 0
+	}
+
+	if s.E < AutopilotLowHealth {
+		a.repairing = true
+	} else if s.E >= AutopilotRepairedHealth {
+		a.repairing = false
+	}
+
+	var keys uint16
+	if heading, ok := a.dodge(g, s); ok {
+		keys = steer(s, heading, true)
+	} else if base := nearestBase(g, s); a.repairing && base != nil {
+		keys = repair(s, base)
+	} else if enemy := nearestEnemy(g, s); enemy != nil {
+		keys = a.hunt(g, s, enemy)
+	}
+
+	// Fire whenever a lock is held; the weapons aim on their own. A full
+	// charge shot fires when the key is let go.
+	charged := s.Weapon == WeaponCharge && s.Charge >= ChargeMax
+	if s.Target != nil && !s.InBase && !charged {
+		keys |= KeyFire
+	}
+
+	a.prevKeys = keys
+	return keys
+}
+
+// dodge returns a heading away from the most urgent torpedo or mine that will
+// pass within collision distance in the next AutopilotDodgeTicks ticks.
+func (a *Autopilot) dodge(g *Game, s *Ship) (float64, bool) {
+	if s.InBase || s.Shield.T > AutopilotDodgeTicks {
+		return 0, false
+	}
+
+	soonest := math.MaxFloat64
+	heading := 0.0
+	for i := 0; i < g.Bullets.ActiveCount; i++ {
+		b := g.Bullets.Pool[i]
+		if !b.Hostile() {
+			continue
+		}
+
+		// Torpedo motion relative to the ship
+		dx, dy := b.X-s.X, b.Y-s.Y
+		vx, vy := b.XAcc-s.VelX, b.YAcc-s.VelY
+		speedSq := vx*vx + vy*vy
+		if speedSq == 0 {
+			continue
+		}
+
+		// Time and distance of the closest approach
+		t := -(dx*vx + dy*vy) / speedSq
+		if t < 0 || t > AutopilotDodgeTicks || t >= soonest {
+			continue
+		}
+		cx, cy := dx+vx*t, dy+vy*t
+		if math.Hypot(cx, cy) > ShipCollisionD+TorpedoR {
+			continue
+		}
+
+		// Flee sideways, to the side of the path the ship is already on
+		side := 1.0
+		if dx*vy-dy*vx > 0 {
+			side = -1
+		}
+		soonest = t
+		heading = math.Atan2(side*vy, side*vx)
+	}
+	return heading, soonest != math.MaxFloat64
+}
+
+// repair flies to base and waits inside its shield.
+func repair(s *Ship, base *Base) uint16 {
+	dist := math.Hypot(base.X-s.X, base.Y-s.Y)
+	if dist < base.ShieldRadius/2 {
+		return 0 // Drag brings the ship to a stop
+	}
+	return steer(s, headingTo(s, base.X, base.Y), true)
+}
+
+// hunt closes in on enemy to AutopilotRange and requests a target lock
+// once no lock is held or in progress.
+func (a *Autopilot) hunt(g *Game, s *Ship, enemy *Enemy) uint16 {
+	ex, ey := enemy.X, enemy.Y+enemy.YOffset
+	dist := math.Hypot(ex-s.X, ey-s.Y)
+	heading := headingTo(s, ex, ey)
+
+	var keys uint16
+	switch {
+	case s.InBase:
+		// Weapons are offline inside a shield, so break out to fight
+		keys = steer(s, heading, true)
+	case dist > AutopilotRange:
+		keys = steer(s, heading, true)
+	case dist < AutopilotRange/2:
+		keys = steer(s, heading+math.Pi, true)
+	default:
+		keys = steer(s, heading, false)
+	}
+
+	// Lock is edge triggered, so release the key for a tick between presses
+	if s.Target == nil && s.LockingOn == nil && a.prevKeys&KeyLock == 0 &&
+		g.Camera.IsOnScreen(ex, ey, enemy.Radius) {
+		keys |= KeyLock
+	}
+	return keys
+}
+
+// steer turns s toward heading and thrusts once roughly aligned.
+func steer(s *Ship, heading float64, thrust bool) uint16 {
+	diff := math.Remainder(heading-s.Angle, 2*math.Pi)
+
+	var keys uint16
+	if diff > ShipRotationSpeed/2 {
+		keys |= KeyRight
+	} else if diff < -ShipRotationSpeed/2 {
+		keys |= KeyLeft
+	}
+	if thrust && math.Abs(diff) < math.Pi/4 {
+		keys |= KeyUp
+	}
+	return keys
+}
+
+func nearestEnemy(g *Game, s *Ship) *Enemy {
+	var nearest *Enemy
+	nearestDistSq := math.MaxFloat64
+	for _, e := range g.Enemies {
+		if !e.IsAlive() {
+			continue
+		}
+		dx, dy := e.X-s.X, e.Y+e.YOffset-s.Y
+		if distSq := dx*dx + dy*dy; distSq < nearestDistSq {
+			nearestDistSq = distSq
+			nearest = e
+		}
+	}
+	return nearest
+}
+
+func nearestBase(g *Game, s *Ship) *Base {
+	var nearest *Base
+	nearestDistSq := math.MaxFloat64
+	for _, b := range g.Bases {
+		dx, dy := b.X-s.X, b.Y-s.Y
+		if distSq := dx*dx + dy*dy; distSq < nearestDistSq {
+			nearestDistSq = distSq
+			nearest = b
+		}
+	}
+	return nearest
+}
+
+// headingTo returns the ship angle (0 = up, clockwise) facing (x, y).
+func headingTo(s *Ship, x, y float64) float64 {
+	return math.Atan2(x-s.X, -(y - s.Y))
+}
+
+// StartDemo leaves multiplayer and shows the title screen, behind which an
+// autopilot flies a fresh infinite world session at normal difficulty as an
+// attract mode demo.
+func (g *Game) StartDemo() {
+	g.LeaveMultiplayer()
+	g.Playback = nil
+	g.Mode = ModeInfinite
+	g.Difficulty = DifficultyNormal
+	g.Reset(g.GameSeed)
+	g.Demo = NewAutopilot()
+	g.Ship.Input = g.Demo
+	g.State = StateTitle
+}
+
+// StopDemo ends attract mode and starts a session for the player in the
+// mode picked on the title screen.
+func (g *Game) StopDemo() {
+	if g.Demo == nil {
+		return
+	}
+	g.StartMode(g.Title.Selected)
+}
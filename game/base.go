@@ -4,20 +4,22 @@ import (
 	"math"
 	"strconv"
 
-	"github.com/gopherjs/gopherjs/js"
+	"github.com/simukka/starship-sorades-13k/render"
 )
 
 // Base represents a stationary base with a protective shield.
 // Ships can enter the shield area, but enemies and torpedos cannot.
-// Bases are permanent and never despawn from the infinite world.
+// The starting base is permanent; the bases of world chunks come and go with
+// their chunk and are regenerated unchanged.
 type Base struct {
-	X, Y         float64    // Fixed world coordinates
-	Radius       float64    // Visual radius of the base structure
-	ShieldRadius float64    // Radius of the protective shield
-	ShieldPhase  float64    // Animation phase for shield effect
-	ImpactTimer  int        // Frames remaining for impact vibration
-	ImpactAngle  float64    // Angle of last impact for directional vibration
-	Image        *js.Object // Base sprite (optional)
+	X, Y         float64      // Fixed world coordinates
+	Radius       float64      // Visual radius of the base structure
+	ShieldRadius float64      // Radius of the protective shield
+	ShieldPhase  float64      // Animation phase for shield effect
+	ImpactTimer  int          // Frames remaining for impact vibration
+	ImpactAngle  float64      // Angle of last impact for directional vibration
+	Image        render.Image // Base sprite (optional)
+	Generated    bool         // Placed by world generation, see World
 }
 
 // NewBase creates a new base at the specified world coordinates.
@@ -53,11 +55,18 @@ func (b *Base) IsShipInside(s *Ship) bool {
 	return b.ContainsPoint(s.X, s.Y)
 }
 
-// Render draws the base and its shield.
-func (b *Base) Render(g *Game) {
+// Update advances the shield animation and the impact vibration by one tick.
+func (b *Base) Update() {
 	// Update shield animation phase
 	b.ShieldPhase += 0.02
 
+	if b.ImpactTimer > 0 {
+		b.ImpactTimer--
+	}
+}
+
+// Render draws the base and its shield.
+func (b *Base) Render(g *Game) {
 	// Convert world position to screen position
 	screenX, screenY := g.Camera.WorldToScreen(b.X, b.Y)
 
@@ -74,69 +83,68 @@ func (b *Base) Render(g *Game) {
 		// High frequency oscillation for vibration effect
 		vibrateX = math.Sin(float64(b.ImpactTimer)*2.5) * intensity * math.Cos(b.ImpactAngle)
 		vibrateY = math.Sin(float64(b.ImpactTimer)*2.5) * intensity * math.Sin(b.ImpactAngle)
-		b.ImpactTimer--
 	}
 
-	g.Ctx.Call("save")
+	g.Ctx.Save()
 
 	// Draw shield circle with glow effect
-	g.Ctx.Set("shadowBlur", Theme.ShieldShadowBlur)
-	g.Ctx.Set("shadowColor", Theme.BaseShieldGlowColor)
+	g.Ctx.SetShadowBlur(Theme.ShieldShadowBlur)
+	g.Ctx.SetShadowColor(Theme.BaseShieldGlowColor)
 
 	// Shield fill (semi-transparent) - with vibration offset
-	g.Ctx.Set("fillStyle", Theme.BaseShieldColor)
-	g.Ctx.Call("beginPath")
-	g.Ctx.Call("arc", screenX+vibrateX, screenY+vibrateY, b.ShieldRadius, 0, math.Pi*2)
-	g.Ctx.Call("fill")
+	g.Ctx.SetFillStyle(Theme.BaseShieldColor)
+	g.Ctx.BeginPath()
+	g.Ctx.Arc(screenX+vibrateX, screenY+vibrateY, b.ShieldRadius, 0, math.Pi*2)
+	g.Ctx.Fill()
 
 	// Shield border with pulsing effect - amplify pulse during impact
 	pulseAlpha := 0.4 + 0.3*math.Sin(b.ShieldPhase)
 	if b.ImpactTimer > 0 {
 		pulseAlpha = 0.7 + 0.3*math.Sin(float64(b.ImpactTimer)*0.5)
 	}
-	g.Ctx.Set("globalAlpha", pulseAlpha)
-	g.Ctx.Set("strokeStyle", Theme.BaseShieldGlowColor)
-	g.Ctx.Set("lineWidth", 2.0)
-	g.Ctx.Call("stroke")
+	g.Ctx.SetGlobalAlpha(pulseAlpha)
+	g.Ctx.SetStrokeStyle(Theme.BaseShieldGlowColor)
+	g.Ctx.SetLineWidth(2.0)
+	g.Ctx.Stroke()
 
 	// Inner shield ring - with vibration
-	g.Ctx.Set("globalAlpha", pulseAlpha*0.5)
-	g.Ctx.Call("beginPath")
-	g.Ctx.Call("arc", screenX+vibrateX*0.5, screenY+vibrateY*0.5, b.ShieldRadius*0.95, 0, math.Pi*2)
-	g.Ctx.Call("stroke")
+	g.Ctx.SetGlobalAlpha(pulseAlpha * 0.5)
+	g.Ctx.BeginPath()
+	g.Ctx.Arc(screenX+vibrateX*0.5, screenY+vibrateY*0.5, b.ShieldRadius*0.95, 0, math.Pi*2)
+	g.Ctx.Stroke()
 
-	g.Ctx.Set("globalAlpha", 1)
-	g.Ctx.Set("shadowBlur", 0)
+	g.Ctx.SetGlobalAlpha(1)
+	g.Ctx.SetShadowBlur(0)
 
 	// Draw base structure (simple hexagon shape)
-	// g.Ctx.Set("fillStyle", Theme.BaseColor)
-	// g.Ctx.Set("strokeStyle", Theme.BaseShieldGlowColor)
-	// g.Ctx.Set("lineWidth", 2.0)
+	// g.Ctx.SetFillStyle(Theme.BaseColor)
+	// g.Ctx.SetStrokeStyle(Theme.BaseShieldGlowColor)
+	// g.Ctx.SetLineWidth(2.0)
 
-	// g.Ctx.Call("beginPath")
+	// g.Ctx.BeginPath()
 	// for i := 0; i < 6; i++ {
 	// 	angle := float64(i)*math.Pi/3 - math.Pi/6
 	// 	px := screenX + math.Cos(angle)*b.Radius
 	// 	py := screenY + math.Sin(angle)*b.Radius
 	// 	if i == 0 {
-	// 		g.Ctx.Call("moveTo", px, py)
+	// 		g.Ctx.MoveTo(px, py)
 	// 	} else {
-	// 		g.Ctx.Call("lineTo", px, py)
+	// 		g.Ctx.LineTo(px, py)
 	// 	}
 	// }
-	// g.Ctx.Call("closePath")
-	// g.Ctx.Call("fill")
-	// g.Ctx.Call("stroke")
+	// g.Ctx.ClosePath()
+	// g.Ctx.Fill()
+	// g.Ctx.Stroke()
 
 	// Draw center glow
-	g.Ctx.Set("shadowBlur", 12)
-	g.Ctx.Set("shadowColor", Theme.BaseShieldGlowColor)
-	g.Ctx.Set("fillStyle", Theme.BaseShieldGlowColor)
-	g.Ctx.Call("beginPath")
-	g.Ctx.Call("arc", screenX, screenY, b.Radius*0.3, 0, math.Pi*2)
-	g.Ctx.Call("fill")
-
-	g.Ctx.Call("restore")
+	g.Ctx.SetShadowBlur(12)
+	g.Ctx.SetShadowColor(Theme.BaseShieldGlowColor)
+	g.Ctx.SetFillStyle(Theme.BaseShieldGlowColor)
+	g.Ctx.BeginPath()
+	g.Ctx.Arc(screenX, screenY, b.Radius*0.3, 0, math.Pi*2)
+	g.Ctx.Fill()
+
+	g.Ctx.Restore()
 }
 
 // BlocksTorpedo checks if a torpedo should be blocked by this base's shield.
@@ -339,46 +347,46 @@ func (g *Game) RenderBaseIndicators() {
 	distance := math.Sqrt(worldDx*worldDx + worldDy*worldDy)
 
 	// Draw the indicator arrow
-	g.Ctx.Call("save")
+	g.Ctx.Save()
 
 	// Pulsing effect
 	pulse := 0.7 + 0.3*math.Sin(base.ShieldPhase*2)
-	g.Ctx.Set("globalAlpha", pulse)
+	g.Ctx.SetGlobalAlpha(pulse)
 
 	// Draw arrow pointing toward base
-	g.Ctx.Call("translate", indicatorX, indicatorY)
-	g.Ctx.Call("rotate", angle)
+	g.Ctx.Translate(indicatorX, indicatorY)
+	g.Ctx.Rotate(angle)
 
 	// Arrow shape
 	arrowSize := 16.0
-	g.Ctx.Set("fillStyle", Theme.BaseShieldGlowColor)
-	g.Ctx.Set("shadowBlur", 8)
-	g.Ctx.Set("shadowColor", Theme.BaseShieldGlowColor)
+	g.Ctx.SetFillStyle(Theme.BaseShieldGlowColor)
+	g.Ctx.SetShadowBlur(8)
+	g.Ctx.SetShadowColor(Theme.BaseShieldGlowColor)
 
-	g.Ctx.Call("beginPath")
-	g.Ctx.Call("moveTo", arrowSize, 0)               // Tip
-	g.Ctx.Call("lineTo", -arrowSize/2, -arrowSize/2) // Top back
-	g.Ctx.Call("lineTo", -arrowSize/4, 0)            // Notch
-	g.Ctx.Call("lineTo", -arrowSize/2, arrowSize/2)  // Bottom back
-	g.Ctx.Call("closePath")
-	g.Ctx.Call("fill")
+	g.Ctx.BeginPath()
+	g.Ctx.MoveTo(arrowSize, 0)               // Tip
+	g.Ctx.LineTo(-arrowSize/2, -arrowSize/2) // Top back
+	g.Ctx.LineTo(-arrowSize/4, 0)            // Notch
+	g.Ctx.LineTo(-arrowSize/2, arrowSize/2)  // Bottom back
+	g.Ctx.ClosePath()
+	g.Ctx.Fill()
 
-	g.Ctx.Call("restore")
+	g.Ctx.Restore()
 
 	// Draw distance text
-	g.Ctx.Call("save")
-	g.Ctx.Set("globalAlpha", pulse)
-	g.Ctx.Set("fillStyle", Theme.BaseShieldGlowColor)
-	g.Ctx.Set("font", "12px "+Theme.ScoreFont)
-	g.Ctx.Set("textAlign", "center")
+	g.Ctx.Save()
+	g.Ctx.SetGlobalAlpha(pulse)
+	g.Ctx.SetFillStyle(Theme.BaseShieldGlowColor)
+	g.Ctx.SetFont("12px " + Theme.ScoreFont)
+	g.Ctx.SetTextAlign("center")
 
 	// Position text slightly offset from arrow
 	textOffsetX := -math.Cos(angle) * 25
 	textOffsetY := -math.Sin(angle) * 25
 	distText := formatDistance(distance)
-	g.Ctx.Call("fillText", distText, indicatorX+textOffsetX, indicatorY+textOffsetY+4)
+	g.Ctx.FillText(distText, indicatorX+textOffsetX, indicatorY+textOffsetY+4, 0)
 
-	g.Ctx.Call("restore")
+	g.Ctx.Restore()
 }
 
 // formatDistance formats a distance value for display.
@@ -0,0 +1,349 @@
+package game
+
+import "math"
+
+// Behavior is what sets an enemy kind apart: how it moves, when and how it
+// fires and how it is drawn. Enemy.Update and Enemy.Render take care of
+// everything kinds share (choosing a target, despawning, dying, the health
+// bar and the lock reticle) and call the behavior of the enemy's kind for
+// the rest, so a new kind only needs a Behavior and RegisterBehavior.
+//
+// Behaviors run in the simulation and must be deterministic: randomness has
+// to come from g.GameRNG. Render must not change the enemy.
+type Behavior interface {
+	// Update moves the enemy for one tick. e.Target is the nearest ship,
+	// or nil if there is none.
+	Update(g *Game, e *Enemy)
+	// Fire is called once per tick after Update and reports whether the
+	// enemy fired.
+	Fire(g *Game, e *Enemy) bool
+	// Render draws the enemy centered on screen position (x, y).
+	Render(g *Game, e *Enemy, x, y float64)
+}
+
+// EnemyFollowSpeed is how fast fighters close in on their target.
+const EnemyFollowSpeed = 1.5
+
+// Tuning of the kamikaze, mine layer and carrier.
+const (
+	// KamikazeR is the size of a kamikaze, smaller than a fighter.
+	KamikazeR = float64(ShipR) * 0.75
+	// KamikazeSpeed is the top speed of a kamikaze, well below the ship's.
+	KamikazeSpeed = 7.0
+	// KamikazeSteer is the share of its course a kamikaze corrects per tick;
+	// lower values make it easier to sidestep.
+	KamikazeSteer = 0.04
+	// KamikazeDamage is the damage a kamikaze does to the ship it rams.
+	KamikazeDamage = 30
+
+	// MineLayerSpeed is how fast a mine layer circles its target.
+	MineLayerSpeed = 2.5
+	// MineLayerRange is the distance a mine layer circles its target at.
+	MineLayerRange = HEIGHT / 2.0
+	// MineLayerInterval is the number of ticks between two mines.
+	MineLayerInterval = 75
+
+	// CarrierR is the size of a carrier, between a fighter and a boss.
+	CarrierR = float64(ShipR) * 1.5
+	// CarrierSpeed is how fast a carrier closes in on its target.
+	CarrierSpeed = 1.0
+	// CarrierRange is the distance a carrier keeps from its target.
+	CarrierRange = WIDTH / 3.0
+	// CarrierInterval is the number of ticks between two launches.
+	CarrierInterval = 150
+	// CarrierLaunches is how many fighters a carrier holds.
+	CarrierLaunches = 6
+)
+
+// enemyBehaviors holds the behavior of every enemy kind.
+var enemyBehaviors = map[EnemyKind]Behavior{
+	SmallFighter:  Fighter{Speed: EnemyFollowSpeed},
+	MediumFighter: Fighter{Speed: EnemyFollowSpeed},
+	TurretFighter: Fighter{Speed: EnemyFollowSpeed},
+	Boss:          BossFight{Speed: EnemyFollowSpeed},
+	Kamikaze:      Rammer{Speed: KamikazeSpeed, Steer: KamikazeSteer},
+	MineLayer:     MineDropper{Speed: MineLayerSpeed, Range: MineLayerRange},
+	Carrier:       Launcher{Speed: CarrierSpeed, Range: CarrierRange},
+}
+
+// RegisterBehavior sets the behavior of an enemy kind, replacing the one it
+// had before.
+func RegisterBehavior(kind EnemyKind, b Behavior) {
+	enemyBehaviors[kind] = b
+}
+
+// BehaviorFor returns the behavior of an enemy kind. Kinds without one
+// behave like fighters.
+func BehaviorFor(kind EnemyKind) Behavior {
+	if b, ok := enemyBehaviors[kind]; ok {
+		return b
+	}
+	return Fighter{Speed: EnemyFollowSpeed}
+}
+
+// Fighter is the behavior of the original enemy kinds: it follows its
+// target at Speed until it is two radii away, fires a torpedo at the target
+// whenever its FireTimer runs out and is drawn as its sprite, rotated to
+// face the target.
+type Fighter struct {
+	Speed float64
+}
+
+// Update implements Behavior.
+func (f Fighter) Update(g *Game, e *Enemy) {
+	if e.Target == nil {
+		e.VelX, e.VelY = 0, 0
+		return
+	}
+
+	dx := e.Target.X - e.X
+	dy := e.Target.Y - (e.Y + e.YOffset)
+	dist := math.Sqrt(dx*dx + dy*dy)
+	if dist <= e.Radius*2 { // Don't get too close
+		e.VelX, e.VelY = 0, 0
+		return
+	}
+	e.MoveBy(g, (dx/dist)*f.Speed, (dy/dist)*f.Speed)
+}
+
+// Fire implements Behavior.
+func (f Fighter) Fire(g *Game, e *Enemy) bool {
+	e.FireTimer--
+	if e.FireTimer > 0 {
+		// need to wait
+		return false
+	}
+
+	e.FireTimer = g.Difficulty.FireInterval(max(5, 600/(g.Level.LevelNum+4)))
+
+	torpedo := g.Bullets.AcquireKind(TorpedoBullet)
+	if torpedo == nil {
+		return false
+	}
+
+	// Every fighter uses sound 20, the medium enemy's shot
+	g.Audio.PlayWithPan(20,
+		torpedo.AudioPan(),
+		torpedo.DistanceVolume(e.Target.X, e.Target.Y, float64(HEIGHT)))
+
+	torpedo.X = math.Floor(e.X)
+	torpedo.Y = e.Y + e.YOffset
+
+	speed := g.Difficulty.TorpedoSpeed(e.Radius / 2)
+	torpedo.XAcc = math.Sin(e.TargetAngle()) * speed
+	torpedo.YAcc = math.Cos(e.TargetAngle()) * speed
+
+	torpedo.E = 0
+
+	return true
+}
+
+// Render implements Behavior.
+func (f Fighter) Render(g *Game, e *Enemy, x, y float64) {
+	renderSprite(g, e, x, y)
+}
+
+// Rammer flies straight at its target and rams it, destroying itself. It
+// does not turn on the spot: every tick it corrects its course by Steer
+// towards the target, so a ship that changes direction late can sidestep
+// it.
+type Rammer struct {
+	Speed float64
+	Steer float64
+}
+
+// Update implements Behavior.
+func (r Rammer) Update(g *Game, e *Enemy) {
+	if e.Target == nil {
+		e.VelX, e.VelY = 0, 0
+		return
+	}
+
+	dx := e.Target.X - e.X
+	dy := e.Target.Y - (e.Y + e.YOffset)
+	dist := math.Sqrt(dx*dx + dy*dy)
+	if dist <= e.Radius {
+		// Rammed - blows up, but earns the rammed ship nothing
+		e.Target.Hurt(g, KamikazeDamage)
+		e.Health = 0
+		e.Spent = true
+		e.VelX, e.VelY = 0, 0
+		return
+	}
+
+	vx := e.VelX + ((dx/dist)*r.Speed-e.VelX)*r.Steer
+	vy := e.VelY + ((dy/dist)*r.Speed-e.VelY)*r.Steer
+	e.MoveBy(g, vx, vy)
+}
+
+// Fire implements Behavior. Rammers do not fire.
+func (r Rammer) Fire(g *Game, e *Enemy) bool {
+	return false
+}
+
+// Render implements Behavior.
+func (r Rammer) Render(g *Game, e *Enemy, x, y float64) {
+	renderSprite(g, e, x, y)
+}
+
+// MineDropper circles its target at Range and drops a mine every
+// MineLayerInterval ticks, fencing the ship in.
+type MineDropper struct {
+	Speed float64
+	Range float64
+}
+
+// Update implements Behavior.
+func (m MineDropper) Update(g *Game, e *Enemy) {
+	if e.Target == nil {
+		e.VelX, e.VelY = 0, 0
+		return
+	}
+
+	dx := e.Target.X - e.X
+	dy := e.Target.Y - (e.Y + e.YOffset)
+	dist := math.Sqrt(dx*dx + dy*dy)
+	if dist == 0 {
+		return
+	}
+
+	// Circle the target, closing in or backing off towards Range
+	radial := math.Max(-1, math.Min(1, (dist-m.Range)/m.Range))
+	vx := (dx*radial - dy) / dist
+	vy := (dy*radial + dx) / dist
+	speed := math.Sqrt(vx*vx + vy*vy)
+	e.MoveBy(g, vx/speed*m.Speed, vy/speed*m.Speed)
+}
+
+// Fire implements Behavior. Drops a mine where the enemy is.
+func (m MineDropper) Fire(g *Game, e *Enemy) bool {
+	e.FireTimer--
+	if e.FireTimer > 0 {
+		return false
+	}
+	e.FireTimer = g.Difficulty.FireInterval(MineLayerInterval)
+
+	mine := g.Bullets.AcquireKind(MineBullet)
+	if mine == nil {
+		return false
+	}
+	mine.X = e.X
+	mine.Y = e.Y + e.YOffset
+	mine.T = MineLifetime
+
+	g.Audio.PlayWithPan(20, e.AudioPan(),
+		e.DistanceVolume(e.Target.X, e.Target.Y, float64(HEIGHT))*0.5)
+	return true
+}
+
+// Render implements Behavior.
+func (m MineDropper) Render(g *Game, e *Enemy, x, y float64) {
+	renderSprite(g, e, x, y)
+}
+
+// Launcher closes in on its target until it is Range away and launches a
+// small fighter every CarrierInterval ticks, CarrierLaunches in all.
+type Launcher struct {
+	Speed float64
+	Range float64
+}
+
+// Update implements Behavior.
+func (l Launcher) Update(g *Game, e *Enemy) {
+	if e.Target == nil {
+		e.VelX, e.VelY = 0, 0
+		return
+	}
+
+	dx := e.Target.X - e.X
+	dy := e.Target.Y - (e.Y + e.YOffset)
+	dist := math.Sqrt(dx*dx + dy*dy)
+	if dist <= l.Range {
+		e.VelX, e.VelY = 0, 0
+		return
+	}
+	e.MoveBy(g, (dx/dist)*l.Speed, (dy/dist)*l.Speed)
+}
+
+// Fire implements Behavior. Launches a small fighter from the enemy with
+// the base health of its kind.
+func (l Launcher) Fire(g *Game, e *Enemy) bool {
+	if e.Launched >= CarrierLaunches {
+		return false
+	}
+	e.FireTimer--
+	if e.FireTimer > 0 {
+		return false
+	}
+	e.FireTimer = g.Difficulty.FireInterval(CarrierInterval)
+
+	cfg, ok := g.Spawns.Enemies[SmallFighter]
+	if !ok {
+		return false
+	}
+	fighter := g.NewEnemy(SmallFighter, e.X, e.Y+e.YOffset, cfg.HealthBase)
+	fighter.Angle = e.Angle
+	g.Enemies = append(g.Enemies, fighter)
+	e.Launched++
+	return true
+}
+
+// Render implements Behavior. The number of fighters left is shown as
+// lights along the hull.
+func (l Launcher) Render(g *Game, e *Enemy, x, y float64) {
+	renderSprite(g, e, x, y)
+
+	left := CarrierLaunches - e.Launched
+	g.Ctx.Save()
+	g.Ctx.SetFillStyle(Theme.EnemyCarrierGlow)
+	for i := 0; i < left; i++ {
+		lx := x + (float64(i)-float64(CarrierLaunches-1)/2)*e.Radius/4
+		g.Ctx.FillRect(lx-2, y+e.Radius*0.6, 4, 4)
+	}
+	g.Ctx.Restore()
+}
+
+// renderSprite draws the enemy sprite centered on (x, y), rotated by the
+// enemy angle.
+func renderSprite(g *Game, e *Enemy, x, y float64) {
+	g.Ctx.Save()
+	g.Ctx.Translate(x, y)
+	g.Ctx.Rotate(e.Angle)
+	g.Ctx.DrawImageScaled(e.Image,
+		-e.Radius, -e.Radius, e.Radius*2, e.Radius*2)
+	g.Ctx.Restore()
+}
+
+// MoveBy moves the enemy by (vx, vy) unless that would take it into a base
+// shield. Several points around the enemy are checked so that it cannot
+// slip through the shield edge. An obstacle in the way turns the move to
+// pass it. The velocity is kept for predictive targeting; it is zero when
+// the move was blocked. Reports whether the enemy moved.
+func (e *Enemy) MoveBy(g *Game, vx, vy float64) bool {
+	vx, vy, ok := g.steerAroundObstacles(e.X, e.Y+e.YOffset, e.Radius*0.6, vx, vy)
+	if !ok {
+		e.VelX, e.VelY = 0, 0
+		return false
+	}
+
+	newX := e.X + vx
+	newY := e.Y + vy
+
+	newEnemyY := newY + e.YOffset
+	checkRadius := e.Radius * 0.8
+	for _, base := range g.Bases {
+		// Check center and cardinal directions
+		if base.ContainsPoint(newX, newEnemyY) ||
+			base.ContainsPoint(newX, newEnemyY-checkRadius) || // top
+			base.ContainsPoint(newX, newEnemyY+checkRadius) || // bottom
+			base.ContainsPoint(newX-checkRadius, newEnemyY) || // left
+			base.ContainsPoint(newX+checkRadius, newEnemyY) { // right
+			e.VelX, e.VelY = 0, 0
+			return false
+		}
+	}
+
+	e.VelX, e.VelY = vx, vy
+	e.X, e.Y = newX, newY
+	return true
+}
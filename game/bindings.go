@@ -0,0 +1,292 @@
+package game
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+)
+
+// Action is something a key can be bound to.
+type Action int
+
+// Actions in the order the rebinding screen lists them.
+const (
+	ActionRotateLeft Action = iota
+	ActionRotateRight
+	ActionThrust
+	ActionReverse
+	ActionFire
+	ActionLock
+	ActionNextTarget
+	ActionSwitchWeapon
+	ActionShop
+	ActionRadar
+	ActionMouseSteering
+	ActionPause
+	ActionFullscreen
+	ActionStats
+	ActionBindings
+
+	// ActionCount is the number of actions.
+	ActionCount
+)
+
+// actionInfo describes an action: its stable ID in saved bindings, its
+// label on the rebinding screen and the ship control it drives, if any.
+var actionInfo = [ActionCount]struct {
+	id, name string
+	key      uint16
+}{
+	ActionRotateLeft:    {"rotate-left", "ROTATE LEFT", KeyLeft},
+	ActionRotateRight:   {"rotate-right", "ROTATE RIGHT", KeyRight},
+	ActionThrust:        {"thrust", "THRUST", KeyUp},
+	ActionReverse:       {"reverse", "REVERSE", KeyDown},
+	ActionFire:          {"fire", "FIRE", KeyFire},
+	ActionLock:          {"lock", "LOCK TARGET", KeyLock},
+	ActionNextTarget:    {"next-target", "NEXT TARGET", KeyNextTarget},
+	ActionSwitchWeapon:  {"switch-weapon", "SWITCH WEAPON", KeySwitchWeapon},
+	ActionShop:          {"shop", "BASE SHOP", 0},
+	ActionRadar:         {"radar", "RADAR RANGE", 0},
+	ActionMouseSteering: {"mouse-steering", "MOUSE STEERING", 0},
+	ActionPause:         {"pause", "PAUSE", 0},
+	ActionFullscreen:    {"fullscreen", "FULLSCREEN", 0},
+	ActionStats:         {"stats", "STATS OVERLAY", 0},
+	ActionBindings:      {"bindings", "CONTROLS", 0},
+}
+
+// String returns the label of a on the rebinding screen.
+func (a Action) String() string {
+	if a < 0 || a >= ActionCount {
+		return "?"
+	}
+	return actionInfo[a].name
+}
+
+// ShipKey returns the control bit (KeyLeft...KeySwitchWeapon) a drives, or 0
+// for actions that do not steer the ship.
+func (a Action) ShipKey() uint16 {
+	if a < 0 || a >= ActionCount {
+		return 0
+	}
+	return actionInfo[a].key
+}
+
+// Bindings maps key codes (KeyboardEvent.keyCode) to actions. A key has at
+// most one action; an action can have any number of keys.
+type Bindings map[int]Action
+
+// DefaultBindings returns the classic layout: cursor keys, WASD, IJKL and
+// the numpad steer, X, Space, C, Y, Z and 0 fire, T locks, R cycles the
+// lock, Q switches weapons, B opens the shop while docked, N cycles the
+// radar range, V toggles mouse steering, P or Esc pauses, F goes
+// fullscreen, F10 shows stats and F2 opens the rebinding screen.
+func DefaultBindings() Bindings {
+	return Bindings{
+		37: ActionRotateLeft, 65: ActionRotateLeft, 74: ActionRotateLeft, 52: ActionRotateLeft,
+		39: ActionRotateRight, 68: ActionRotateRight, 76: ActionRotateRight, 54: ActionRotateRight,
+		38: ActionThrust, 87: ActionThrust, 73: ActionThrust, 56: ActionThrust,
+		40: ActionReverse, 83: ActionReverse, 75: ActionReverse, 50: ActionReverse, 53: ActionReverse,
+		88: ActionFire, 32: ActionFire, 67: ActionFire, 89: ActionFire, 90: ActionFire, 48: ActionFire,
+		84:  ActionLock,
+		82:  ActionNextTarget,
+		81:  ActionSwitchWeapon,
+		66:  ActionShop,
+		78:  ActionRadar,
+		86:  ActionMouseSteering,
+		80:  ActionPause,
+		27:  ActionPause,
+		70:  ActionFullscreen,
+		121: ActionStats,
+		113: ActionBindings,
+	}
+}
+
+// Lookup returns the action bound to keyCode.
+func (b Bindings) Lookup(keyCode int) (Action, bool) {
+	a, ok := b[keyCode]
+	return a, ok
+}
+
+// KeysFor returns the key codes bound to a in ascending order.
+func (b Bindings) KeysFor(a Action) []int {
+	var keys []int
+	for k, action := range b {
+		if action == a {
+			keys = append(keys, k)
+		}
+	}
+	sort.Ints(keys)
+	return keys
+}
+
+// Bind binds keyCode to a, taking it away from the action it had before.
+func (b Bindings) Bind(a Action, keyCode int) {
+	b[keyCode] = a
+}
+
+// Clear removes every key bound to a.
+func (b Bindings) Clear(a Action) {
+	for _, k := range b.KeysFor(a) {
+		delete(b, k)
+	}
+}
+
+// MarshalJSON encodes the bindings as an object of action IDs keyed by key
+// code, e.g. {"37":"rotate-left"}, so saved bindings survive reordering
+// of the Action constants.
+func (b Bindings) MarshalJSON() ([]byte, error) {
+	m := make(map[string]string, len(b))
+	for k, a := range b {
+		if a >= 0 && a < ActionCount {
+			m[strconv.Itoa(k)] = actionInfo[a].id
+		}
+	}
+	return json.Marshal(m)
+}
+
+// UnmarshalJSON decodes bindings written by MarshalJSON. Unknown actions
+// and malformed key codes are skipped, so a binding table saved by a newer
+// version still loads.
+func (b *Bindings) UnmarshalJSON(data []byte) error {
+	var m map[string]string
+	if err := json.Unmarshal(data, &m); err != nil {
+		return err
+	}
+
+	ids := make(map[string]Action, ActionCount)
+	for a := Action(0); a < ActionCount; a++ {
+		ids[actionInfo[a].id] = a
+	}
+
+	*b = make(Bindings, len(m))
+	for key, id := range m {
+		k, err := strconv.Atoi(key)
+		a, ok := ids[id]
+		if err != nil || !ok {
+			continue
+		}
+		(*b)[k] = a
+	}
+	return nil
+}
+
+// KeyName returns a short label for a key code on the rebinding screen.
+func KeyName(keyCode int) string {
+	switch {
+	case keyCode >= 65 && keyCode <= 90, keyCode >= 48 && keyCode <= 57:
+		return string(rune(keyCode))
+	case keyCode >= 96 && keyCode <= 105:
+		return "NUM " + strconv.Itoa(keyCode-96)
+	case keyCode >= 112 && keyCode <= 123:
+		return "F" + strconv.Itoa(keyCode-111)
+	}
+	if name, ok := keyNames[keyCode]; ok {
+		return name
+	}
+	return "KEY " + strconv.Itoa(keyCode)
+}
+
+var keyNames = map[int]string{
+	8:   "BACKSPACE",
+	9:   "TAB",
+	13:  "ENTER",
+	16:  "SHIFT",
+	17:  "CTRL",
+	18:  "ALT",
+	27:  "ESC",
+	32:  "SPACE",
+	37:  "LEFT",
+	38:  "UP",
+	39:  "RIGHT",
+	40:  "DOWN",
+	186: ";",
+	188: ",",
+	190: ".",
+	191: "/",
+	219: "[",
+	221: "]",
+}
+
+// Keys the rebinding screen is operated with. They are fixed, so the screen
+// stays usable whatever the bindings are.
+const (
+	bindingsKeyUp     = 38 // Up arrow
+	bindingsKeyDown   = 40 // Down arrow
+	bindingsKeyRebind = 13 // Enter
+	bindingsKeyClear  = 46 // Delete
+	bindingsKeyReset  = 36 // Home
+	bindingsKeyClose  = 27 // Esc
+)
+
+// BindingsScreen is the in-game rebinding screen. It lists every action
+// with its keys; Enter waits for the next key press and binds it to the
+// selected action, Delete clears the action, Home restores the defaults and
+// Esc closes the screen (or cancels waiting for a key).
+//
+// The action that opens the screen always keeps at least one key, so the
+// screen can always be reached again.
+type BindingsScreen struct {
+	Visible  bool
+	Selected Action
+	Waiting  bool // The next key press is bound to Selected
+}
+
+// NewBindingsScreen creates a closed rebinding screen.
+func NewBindingsScreen() *BindingsScreen {
+	return &BindingsScreen{}
+}
+
+// Open shows the screen with the first action selected.
+func (s *BindingsScreen) Open() {
+	s.Visible = true
+	s.Selected = 0
+	s.Waiting = false
+}
+
+// HandleKey processes a key press while the screen is visible and reports
+// whether b changed.
+func (s *BindingsScreen) HandleKey(b Bindings, keyCode int) bool {
+	if s.Waiting {
+		s.Waiting = false
+		if keyCode == bindingsKeyClose || s.losesLastKey(b, keyCode) {
+			return false
+		}
+		if old, ok := b.Lookup(keyCode); ok && old == s.Selected {
+			return false
+		}
+		b.Bind(s.Selected, keyCode)
+		return true
+	}
+
+	switch keyCode {
+	case bindingsKeyUp:
+		s.Selected = (s.Selected + ActionCount - 1) % ActionCount
+	case bindingsKeyDown:
+		s.Selected = (s.Selected + 1) % ActionCount
+	case bindingsKeyRebind:
+		s.Waiting = true
+	case bindingsKeyClear:
+		if s.Selected != ActionBindings && len(b.KeysFor(s.Selected)) > 0 {
+			b.Clear(s.Selected)
+			return true
+		}
+	case bindingsKeyReset:
+		for k := range b {
+			delete(b, k)
+		}
+		for k, a := range DefaultBindings() {
+			b[k] = a
+		}
+		return true
+	case bindingsKeyClose:
+		s.Visible = false
+	}
+	return false
+}
+
+// losesLastKey reports whether binding keyCode elsewhere would leave the
+// screen itself without a key.
+func (s *BindingsScreen) losesLastKey(b Bindings, keyCode int) bool {
+	keys := b.KeysFor(ActionBindings)
+	return s.Selected != ActionBindings && len(keys) == 1 && keys[0] == keyCode
+}
@@ -0,0 +1,291 @@
+package game
+
+import (
+	"math"
+
+	"github.com/simukka/starship-sorades-13k/audio"
+)
+
+// BossPartKind is the role of a boss part.
+type BossPartKind uint8
+
+const (
+	// PartTurret fires torpedoes of its own at the boss's target.
+	PartTurret BossPartKind = iota
+	// PartShieldGenerator keeps the boss core from taking damage.
+	PartShieldGenerator
+)
+
+// Boss phases. A boss starts shielded, loses its shield with the shield
+// generator and fights on alone once every turret is gone as well.
+const (
+	BossPhaseShielded = iota // Shield generator up, core immune
+	BossPhaseExposed         // Shield generator destroyed
+	BossPhaseEnraged         // Every part destroyed; the core fires faster and in spreads
+)
+
+// Boss tuning.
+const (
+	// BossTurretInterval is the number of ticks between two turret shots.
+	BossTurretInterval = 45
+	// BossSpreadAngle is the angle between the torpedoes of an enraged
+	// boss's spread.
+	BossSpreadAngle = math.Pi / 10
+	// BossEnragedSpeed scales the speed of an enraged boss.
+	BossEnragedSpeed = 1.6
+)
+
+// BossPart is a hit-testable part of a boss with its own health. Its offset
+// is relative to the center of the boss sprite and turns with the boss.
+type BossPart struct {
+	Kind             BossPartKind
+	OffsetX, OffsetY float64
+	Radius           float64
+	Health           int
+	MaxHealth        int
+	OSD              int // On-screen display timer for health bar
+	FireTimer        int
+	Destroyed        bool // The destruction has been shown
+}
+
+// IsAlive reports whether the part still has health.
+func (p *BossPart) IsAlive() bool {
+	return p.Health > 0
+}
+
+// RenderHealthBar renders the part's health bar below the part centered on
+// screen position (x, y).
+func (p *BossPart) RenderHealthBar(g *Game, x, y float64) {
+	renderHealthBar(g, x, y, p.Radius, p.Health, p.MaxHealth, p.OSD)
+}
+
+// newBossParts lays out the parts of a boss of size r: a turret on each
+// wing and the shield generator above the core. Part health scales with
+// the boss health.
+func newBossParts(r float64, health int) []*BossPart {
+	turret := maxInt(health/3, 1)
+	generator := maxInt(health/2, 1)
+	return []*BossPart{
+		{Kind: PartTurret, OffsetX: -r * 0.57, OffsetY: r * 0.05, Radius: r * 0.15,
+			Health: turret, MaxHealth: turret, FireTimer: BossTurretInterval},
+		{Kind: PartTurret, OffsetX: r * 0.57, OffsetY: r * 0.05, Radius: r * 0.15,
+			Health: turret, MaxHealth: turret, FireTimer: BossTurretInterval * 3 / 2},
+		{Kind: PartShieldGenerator, OffsetY: -r * 0.4, Radius: r * 0.18,
+			Health: generator, MaxHealth: generator},
+	}
+}
+
+// PartPosition returns the world position of a part of e.
+func (e *Enemy) PartPosition(p *BossPart) (x, y float64) {
+	sin, cos := math.Sincos(e.Angle)
+	return e.X + p.OffsetX*cos - p.OffsetY*sin,
+		e.Y + e.YOffset + p.OffsetX*sin + p.OffsetY*cos
+}
+
+// Shielded reports whether the shield generator of e is still up. Enemies
+// without parts are never shielded.
+func (e *Enemy) Shielded() bool {
+	for _, p := range e.Parts {
+		if p.Kind == PartShieldGenerator && p.IsAlive() {
+			return true
+		}
+	}
+	return false
+}
+
+// BossPhase returns the attack phase of e from the parts it has lost.
+func (e *Enemy) BossPhase() int {
+	if e.Shielded() {
+		return BossPhaseShielded
+	}
+	for _, p := range e.Parts {
+		if p.IsAlive() {
+			return BossPhaseExposed
+		}
+	}
+	return BossPhaseEnraged
+}
+
+// partAt returns the first live part of e within reach of its edge from
+// (x, y), or nil.
+func (e *Enemy) partAt(x, y, reach float64) *BossPart {
+	for _, p := range e.Parts {
+		if !p.IsAlive() {
+			continue
+		}
+		px, py := e.PartPosition(p)
+		if math.Hypot(x-px, y-py) <= p.Radius+reach {
+			return p
+		}
+	}
+	return nil
+}
+
+// hitPart applies a player hit at (x, y) to part p of e.
+func (e *Enemy) hitPart(g *Game, p *BossPart, x, y float64, damage int) {
+	g.Level.P++
+	g.Explode(x, y, 0)
+	p.Health -= damage
+	p.OSD = ShipMaxOSD
+	g.Audio.PlayWithPan(9,
+		e.AudioPan(),
+		e.DistanceVolume(g.Ship.X, g.Ship.Y, float64(HEIGHT)))
+}
+
+// UpdateBossPhase shows the parts of e that were destroyed since the last
+// tick and, when e enters a new phase, announces it and switches to the
+// music preset of the phase. It runs on the host from the boss behavior
+// and on clients after every world state, so everyone hears the change.
+func (g *Game) UpdateBossPhase(e *Enemy) {
+	if len(e.Parts) == 0 {
+		return
+	}
+	for _, p := range e.Parts {
+		if p.IsAlive() || p.Destroyed {
+			continue
+		}
+		p.Destroyed = true
+		px, py := e.PartPosition(p)
+		g.Explode(px, py, p.Radius*2)
+		g.Audio.PlayWithPan(10,
+			e.AudioPan(),
+			e.DistanceVolume(g.Ship.X, g.Ship.Y, float64(HEIGHT)))
+	}
+
+	phase := e.BossPhase()
+	if phase == e.Phase {
+		return
+	}
+	e.Phase = phase
+	g.Audio.SetMusicPreset(audio.BossMusicPreset + phase)
+	switch phase {
+	case BossPhaseExposed:
+		g.SpawnText("SHIELD DOWN", 60)
+	case BossPhaseEnraged:
+		g.SpawnText("BOSS ENRAGED", 60)
+	}
+}
+
+// endBossMusic hands the music back to the level when e was the last boss
+// with parts.
+func (g *Game) endBossMusic(e *Enemy) {
+	for _, other := range g.Enemies {
+		if other != e && len(other.Parts) > 0 && other.IsAlive() {
+			return
+		}
+	}
+	g.Audio.SetMusicPreset(g.Level.LevelNum)
+}
+
+// BossFight is the behavior of multi-part bosses. It follows its target
+// like a fighter and fires from the core; its turrets fire on their own
+// until destroyed. Losing the shield generator makes the core fire twice as
+// often, and once every part is gone it moves faster and fires spreads of
+// three torpedoes.
+type BossFight struct {
+	Speed float64
+}
+
+// Update implements Behavior.
+func (b BossFight) Update(g *Game, e *Enemy) {
+	g.UpdateBossPhase(e)
+	for _, p := range e.Parts {
+		if p.OSD > 0 {
+			p.OSD--
+		}
+	}
+
+	speed := b.Speed
+	if e.Phase == BossPhaseEnraged {
+		speed *= BossEnragedSpeed
+	}
+	Fighter{Speed: speed}.Update(g, e)
+}
+
+// Fire implements Behavior.
+func (b BossFight) Fire(g *Game, e *Enemy) bool {
+	fired := false
+	for _, p := range e.Parts {
+		if p.Kind != PartTurret || !p.IsAlive() {
+			continue
+		}
+		if p.FireTimer--; p.FireTimer > 0 {
+			continue
+		}
+		p.FireTimer = g.Difficulty.FireInterval(BossTurretInterval)
+		px, py := e.PartPosition(p)
+		angle := CalculateTargetAngle(px, py, e.Target.X, e.Target.Y)
+		if g.fireTorpedo(px, py, angle, float64(ShipR)/2) != nil {
+			g.Audio.PlayWithPan(12, e.AudioPan(),
+				e.DistanceVolume(e.Target.X, e.Target.Y, float64(HEIGHT)))
+			fired = true
+		}
+	}
+
+	if !(Fighter{}).Fire(g, e) {
+		return fired
+	}
+	if e.Phase >= BossPhaseExposed {
+		e.FireTimer = maxInt(e.FireTimer/2, 1)
+	}
+	if e.Phase == BossPhaseEnraged {
+		x, y := math.Floor(e.X), e.Y+e.YOffset
+		for _, d := range []float64{-BossSpreadAngle, BossSpreadAngle} {
+			g.fireTorpedo(x, y, e.TargetAngle()+d, e.Radius/2)
+		}
+	}
+	return true
+}
+
+// Render implements Behavior. Live parts are ringed, the shield is drawn
+// around the core while the generator is up, and every part shows its own
+// health bar after a hit.
+func (b BossFight) Render(g *Game, e *Enemy, x, y float64) {
+	renderSprite(g, e, x, y)
+
+	g.Ctx.Save()
+	g.Ctx.SetLineWidth(Theme.EnemyLineWidth)
+	g.Ctx.SetShadowBlur(Theme.DefaultShadowBlur)
+	g.Ctx.SetStrokeStyle(Theme.EnemyBossAccent)
+	g.Ctx.SetShadowColor(Theme.EnemyBossAccent)
+	for _, p := range e.Parts {
+		if !p.IsAlive() {
+			continue
+		}
+		px, py := e.PartPosition(p)
+		sx, sy := g.Camera.WorldToScreen(px, py)
+		g.Ctx.BeginPath()
+		g.Ctx.Arc(sx, sy, p.Radius, 0, math.Pi*2)
+		g.Ctx.Stroke()
+	}
+	if e.Shielded() {
+		g.Ctx.SetStrokeStyle(Theme.BaseShieldBorder)
+		g.Ctx.SetShadowColor(Theme.BaseShieldGlowColor)
+		g.Ctx.BeginPath()
+		g.Ctx.Arc(x, y, e.Radius*0.9, 0, math.Pi*2)
+		g.Ctx.Stroke()
+	}
+	g.Ctx.Restore()
+
+	for _, p := range e.Parts {
+		if p.OSD > 0 && p.IsAlive() {
+			px, py := e.PartPosition(p)
+			sx, sy := g.Camera.WorldToScreen(px, py)
+			p.RenderHealthBar(g, sx, sy)
+		}
+	}
+}
+
+// fireTorpedo launches a torpedo from (x, y) at angle (0 = down) with the
+// given speed, scaled by the difficulty.
+func (g *Game) fireTorpedo(x, y, angle, speed float64) *Bullet {
+	torpedo := g.Bullets.AcquireKind(TorpedoBullet)
+	if torpedo == nil {
+		return nil
+	}
+	speed = g.Difficulty.TorpedoSpeed(speed)
+	torpedo.X, torpedo.Y = x, y
+	torpedo.XAcc = math.Sin(angle) * speed
+	torpedo.YAcc = math.Cos(angle) * speed
+	return torpedo
+}
@@ -0,0 +1,192 @@
+package game
+
+import (
+	"strconv"
+
+	"github.com/simukka/starship-sorades-13k/common"
+)
+
+// GameMode selects how enemies are spawned.
+type GameMode uint8
+
+const (
+	// ModeInfinite spawns enemies continuously, scaling with the score.
+	ModeInfinite GameMode = iota
+	// ModeCampaign plays the 13 authored waves of the original game.
+	ModeCampaign
+)
+
+// String returns the name of m as used by the JavaScript API.
+func (m GameMode) String() string {
+	if m == ModeCampaign {
+		return "campaign"
+	}
+	return "infinite"
+}
+
+// ParseGameMode returns the mode named s, defaulting to ModeInfinite.
+func ParseGameMode(s string) GameMode {
+	if s == "campaign" {
+		return ModeCampaign
+	}
+	return ModeInfinite
+}
+
+// Campaign tuning.
+const (
+	// CampaignIntroTicks is how long a wave title is shown before the wave
+	// spawns.
+	CampaignIntroTicks = 90
+	// CampaignClearedTicks is the pause after a wave is cleared.
+	CampaignClearedTicks = 120
+	// CampaignSpawnDistance puts wave enemies just off screen, closer than
+	// EnemySpawnDistance, so a wave arrives together.
+	CampaignSpawnDistance = WIDTH * 0.6
+)
+
+// WaveSpawn is a group of enemies of one kind in a wave.
+type WaveSpawn struct {
+	Kind  EnemyKind
+	Count int
+}
+
+// Wave is one authored campaign wave.
+type Wave struct {
+	Title  string
+	Spawns []WaveSpawn
+	Health int // Added to the base health of every enemy in the wave
+}
+
+// CampaignWaves are the 13 waves of the campaign. The last one is the boss
+// wave that ends it.
+var CampaignWaves = []Wave{
+	{"WAVE 1", []WaveSpawn{{SmallFighter, 4}}, 0},
+	{"WAVE 2", []WaveSpawn{{SmallFighter, 6}}, 0},
+	{"WAVE 3", []WaveSpawn{{SmallFighter, 5}, {MediumFighter, 1}}, 1},
+	{"WAVE 4", []WaveSpawn{{SmallFighter, 6}, {MediumFighter, 2}}, 1},
+	{"WAVE 5", []WaveSpawn{{MediumFighter, 3}, {TurretFighter, 1}}, 2},
+	{"WAVE 6", []WaveSpawn{{SmallFighter, 8}, {TurretFighter, 2}}, 2},
+	{"WAVE 7", []WaveSpawn{{SmallFighter, 6}, {MediumFighter, 3}, {TurretFighter, 1}}, 3},
+	{"WAVE 8", []WaveSpawn{{MediumFighter, 5}, {TurretFighter, 2}}, 3},
+	{"WAVE 9", []WaveSpawn{{SmallFighter, 10}, {MediumFighter, 2}, {TurretFighter, 2}}, 4},
+	{"WAVE 10", []WaveSpawn{{Boss, 1}, {SmallFighter, 4}}, 4},
+	{"WAVE 11", []WaveSpawn{{SmallFighter, 8}, {MediumFighter, 4}, {TurretFighter, 3}}, 5},
+	{"WAVE 12", []WaveSpawn{{MediumFighter, 6}, {TurretFighter, 4}}, 6},
+	{"FINAL WAVE", []WaveSpawn{{Boss, 1}, {TurretFighter, 2}, {MediumFighter, 2}}, 10},
+}
+
+// CampaignPhase is the state of the current campaign wave.
+type CampaignPhase uint8
+
+const (
+	CampaignIntro    CampaignPhase = iota // Title shown, wave not yet spawned
+	CampaignFighting                      // Wave enemies alive
+	CampaignCleared                       // Wave cleared, next one pending
+	CampaignComplete                      // Final wave cleared
+)
+
+// Campaign tracks progress through CampaignWaves.
+type Campaign struct {
+	Wave  int // Index into CampaignWaves
+	Phase CampaignPhase
+	Timer int // Ticks left in the intro or cleared phase
+}
+
+// StartMode switches to mode and starts a fresh session for the player with
+// the current seed, at the difficulty picked on the title screen. The
+// infinite world joins the default multiplayer room of that difficulty; the
+// campaign is single player, so it leaves multiplayer.
+func (g *Game) StartMode(mode GameMode) {
+	g.Demo = nil
+	g.Playback = nil
+	g.Mode = mode
+	g.Title.Selected = mode
+	g.Difficulty = g.Title.Difficulty
+	if mode == ModeCampaign {
+		g.LeaveMultiplayer()
+	}
+	g.Reset(g.GameSeed)
+	g.Ship.Input = g.LocalInput
+	g.State = StatePlaying
+
+	// Headless games have no network
+	if mode == ModeInfinite && g.Canvas != nil {
+		g.JoinMultiplayer(g.Difficulty.RoomID(DefaultRoomID))
+	}
+}
+
+// startCampaign begins the first wave. Called by Reset in campaign mode.
+func (g *Game) startCampaign() {
+	g.Campaign = &Campaign{}
+	g.startWave(0)
+}
+
+// startWave announces wave i. Each wave plays in its own level: the level
+// seed is derived from the game seed and reseeds the gameplay RNG, so a
+// wave plays out the same however the previous ones went.
+func (g *Game) startWave(i int) {
+	c := g.Campaign
+	c.Wave = i
+	c.Phase = CampaignIntro
+	c.Timer = CampaignIntroTicks
+
+	g.Level.LevelNum = i + 1
+	g.Level.LevelSeed = common.LevelSeed(g.GameSeed, g.Level.LevelNum)
+	g.GameRNG.SetSeed(g.Level.LevelSeed)
+
+	g.SpawnText(CampaignWaves[i].Title, CampaignIntroTicks)
+	g.Audio.SetMusicPreset(g.Level.LevelNum)
+}
+
+// UpdateCampaign advances the campaign by one tick. It replaces
+// CheckWaveSpawn in campaign mode.
+func (g *Game) UpdateCampaign() {
+	c := g.Campaign
+	if c == nil {
+		return
+	}
+
+	switch c.Phase {
+	case CampaignIntro:
+		if c.Timer--; c.Timer <= 0 {
+			g.spawnWave(CampaignWaves[c.Wave])
+			c.Phase = CampaignFighting
+		}
+	case CampaignFighting:
+		if g.enemiesAlive() {
+			return
+		}
+		if c.Wave == len(CampaignWaves)-1 {
+			c.Phase = CampaignComplete
+			g.SpawnText("CAMPAIGN COMPLETE", -1)
+			return
+		}
+		c.Phase = CampaignCleared
+		c.Timer = CampaignClearedTicks
+		g.SpawnText("WAVE "+strconv.Itoa(c.Wave+1)+" CLEARED", CampaignClearedTicks)
+	case CampaignCleared:
+		if c.Timer--; c.Timer <= 0 {
+			g.startWave(c.Wave + 1)
+		}
+	}
+}
+
+// spawnWave spawns the enemies of w around every ship.
+func (g *Game) spawnWave(w Wave) {
+	for _, ship := range g.Ships {
+		for _, s := range w.Spawns {
+			health := g.Spawns.Enemies[s.Kind].HealthBase + w.Health
+			g.SpawnEnemies(s.Kind, ship, s.Count, health, CampaignSpawnDistance)
+		}
+	}
+}
+
+// enemiesAlive reports whether any enemy is still alive.
+func (g *Game) enemiesAlive() bool {
+	for _, e := range g.Enemies {
+		if e.IsAlive() {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,137 @@
+package game
+
+import (
+	"encoding/binary"
+	"hash"
+	"hash/fnv"
+	"math"
+)
+
+// stateHasher feeds simulation values into an FNV-1a hash in a fixed
+// byte order, so the same state hashes the same on every platform.
+type stateHasher struct {
+	h   hash.Hash32
+	buf [8]byte
+}
+
+func (sh *stateHasher) uint32(v uint32) {
+	binary.LittleEndian.PutUint32(sh.buf[:4], v)
+	sh.h.Write(sh.buf[:4])
+}
+
+func (sh *stateHasher) int(v int) {
+	sh.uint32(uint32(int32(v)))
+}
+
+func (sh *stateHasher) float(v float64) {
+	binary.LittleEndian.PutUint64(sh.buf[:], math.Float64bits(v))
+	sh.h.Write(sh.buf[:])
+}
+
+func (sh *stateHasher) bool(v bool) {
+	if v {
+		sh.uint32(1)
+	} else {
+		sh.uint32(0)
+	}
+}
+
+func (sh *stateHasher) string(v string) {
+	sh.int(len(v))
+	sh.h.Write([]byte(v))
+}
+
+// Checksum returns a hash of the gameplay state at the current tick.
+// Two simulations started with the same seed and fed the same inputs must
+// produce the same checksum on every tick; the first tick where they differ
+// is where they diverged.
+//
+// Only gameplay state is hashed: ships, enemies, bullets, bonuses, obstacle
+// damage, level, difficulty, campaign progress, tick and the gameplay RNG.
+// Cosmetic state (explosions, animation phases, FxRNG, text messages) is
+// left out so effects can change without breaking determinism.
+func (g *Game) Checksum() uint32 {
+	sh := &stateHasher{h: fnv.New32a()}
+
+	sh.uint32(g.Tick)
+	sh.uint32(g.GameRNG.State())
+	sh.int(g.Level.LevelNum)
+	sh.int(g.Level.P)
+	sh.int(g.Level.Bomb)
+
+	if c := g.Campaign; c != nil {
+		sh.int(c.Wave)
+		sh.int(int(c.Phase))
+		sh.int(c.Timer)
+	}
+	sh.int(int(g.Difficulty))
+
+	sh.int(len(g.Ships))
+	for _, s := range g.Ships {
+		sh.float(s.X)
+		sh.float(s.Y)
+		sh.float(s.VelX)
+		sh.float(s.VelY)
+		sh.float(s.Angle)
+		sh.int(s.E)
+		sh.int(s.Points)
+		sh.int(s.Timeout)
+		sh.int(s.Reload)
+		sh.int(s.Shield.T)
+		sh.int(len(s.Weapons))
+		sh.int(s.LockTimer)
+		sh.bool(s.Target != nil)
+		sh.bool(s.LockingOn != nil)
+		for _, level := range s.Upgrades {
+			sh.int(level)
+		}
+		sh.int(int(s.Weapon))
+		sh.int(int(s.Arsenal))
+		sh.int(s.Charge)
+	}
+
+	sh.int(len(g.Enemies))
+	for _, e := range g.Enemies {
+		sh.int(int(e.Kind))
+		sh.float(e.X)
+		sh.float(e.Y)
+		sh.float(e.YOffset)
+		sh.float(e.Angle)
+		sh.int(e.Health)
+		sh.int(e.FireTimer)
+		for _, p := range e.Parts {
+			sh.int(p.Health)
+			sh.int(p.FireTimer)
+		}
+	}
+
+	sh.int(g.Bullets.ActiveCount)
+	for i := 0; i < g.Bullets.ActiveCount; i++ {
+		b := g.Bullets.Pool[i]
+		sh.int(int(b.Kind))
+		sh.float(b.X)
+		sh.float(b.Y)
+		sh.float(b.XAcc)
+		sh.float(b.YAcc)
+		sh.int(b.T)
+		sh.int(b.E)
+	}
+
+	sh.int(g.Bonuses.ActiveCount)
+	for i := 0; i < g.Bonuses.ActiveCount; i++ {
+		item := g.Bonuses.Pool[i]
+		sh.string(item.Type)
+		sh.float(item.X)
+		sh.float(item.Y)
+	}
+
+	// Obstacles are generated from the seed; only their damage is state
+	for _, id := range g.World.Damaged() {
+		sh.int(id.Chunk.X)
+		sh.int(id.Chunk.Y)
+		sh.int(id.Index)
+		sh.int(g.World.Damage[id])
+	}
+
+	return sh.h.Sum32()
+}
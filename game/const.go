@@ -1,7 +1,7 @@
 package game
 
 import (
-	"github.com/gopherjs/gopherjs/js"
+	"github.com/simukka/starship-sorades-13k/render"
 )
 
 // Constants for game configuration
@@ -11,6 +11,10 @@ const (
 	Speed         = 2
 	MaxBomb       = 5
 	FrameDuration = 33.33 // ~30 FPS
+
+	// Size of the centered text message image
+	TextImageWidth  = WIDTH * 10 / 16
+	TextImageHeight = WIDTH / 8
 )
 
 // World constants for infinite world mode
@@ -77,10 +81,15 @@ type Entity interface {
 	// IsAlive returns true if the entity is still active.
 	IsAlive() bool
 
-	// Update updates the entity's state for one frame, including rendering.
+	// Update advances the entity's simulation state by one tick.
+	// It never draws, so it is safe to call from a headless game.
 	// Returns false if the entity should be removed.
 	Update(g *Game) bool
 
+	// Render draws the entity at its current state. It does not change
+	// any simulation state.
+	Render(g *Game)
+
 	// AudioPan returns stereo pan value based on position (-1 to 1).
 	AudioPan() float64
 }
@@ -99,6 +108,7 @@ const (
 	ShipAngleFactor = 0.8
 	ShipMaxAngle    = 10
 	ShipMaxOSD      = 180 // 6 * 30
+	ShipMaxEnergy   = 100 // Energy of a ship without upgrades
 	ShipMaxShield   = 300 // 10 * 30 frames
 
 	// ShipCollisionD is the vertical collision half-extent (depth along Y axis).
@@ -117,6 +127,16 @@ const (
 	TorpedoR                   = 16
 	BonusR                     = 16
 	BulletTorpedoCollisionDist = 12.0
+	TorpedoFrameCount          = 8 // Torpedo spin animation frames
+
+	// MineR is the size of a mine sprite.
+	MineR = 20
+	// MineLifetime is how many ticks a mine stays armed.
+	MineLifetime = 900 // 30 seconds
+	// MineTriggerRadius is how close a ship may come before a mine goes off.
+	MineTriggerRadius = float64(ShipR)
+	// MineDamage is the damage a mine does to the ship that set it off.
+	MineDamage = 20
 )
 
 // Enemy constants
@@ -127,6 +147,16 @@ const (
 	EnemyAngleSmoothingFactor = 20
 )
 
+// Bomb constants
+const (
+	// BombBaseRadius is the reach of a bomb fired with a single weapon.
+	BombBaseRadius = HEIGHT / 2.0
+	// BombRadiusPerWeapon is added to the reach for every further weapon.
+	BombRadiusPerWeapon = float64(ShipR) * 2
+	// BombDamage is the health a bomb takes from an enemy per weapon.
+	BombDamage = 4
+)
+
 // Base constants
 const (
 	// BaseShieldRadius is the radius of the base's protective shield.
@@ -141,17 +171,18 @@ const (
 type Shield struct {
 	MaxT  int
 	T     int
-	Image *js.Object
+	Image render.Image
 }
 
 // TextDisplay holds text display state.
 type TextDisplay struct {
-	MaxT  int
-	T     int
-	X     int
-	Y     int
-	YAcc  float64
-	Image *js.Object
+	Message string // Text shown; Image is rendered from it on demand
+	MaxT    int
+	T       int
+	X       int
+	Y       int
+	YAcc    float64
+	Image   render.Image
 }
 
 // Points holds score display configuration.
@@ -159,19 +190,17 @@ type Points struct {
 	Width  int
 	Height int
 	Step   int
-	Images []*js.Object
+	Images []render.Image
 }
 
 // Level holds the game level/state.
 type Level struct {
-	Y                 float64
-	Bomb              int
-	P                 int // Score
-	LevelNum          int
-	Paused            bool
-	LevelSeed         uint32
-	Text              TextDisplay
-	Points            Points
-	Background        *js.Object
-	BackgroundPattern *js.Object
+	Y          float64
+	Bomb       int
+	P          int // Score
+	LevelNum   int
+	LevelSeed  uint32
+	Text       TextDisplay
+	Points     Points
+	Background render.Image
 }
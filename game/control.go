@@ -0,0 +1,161 @@
+package game
+
+import "math"
+
+// ControlState is the input of one ship for one simulation tick. Every
+// input device is reduced to it before the simulation sees it, so the
+// simulation, replays and the network only deal with this one format.
+type ControlState struct {
+	Keys   uint16 // Bitmask of KeyLeft...KeyAim, see PlayerInputData.Keys
+	Turn   int8   // Analog rotation, -AnalogMax (full left) to AnalogMax (full right)
+	Thrust int8   // Analog throttle, -AnalogMax (full reverse) to AnalogMax (full forward)
+	AimX   int32  // World position pointed at, used with KeyLockAt and KeyAim
+	AimY   int32
+	Buy    Upgrade // Upgrade bought with KeyBuy
+}
+
+// HasAim reports whether c carries an aim point.
+func (c ControlState) HasAim() bool {
+	return c.Keys&(KeyLockAt|KeyAim) != 0
+}
+
+// AnalogMax is the full deflection of ControlState.Turn and Thrust. Analog
+// input is quantized to int8 so that it replays and syncs bit for bit.
+const AnalogMax = 127
+
+// Analog converts v in [-1, 1] to a ControlState analog value.
+func Analog(v float64) int8 {
+	return int8(math.Round(math.Max(-1, math.Min(v, 1)) * AnalogMax))
+}
+
+// Merge combines two controls of the same ship: keys are or'ed together,
+// analog values add up, clamped to full deflection, and the aim point and
+// purchase are taken from whichever control has one.
+func (c ControlState) Merge(o ControlState) ControlState {
+	m := ControlState{
+		Keys:   c.Keys | o.Keys,
+		Turn:   addAnalog(c.Turn, o.Turn),
+		Thrust: addAnalog(c.Thrust, o.Thrust),
+		AimX:   c.AimX,
+		AimY:   c.AimY,
+		Buy:    c.Buy,
+	}
+	if o.HasAim() {
+		m.AimX, m.AimY = o.AimX, o.AimY
+	}
+	if o.Keys&KeyBuy != 0 {
+		m.Buy = o.Buy
+	}
+	return m
+}
+
+func addAnalog(a, b int8) int8 {
+	return int8(maxInt(-AnalogMax, min(int(a)+int(b), AnalogMax)))
+}
+
+// InputSource produces the controls of a ship. Poll is called once per
+// simulation tick, before the tick is stepped, with the ship it controls.
+//
+// Implementations: KeyboardInput, GamepadInput, TouchInput, MouseInput and
+// ShopScreen (local player, combined in Game.LocalInput), NetworkInput (remote
+// players on the host), ReplayPlayer (recorded sessions) and Autopilot
+// (attract mode and soak tests).
+type InputSource interface {
+	Poll(g *Game, s *Ship) ControlState
+}
+
+// PollInputs collects the controls of every ship from its Input, in
+// g.Ships order, ready for Step. The result ends at the last ship with an
+// input source, so ships driven by the network (remote ships on a client)
+// are not moved by the simulation.
+func (g *Game) PollInputs() []ControlState {
+	n := 0
+	for i, s := range g.Ships {
+		if s.Input != nil {
+			n = i + 1
+		}
+	}
+
+	inputs := make([]ControlState, n)
+	for i, s := range g.Ships[:n] {
+		if s.Input != nil {
+			inputs[i] = s.Input.Poll(g, s)
+		}
+	}
+	return inputs
+}
+
+// CombinedInput lets several devices control the same ship, such as the
+// keyboard and a gamepad of the local player.
+type CombinedInput []InputSource
+
+// Poll implements InputSource by merging the controls of every source.
+func (ci CombinedInput) Poll(g *Game, s *Ship) ControlState {
+	var c ControlState
+	for _, src := range ci {
+		c = c.Merge(src.Poll(g, s))
+	}
+	return c
+}
+
+// KeyboardInput reads the controls from the keyboard. Key events update the
+// set of held keys as they arrive; Poll samples it once per tick and maps
+// the held keys to ship controls through Bindings.
+type KeyboardInput struct {
+	Bindings Bindings
+	pressed  map[int]bool // Key codes held down
+}
+
+// NewKeyboardInput creates a keyboard source with the default bindings and
+// no keys held.
+func NewKeyboardInput() *KeyboardInput {
+	return &KeyboardInput{
+		Bindings: DefaultBindings(),
+		pressed:  make(map[int]bool),
+	}
+}
+
+// KeyDown records that a key is held.
+func (k *KeyboardInput) KeyDown(keyCode int) {
+	k.pressed[keyCode] = true
+}
+
+// KeyUp records that a key was released.
+func (k *KeyboardInput) KeyUp(keyCode int) {
+	delete(k.pressed, keyCode)
+}
+
+// ReleaseAll forgets every held key, e.g. when the rebinding screen takes
+// over the keyboard and would swallow the key up events.
+func (k *KeyboardInput) ReleaseAll() {
+	for keyCode := range k.pressed {
+		delete(k.pressed, keyCode)
+	}
+}
+
+// Poll implements InputSource.
+func (k *KeyboardInput) Poll(g *Game, s *Ship) ControlState {
+	var c ControlState
+	for keyCode := range k.pressed {
+		if a, ok := k.Bindings.Lookup(keyCode); ok {
+			c.Keys |= a.ShipKey()
+		}
+	}
+	return c
+}
+
+// NetworkInput holds the latest input a remote player sent to the host.
+// The host applies it on every tick until the next message arrives.
+type NetworkInput struct {
+	latest ControlState
+}
+
+// Receive stores input received from the remote player.
+func (n *NetworkInput) Receive(input *PlayerInputData) {
+	n.latest = input.Controls()
+}
+
+// Poll implements InputSource.
+func (n *NetworkInput) Poll(g *Game, s *Ship) ControlState {
+	return n.latest
+}
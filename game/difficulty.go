@@ -0,0 +1,132 @@
+package game
+
+import "math"
+
+// Difficulty is a preset that scales how hard enemies hit back. It is
+// picked on the title screen, recorded in replays and shared by everyone in
+// a multiplayer room, so scores are only compared within one difficulty.
+type Difficulty uint8
+
+// Difficulties. Normal is the zero value, so games, replays and hosts that
+// predate the presets play the original balance.
+const (
+	DifficultyNormal Difficulty = iota
+	DifficultyEasy
+	DifficultyHard
+	DifficultyInsane
+
+	// DifficultyCount is the number of difficulties.
+	DifficultyCount
+)
+
+// DifficultyOrder lists the difficulties from easiest to hardest, the order
+// the title screen offers them in.
+var DifficultyOrder = [DifficultyCount]Difficulty{
+	DifficultyEasy, DifficultyNormal, DifficultyHard, DifficultyInsane,
+}
+
+// difficultyPresets holds the multipliers of each difficulty. Fire rate
+// divides the ticks between two shots, so a higher rate fires more often.
+var difficultyPresets = [DifficultyCount]struct {
+	name         string
+	health       float64 // Enemy health, boss parts included
+	count        float64 // Enemies per spawn
+	fireRate     float64 // Shots per tick of every enemy weapon
+	torpedoSpeed float64
+	damage       float64 // Damage the ship takes
+}{
+	DifficultyEasy:   {"easy", 0.7, 0.7, 0.7, 0.8, 0.5},
+	DifficultyNormal: {"normal", 1, 1, 1, 1, 1},
+	DifficultyHard:   {"hard", 1.4, 1.3, 1.3, 1.2, 1.5},
+	DifficultyInsane: {"insane", 2, 1.6, 1.6, 1.4, 2},
+}
+
+// String returns the name of d.
+func (d Difficulty) String() string {
+	if d >= DifficultyCount {
+		return "?"
+	}
+	return difficultyPresets[d].name
+}
+
+// ParseDifficulty returns the difficulty named s, defaulting to
+// DifficultyNormal.
+func ParseDifficulty(s string) Difficulty {
+	for d, p := range difficultyPresets {
+		if p.name == s {
+			return Difficulty(d)
+		}
+	}
+	return DifficultyNormal
+}
+
+// Harder returns the next harder difficulty, or d if it is the hardest.
+func (d Difficulty) Harder() Difficulty {
+	return d.step(1)
+}
+
+// Easier returns the next easier difficulty, or d if it is the easiest.
+func (d Difficulty) Easier() Difficulty {
+	return d.step(-1)
+}
+
+func (d Difficulty) step(by int) Difficulty {
+	for i, o := range DifficultyOrder {
+		if o == d {
+			return DifficultyOrder[maxInt(0, min(i+by, len(DifficultyOrder)-1))]
+		}
+	}
+	return DifficultyNormal
+}
+
+// scaleInt multiplies n by m, rounded to the nearest integer. Normal
+// multipliers of 1 leave n exactly as it was.
+func scaleInt(n int, m float64) int {
+	return int(math.Round(float64(n) * m))
+}
+
+// EnemyHealth scales the health of a new enemy. Live enemies keep at least
+// one point of health.
+func (d Difficulty) EnemyHealth(health int) int {
+	if health <= 0 {
+		return health
+	}
+	return maxInt(scaleInt(health, difficultyPresets[d].health), 1)
+}
+
+// SpawnCount scales the number of enemies of a spawn. Spawns of at least
+// one enemy keep at least one.
+func (d Difficulty) SpawnCount(count int) int {
+	if count <= 0 {
+		return count
+	}
+	return maxInt(scaleInt(count, difficultyPresets[d].count), 1)
+}
+
+// FireInterval scales the number of ticks between two shots of an enemy.
+func (d Difficulty) FireInterval(ticks int) int {
+	return maxInt(int(math.Round(float64(ticks)/difficultyPresets[d].fireRate)), 1)
+}
+
+// TorpedoSpeed scales the launch speed of a torpedo.
+func (d Difficulty) TorpedoSpeed(speed float64) float64 {
+	return speed * difficultyPresets[d].torpedoSpeed
+}
+
+// Damage scales the damage the ship takes from a hit. A hit always does at
+// least one point of damage.
+func (d Difficulty) Damage(damage int) int {
+	if damage <= 0 {
+		return damage
+	}
+	return maxInt(scaleInt(damage, difficultyPresets[d].damage), 1)
+}
+
+// RoomID returns the multiplayer room of room for players at difficulty d.
+// Normal keeps the room itself, so it stays the room older clients join.
+func (d Difficulty) RoomID(room string) string {
+	if d == DifficultyNormal {
+		return room
+	}
+	return room + "-" + d.String()
+}
@@ -4,7 +4,7 @@ import (
 	"math"
 	"strconv"
 
-	"github.com/gopherjs/gopherjs/js"
+	"github.com/simukka/starship-sorades-13k/render"
 )
 
 type EnemyKind int
@@ -14,6 +14,12 @@ const (
 	MediumFighter
 	TurretFighter
 	Boss
+	Kamikaze  // Rams the ship
+	MineLayer // Circles the ship, dropping mines
+	Carrier   // Keeps its distance and launches small fighters
+
+	// EnemyKindCount is the number of enemy kinds.
+	EnemyKindCount
 )
 
 // EnemyKindNames maps EnemyKind to display names for the UI
@@ -22,49 +28,55 @@ var EnemyKindNames = map[EnemyKind]string{
 	MediumFighter: "Medium Fighter",
 	TurretFighter: "Turret",
 	Boss:          "Boss",
+	Kamikaze:      "Kamikaze",
+	MineLayer:     "Mine Layer",
+	Carrier:       "Carrier",
 }
 
 // Enemy represents an enemy entity.
 type Enemy struct {
-	Image         *js.Object
-	X, Y          float64
-	VelX, VelY    float64 // Velocity for predictive targeting
-	YStop         float64
-	YOffset       float64
-	Radius        float64
-	Angle         float64
-	MaxAngle      float64
-	Health        int
-	MaxHealth     int // Maximum health for health bar calculation
-	OSD           int // On-screen display timer for health bar
-	FireTimer     int
-	FireDirection float64
-	TActive       int
-	TypeIndex     int
-	NetworkID     int // Unique ID for multiplayer synchronization
+	Image      render.Image
+	X, Y       float64
+	VelX, VelY float64 // Velocity for predictive targeting
+	YStop      float64
+	YOffset    float64
+	Radius     float64
+	Angle      float64
+	MaxAngle   float64
+	Health     int
+	MaxHealth  int // Maximum health for health bar calculation
+	OSD        int // On-screen display timer for health bar
+	FireTimer  int
+	TActive    int
+	NetworkID  int // Unique ID for multiplayer synchronization
+	Launched   int // Fighters launched so far (carriers)
+	Parts      []*BossPart
+	Phase      int  // Attack phase of bosses with parts, -1 before the first tick
+	Spent      bool // Destroyed itself by ramming; blows up without a reward
 
 	Target *Ship
 	Kind   EnemyKind
 }
 
-// EnemySpawnConfig holds configuration for spawning enemies.
+// EnemySpawnConfig holds configuration for spawning enemies. The JSON names
+// are the ones used in spawn tables (see SpawnTable).
 type EnemySpawnConfig struct {
-	CountBase       int
-	CountPerPoints  int // Points threshold for +1 enemy count (e.g., 1000 = +1 per 1000 points)
-	MaxAngle        float64
-	HealthBase      int
-	HealthPerPoints int // Points threshold for +1 health (e.g., 500 = +1 per 500 points)
-	TBase           int
-	TRange          int
-	TStart          int
-	YStopBase       float64
-	YStopRange      float64
-	YOffsetMult     float64 // yoffset multiplyer
-	UseYStep        bool    // For turret-style Y positioning
-	HasFireDir      bool    // For turrets with rotating fire
+	CountBase       int     `json:"countBase"`
+	CountPerPoints  int     `json:"countPerPoints"` // Points threshold for +1 enemy count (e.g., 1000 = +1 per 1000 points)
+	MaxAngle        float64 `json:"maxAngle"`
+	HealthBase      int     `json:"healthBase"`
+	HealthPerPoints int     `json:"healthPerPoints"` // Points threshold for +1 health (e.g., 500 = +1 per 500 points)
+	TBase           int     `json:"tBase"`
+	TRange          int     `json:"tRange"`
+	TStart          int     `json:"tStart"`
+	YStopBase       float64 `json:"yStopBase"`
+	YStopRange      float64 `json:"yStopRange"`
+	YOffsetMult     float64 `json:"yOffsetMult"` // yoffset multiplyer
+	UseYStep        bool    `json:"useYStep"`    // For turret-style Y positioning
+	DropsWeapon     bool    `json:"dropsWeapon"` // Also drops a weapon pickup when destroyed
 }
 
-// Standard enemy spawn configurations
+// Standard enemy spawn configurations, the defaults of SpawnTable
 var enemyConfigs = map[EnemyKind]EnemySpawnConfig{
 	// Type 0: Small fighters - spawn frequently, scale with points
 	SmallFighter: {
@@ -83,13 +95,33 @@ var enemyConfigs = map[EnemyKind]EnemySpawnConfig{
 		CountBase: 0, CountPerPoints: 5000,
 		MaxAngle: math.Pi * 32, HealthBase: 20, HealthPerPoints: 600,
 		TBase: 0, TRange: 30, YStopBase: HEIGHT / 2, YStopRange: 0,
-		UseYStep: true, HasFireDir: true,
+		UseYStep: true,
 	},
 	Boss: {
 		CountBase: 0, CountPerPoints: 10000,
 		YOffsetMult: 0.6,
 		MaxAngle:    math.Pi / 8, HealthBase: 30, HealthPerPoints: 500,
 		TBase: 0, TRange: 20, TStart: 60,
+		DropsWeapon: true,
+	},
+	// Kamikazes - fragile, come in pairs once the player can take a hit
+	Kamikaze: {
+		CountBase: 2, CountPerPoints: 3000,
+		MaxAngle: math.Pi / 8, HealthBase: 4, HealthPerPoints: 1500,
+	},
+	// Mine layers - the timer is the delay before the first mine
+	MineLayer: {
+		CountBase: 1, CountPerPoints: 6000,
+		MaxAngle: math.Pi / 16, HealthBase: 12, HealthPerPoints: 800,
+		TBase: 60, TRange: 60,
+		DropsWeapon: true,
+	},
+	// Carriers - tough, the timer is the delay before the first launch
+	Carrier: {
+		CountBase: 1, CountPerPoints: 0,
+		MaxAngle: math.Pi / 16, HealthBase: 40, HealthPerPoints: 400,
+		TBase: 30, TRange: 60,
+		DropsWeapon: true,
 	},
 }
 
@@ -146,12 +178,6 @@ func (e *Enemy) IsAlive() bool {
 	return e.Health > 0
 }
 
-// Update implements Entity interface - updates enemy state, targeting, and movement.
-// Returns false if the enemy should be removed (death or despawn).
-func (e *Enemy) Update(g *Game) bool {
-	return e.Render(g)
-}
-
 // DistanceVolume calculates volume based on distance to a target position.
 // Closer = louder (up to 1.0), farther = quieter (minimum 0.2).
 // maxDist is the distance at which volume reaches minimum.
@@ -169,19 +195,8 @@ func (s *Enemy) DistanceVolume(targetX, targetY, maxDist float64) float64 {
 	return 1.0 - (dist/maxDistSq)*0.8
 }
 
-// Render updates the enemy's orientation to face its target and draws the enemy sprite.
-//
-// Targeting behavior:
-//   - Calculates the angle from enemy position to target (player ship) using CalculateTargetAngle
-//   - Normalizes the angle to the range [-π, π] for consistent rotation direction
-//   - Clamps the angle to MaxAngle to limit how far the enemy can rotate
-//   - Applies exponential smoothing using EnemyAngleSmoothingFactor for gradual rotation:
-//     newAngle = (currentAngle * EnemyAngleSmoothingFactor - clampedTargetAngle) / (EnemyAngleSmoothingFactor + 1)
-//
-// Rendering:
-//   - Saves canvas state, translates to enemy position, rotates by current angle
-//   - Draws the enemy image centered on its position
-//   - Restores canvas state to avoid affecting other draw calls
+// TargetAngle returns the angle from the enemy to its target ship.
+// Uses the same convention as CalculateTargetAngle (0 = down).
 func (e *Enemy) TargetAngle() float64 {
 	dx := e.Target.X - e.X
 	dy := e.Target.Y - e.Y
@@ -191,7 +206,20 @@ func (e *Enemy) TargetAngle() float64 {
 	return math.Atan2(dx, dy)
 }
 
-func (e *Enemy) Render(g *Game) bool {
+// Update implements Entity interface - updates enemy targeting, movement and
+// firing for one tick.
+//
+// Targeting behavior:
+//   - Targets the nearest ship and despawns when every ship is too far away,
+//     or in the campaign comes back to just off screen
+//   - Normalizes the angle to the target to [-π, π] for consistent rotation direction
+//   - Applies exponential smoothing using EnemyAngleSmoothingFactor for gradual rotation:
+//     newAngle = (currentAngle * EnemyAngleSmoothingFactor - targetAngle) / (EnemyAngleSmoothingFactor + 1)
+//
+// Movement and firing are left to the behavior of the enemy's kind.
+//
+// Returns false if the enemy should be removed (death or despawn).
+func (e *Enemy) Update(g *Game) bool {
 	enemyY := e.Y + e.YOffset
 
 	// Find nearest ship to target
@@ -208,9 +236,19 @@ func (e *Enemy) Render(g *Game) bool {
 	}
 	e.Target = nearestShip
 
-	// Check if enemy is too far from any ship - despawn
+	// Check if enemy is too far from any ship - despawn. A campaign wave is
+	// only cleared by destroying it, so its enemies are brought back just
+	// off screen instead.
 	if nearestDist > EnemyDespawnDistance*EnemyDespawnDistance {
-		return false
+		if g.Campaign == nil || nearestShip == nil {
+			if len(e.Parts) > 0 {
+				g.endBossMusic(e)
+			}
+			return false
+		}
+		d := math.Sqrt(nearestDist)
+		e.X = nearestShip.X + (e.X-nearestShip.X)/d*CampaignSpawnDistance
+		e.Y = nearestShip.Y + (enemyY-nearestShip.Y)/d*CampaignSpawnDistance - e.YOffset
 	}
 
 	// Normalize angle to [-π, π] range for consistent shortest-path rotation
@@ -220,218 +258,180 @@ func (e *Enemy) Render(g *Game) bool {
 	e.Angle = (e.Angle*EnemyAngleSmoothingFactor - angle) / (EnemyAngleSmoothingFactor + 1)
 
 	if e.Health <= 0 {
-		// Enemy destroyed - award points to nearest ship (the target)
-		pointsAward := 100
-		if e.Target != nil {
-			e.Target.Points += pointsAward
-		}
+		// Enemy destroyed - award points to nearest ship (the target), unless
+		// it rammed something and destroyed itself
+		if !e.Spent {
+			pointsAward := 100
+			if e.Target != nil {
+				e.Target.Points += pointsAward
+			}
 
-		g.SpawnBonus(e.X, e.Y, 0, 0, "")
+			g.SpawnBonus(e.X, e.Y, 0, 0, "")
+			if g.Spawns.Enemies[e.Kind].DropsWeapon {
+				g.SpawnBonus(e.X, e.Y, 0, 0, WeaponDrop(e.Target))
+			}
+		}
 		g.Explode(e.X, e.Y, e.Radius*2)
 		g.Explode(e.X, e.Y, e.Radius*3)
 
 		g.Audio.PlayWithPan(10,
 			e.AudioPan(),
 			e.DistanceVolume(g.Ship.X, g.Ship.Y, float64(HEIGHT)))
+		if len(e.Parts) > 0 {
+			g.endBossMusic(e)
+		}
 		return false
 	}
 
-	// Move enemy toward target (follow behavior)
-	if e.Target != nil {
-		followSpeed := 1.5 // Adjust for enemy speed
-		dx := e.Target.X - e.X
-		dy := e.Target.Y - enemyY
-		dist := math.Sqrt(dx*dx + dy*dy)
-		if dist > e.Radius*2 { // Don't get too close
-			// Calculate velocity
-			e.VelX = (dx / dist) * followSpeed
-			e.VelY = (dy / dist) * followSpeed
-
-			newX := e.X + e.VelX
-			newY := e.Y + e.VelY
-
-			// Check if new position would be inside a base shield
-			// Check multiple points around the enemy to prevent slipping through
-			blocked := false
-			newEnemyY := newY + e.YOffset
-			checkRadius := e.Radius * 0.8
-			for _, base := range g.Bases {
-				// Check center and cardinal directions
-				if base.ContainsPoint(newX, newEnemyY) ||
-					base.ContainsPoint(newX, newEnemyY-checkRadius) || // top
-					base.ContainsPoint(newX, newEnemyY+checkRadius) || // bottom
-					base.ContainsPoint(newX-checkRadius, newEnemyY) || // left
-					base.ContainsPoint(newX+checkRadius, newEnemyY) { // right
-					blocked = true
-					break
-				}
-			}
+	// Kind specific movement
+	behavior := BehaviorFor(e.Kind)
+	behavior.Update(g, e)
 
-			// Only move if not blocked by a shield
-			if !blocked {
-				e.X = newX
-				e.Y = newY
-			} else {
-				// Blocked - zero velocity
-				e.VelX = 0
-				e.VelY = 0
-			}
-		} else {
-			// Too close - stop moving
-			e.VelX = 0
-			e.VelY = 0
-		}
-	} else {
-		e.VelX = 0
-		e.VelY = 0
+	// Health bar fades out after a hit
+	if e.OSD > 0 {
+		e.OSD--
 	}
 
-	// Convert world position to screen position for rendering
-	screenX, screenY := g.Camera.WorldToScreen(e.X, enemyY)
+	// and fire at target
+	behavior.Fire(g, e)
+	return true
+}
+
+// Render implements Entity interface - draws the enemy sprite, the targeting
+// reticle of the local ship and the health bar.
+//
+// The sprite itself is drawn by the behavior of the enemy's kind.
+func (e *Enemy) Render(g *Game) {
+	enemyY := e.Y + e.YOffset
 
 	// Only render if on screen
 	if !g.Camera.IsOnScreen(e.X, enemyY, e.Radius*2) {
-		// Still alive, just off-screen
-		e.Fire(g)
-		return true
+		return
 	}
 
-	// Render enemy
-	g.Ctx.Call("save")
-	g.Ctx.Call("translate", screenX, screenY)
-	g.Ctx.Call("rotate", e.Angle)
-	g.Ctx.Call("drawImage", e.Image,
-		-e.Radius, -e.Radius, e.Radius*2, e.Radius*2)
-	g.Ctx.Call("restore")
+	// Convert world position to screen position for rendering
+	screenX, screenY := g.Camera.WorldToScreen(e.X, enemyY)
+
+	// Kind specific drawing
+	BehaviorFor(e.Kind).Render(g, e, screenX, screenY)
 
 	// Render targeting reticle if this enemy is targeted or being locked
 	if g.Ship.Target == e {
 		// Locked target - red reticle
-		g.Ctx.Call("save")
-		g.Ctx.Set("strokeStyle", "#ff0000")
-		g.Ctx.Set("lineWidth", 2)
-		g.Ctx.Set("shadowBlur", 8)
-		g.Ctx.Set("shadowColor", "#ff0000")
-		g.Ctx.Call("beginPath")
-		g.Ctx.Call("arc", screenX, screenY, e.Radius*1.5, 0, math.Pi*2)
-		g.Ctx.Call("stroke")
+		g.Ctx.Save()
+		g.Ctx.SetStrokeStyle("#ff0000")
+		g.Ctx.SetLineWidth(2)
+		g.Ctx.SetShadowBlur(8)
+		g.Ctx.SetShadowColor("#ff0000")
+		g.Ctx.BeginPath()
+		g.Ctx.Arc(screenX, screenY, e.Radius*1.5, 0, math.Pi*2)
+		g.Ctx.Stroke()
 		// Corner brackets
 		bracketSize := e.Radius * 0.6
 		bracketOffset := e.Radius * 1.2
-		g.Ctx.Call("beginPath")
+		g.Ctx.BeginPath()
 		// Top-left
-		g.Ctx.Call("moveTo", screenX-bracketOffset, screenY-bracketOffset+bracketSize)
-		g.Ctx.Call("lineTo", screenX-bracketOffset, screenY-bracketOffset)
-		g.Ctx.Call("lineTo", screenX-bracketOffset+bracketSize, screenY-bracketOffset)
+		g.Ctx.MoveTo(screenX-bracketOffset, screenY-bracketOffset+bracketSize)
+		g.Ctx.LineTo(screenX-bracketOffset, screenY-bracketOffset)
+		g.Ctx.LineTo(screenX-bracketOffset+bracketSize, screenY-bracketOffset)
 		// Top-right
-		g.Ctx.Call("moveTo", screenX+bracketOffset-bracketSize, screenY-bracketOffset)
-		g.Ctx.Call("lineTo", screenX+bracketOffset, screenY-bracketOffset)
-		g.Ctx.Call("lineTo", screenX+bracketOffset, screenY-bracketOffset+bracketSize)
+		g.Ctx.MoveTo(screenX+bracketOffset-bracketSize, screenY-bracketOffset)
+		g.Ctx.LineTo(screenX+bracketOffset, screenY-bracketOffset)
+		g.Ctx.LineTo(screenX+bracketOffset, screenY-bracketOffset+bracketSize)
 		// Bottom-right
-		g.Ctx.Call("moveTo", screenX+bracketOffset, screenY+bracketOffset-bracketSize)
-		g.Ctx.Call("lineTo", screenX+bracketOffset, screenY+bracketOffset)
-		g.Ctx.Call("lineTo", screenX+bracketOffset-bracketSize, screenY+bracketOffset)
+		g.Ctx.MoveTo(screenX+bracketOffset, screenY+bracketOffset-bracketSize)
+		g.Ctx.LineTo(screenX+bracketOffset, screenY+bracketOffset)
+		g.Ctx.LineTo(screenX+bracketOffset-bracketSize, screenY+bracketOffset)
 		// Bottom-left
-		g.Ctx.Call("moveTo", screenX-bracketOffset+bracketSize, screenY+bracketOffset)
-		g.Ctx.Call("lineTo", screenX-bracketOffset, screenY+bracketOffset)
-		g.Ctx.Call("lineTo", screenX-bracketOffset, screenY+bracketOffset-bracketSize)
-		g.Ctx.Call("stroke")
-		g.Ctx.Call("restore")
+		g.Ctx.MoveTo(screenX-bracketOffset+bracketSize, screenY+bracketOffset)
+		g.Ctx.LineTo(screenX-bracketOffset, screenY+bracketOffset)
+		g.Ctx.LineTo(screenX-bracketOffset, screenY+bracketOffset-bracketSize)
+		g.Ctx.Stroke()
+		g.Ctx.Restore()
 	} else if g.Ship.LockingOn == e {
 		// Locking - orange pulsing reticle
 		progress := float64(g.Ship.LockMaxTime-g.Ship.LockTimer) / float64(g.Ship.LockMaxTime)
-		g.Ctx.Call("save")
-		g.Ctx.Set("strokeStyle", "#ff8800")
-		g.Ctx.Set("lineWidth", 2)
-		g.Ctx.Set("shadowBlur", 6)
-		g.Ctx.Set("shadowColor", "#ff8800")
-		g.Ctx.Call("beginPath")
+		g.Ctx.Save()
+		g.Ctx.SetStrokeStyle("#ff8800")
+		g.Ctx.SetLineWidth(2)
+		g.Ctx.SetShadowBlur(6)
+		g.Ctx.SetShadowColor("#ff8800")
+		g.Ctx.BeginPath()
 		// Draw arc showing lock progress
-		g.Ctx.Call("arc", screenX, screenY, e.Radius*1.5, -math.Pi/2, -math.Pi/2+progress*math.Pi*2)
-		g.Ctx.Call("stroke")
-		g.Ctx.Call("restore")
+		g.Ctx.Arc(screenX, screenY, e.Radius*1.5, -math.Pi/2, -math.Pi/2+progress*math.Pi*2)
+		g.Ctx.Stroke()
+		g.Ctx.Restore()
 	}
 
 	// Render health bar if recently hit
 	if e.OSD > 0 {
 		e.RenderHealthBar(g, screenX, screenY)
 	}
-
-	// and fire at target
-	e.Fire(g)
-	return true
 }
 
 func (e *Enemy) Collision(g *Game, b *Bullet) bool {
-	hitboxD := e.Radius * 0.6
-	if b.Kind != StandardBullet {
+	if b.Hostile() || b.Kind == LaserBullet {
 		return false
 	}
+	reach := b.Reach()
+	if p := e.partAt(b.X, b.Y, reach); p != nil {
+		e.hitPart(g, p, b.X, b.Y, b.Damage())
+		return true
+	}
+	hitboxD := e.Radius*0.6 + reach
 	if b.Y < (e.Y+hitboxD) && b.Y > (e.Y-hitboxD) &&
 		b.X > (e.X-hitboxD) && b.X < (e.X+hitboxD) {
-		g.Level.P++
-		g.Explode(b.X, b.Y, 0)
-		e.Health--
-		e.OSD = ShipMaxOSD // Show health bar when hit
-		g.Audio.PlayWithPan(9,
-			e.AudioPan(),
-			e.DistanceVolume(g.Ship.X, g.Ship.Y, float64(HEIGHT)))
+		e.hitCore(g, b.X, b.Y, b.Damage())
 		return true
 	}
 	return false
 }
 
-func (e *Enemy) Fire(g *Game) bool {
-	e.FireTimer--
-	if e.FireTimer > 0 {
-		// need to wait
-		return false
+// hitCore applies a player hit at (x, y) to the core of e. The shield of a
+// boss absorbs it.
+func (e *Enemy) hitCore(g *Game, x, y float64, damage int) {
+	if e.Shielded() {
+		// The shield generator has to go first
+		g.Explode(x, y, 0)
+		g.Audio.PlayWithPan(2,
+			e.AudioPan(),
+			e.DistanceVolume(g.Ship.X, g.Ship.Y, float64(HEIGHT)))
+		return
 	}
+	g.Level.P++
+	g.Explode(x, y, 0)
+	e.Health -= damage
+	e.OSD = ShipMaxOSD // Show health bar when hit
+	g.Audio.PlayWithPan(9,
+		e.AudioPan(),
+		e.DistanceVolume(g.Ship.X, g.Ship.Y, float64(HEIGHT)))
+}
 
-	if e.TypeIndex == 2 {
-
+// Damage takes damage from the enemy's health and from every live part of
+// it, as a bomb does. The core of a shielded boss is spared.
+func (e *Enemy) Damage(damage int) {
+	shielded := e.Shielded()
+	for _, p := range e.Parts {
+		if p.IsAlive() {
+			p.Health -= damage
+			p.OSD = ShipMaxOSD
+		}
 	}
-
-	e.FireTimer = max(5, 600/(g.Level.LevelNum+4))
-
-	torpedo := g.Bullets.AcquireKind(TorpedoBullet)
-	if torpedo == nil {
-		return false
+	if !shielded {
+		e.Health -= damage
 	}
-
-	// 19?
-	// what is 20?
-	g.Audio.PlayWithPan(20,
-		torpedo.AudioPan(),
-		torpedo.DistanceVolume(e.Target.X, e.Target.Y, float64(HEIGHT)))
-
-	// speed := 3.0 + float64(g.Level.LevelNum)/2
-
-	torpedo.X = math.Floor(e.X)
-	torpedo.Y = e.Y + e.YOffset
-
-	torpedo.XAcc = math.Sin(e.TargetAngle()) * (e.Radius / 2)
-	torpedo.YAcc = math.Cos(e.TargetAngle()) * (e.Radius / 2)
-
-	torpedo.E = 0
-
-	return true
+	e.OSD = ShipMaxOSD
 }
 
 // SpawnEnemyNearShip spawns enemies of a given kind near a specific ship.
 // Enemy count and strength scale with the ship's points.
 func (g *Game) SpawnEnemyNearShip(kind EnemyKind, ship *Ship) bool {
-	r := float64(ShipR)
-	cfg, exists := enemyConfigs[kind]
+	cfg, exists := g.Spawns.Enemies[kind]
 
 	if !exists {
 		return false
 	}
 
-	if g.EnemyTypes[kind].R > 0 {
-		r = g.EnemyTypes[kind].R
-	}
-
 	// Calculate count based on ship's points
 	count := cfg.CountBase
 	if cfg.CountPerPoints > 0 {
@@ -444,47 +444,72 @@ func (g *Game) SpawnEnemyNearShip(kind EnemyKind, ship *Ship) bool {
 		health += ship.Points / cfg.HealthPerPoints
 	}
 
-	for i := count; i > 0; i-- {
+	return g.SpawnEnemies(kind, ship, count, health, EnemySpawnDistance)
+}
+
+// SpawnEnemies spawns count enemies of a given kind with the given health
+// at random angles around a ship, distance away from it. The count is
+// scaled by the difficulty.
+func (g *Game) SpawnEnemies(kind EnemyKind, ship *Ship, count, health int, distance float64) bool {
+	if _, exists := g.Spawns.Enemies[kind]; !exists {
+		return false
+	}
+
+	for i := g.Difficulty.SpawnCount(count); i > 0; i-- {
 		// Spawn at random angle around the ship
 		spawnAngle := g.GameRNG.Random() * math.Pi * 2
 
 		// Calculate spawn position at distance from ship
-		spawnX := ship.X + math.Cos(spawnAngle)*EnemySpawnDistance
-		spawnY := ship.Y + math.Sin(spawnAngle)*EnemySpawnDistance
+		spawnX := ship.X + math.Cos(spawnAngle)*distance
+		spawnY := ship.Y + math.Sin(spawnAngle)*distance
 
-		yOff := 0.0
-		if kind == Boss {
-			yOff = r * cfg.YOffsetMult
-		}
+		g.Enemies = append(g.Enemies, g.NewEnemy(kind, spawnX, spawnY, health))
+	}
 
-		enemy := &Enemy{
-			Image:     g.EnemyTypes[kind].Image,
-			X:         spawnX,
-			Y:         spawnY,
-			YStop:     0, // Not used in infinite world
-			YOffset:   yOff,
-			Radius:    r,
-			MaxAngle:  cfg.MaxAngle,
-			Health:    health,
-			MaxHealth: health, // Store max health for health bar
-			FireTimer: cfg.TStart + g.GameRNG.RandomInt(cfg.TBase, cfg.TBase+cfg.TRange),
-			Kind:      kind,
-		}
+	return true
+}
 
-		if cfg.HasFireDir {
-			enemy.FireDirection = g.GameRNG.Random() * math.Pi
-		}
+// NewEnemy creates an enemy of a given kind with the given health, scaled
+// by the difficulty, at world position (x, y), drawing its fire timer from
+// the spawn table. The caller adds it to g.Enemies.
+func (g *Game) NewEnemy(kind EnemyKind, x, y float64, health int) *Enemy {
+	cfg := g.Spawns.Enemies[kind]
+	health = g.Difficulty.EnemyHealth(health)
+	r := float64(ShipR)
+	if g.EnemyTypes[kind].R > 0 {
+		r = g.EnemyTypes[kind].R
+	}
 
-		g.Enemies = append(g.Enemies, enemy)
+	yOff := 0.0
+	if kind == Boss {
+		yOff = r * cfg.YOffsetMult
 	}
 
-	return true
+	enemy := &Enemy{
+		Image:     g.EnemyTypes[kind].Image,
+		X:         x,
+		Y:         y,
+		YStop:     0, // Not used in infinite world
+		YOffset:   yOff,
+		Radius:    r,
+		MaxAngle:  cfg.MaxAngle,
+		Health:    health,
+		MaxHealth: health, // Store max health for health bar
+		FireTimer: cfg.TStart + g.GameRNG.RandomInt(cfg.TBase, cfg.TBase+cfg.TRange),
+		Kind:      kind,
+	}
+
+	if kind == Boss {
+		enemy.Parts = newBossParts(r, health)
+		enemy.Phase = -1
+	}
+	return enemy
 }
 
 // EnemyType defines an enemy type's behavior and appearance.
 type EnemyType struct {
 	R     float64
-	Image *js.Object
+	Image render.Image
 }
 
 // CalculateTargetAngle calculates the angle from source to target position.
@@ -508,20 +533,26 @@ func max(a, b int) int {
 // RenderHealthBar renders the enemy's health bar below the enemy sprite.
 // Similar to Ship.RenderEnergyBar, it fades out over time.
 func (e *Enemy) RenderHealthBar(g *Game, screenX, screenY float64) {
-	barWidth := e.Radius * 2
-	barX := int(screenX) - int(e.Radius)
-	barY := int(screenY) + int(e.Radius) + 4
+	renderHealthBar(g, screenX, screenY, e.Radius, e.Health, e.MaxHealth, e.OSD)
+}
+
+// renderHealthBar renders a health bar below something of the given radius
+// centered on screen position (screenX, screenY), faded by osd.
+func renderHealthBar(g *Game, screenX, screenY, radius float64, health, maxHealth, osd int) {
+	barWidth := radius * 2
+	barX := math.Floor(screenX) - math.Floor(radius)
+	barY := math.Floor(screenY) + math.Floor(radius) + 4
 
 	// Calculate health percentage and color
-	healthPercent := float64(e.Health) / float64(e.MaxHealth)
+	healthPercent := float64(health) / float64(maxHealth)
 	if healthPercent < 0 {
 		healthPercent = 0
 	}
 	colorValue := int(healthPercent * 512)
 
-	g.Ctx.Set("globalAlpha", float64(e.OSD)/float64(ShipMaxOSD))
-	g.Ctx.Set("fillStyle", Theme.EnergyBarBackground)
-	g.Ctx.Call("fillRect", barX, barY, int(barWidth), 3)
+	g.Ctx.SetGlobalAlpha(float64(osd) / float64(ShipMaxOSD))
+	g.Ctx.SetFillStyle(Theme.EnergyBarBackground)
+	g.Ctx.FillRect(barX, barY, math.Floor(barWidth), 3)
 
 	var r, gr int
 	if colorValue > 255 {
@@ -531,13 +562,40 @@ func (e *Enemy) RenderHealthBar(g *Game, screenX, screenY float64) {
 		r = 255
 		gr = colorValue
 	}
-	g.Ctx.Set("fillStyle", "rgb("+strconv.Itoa(r)+","+strconv.Itoa(gr)+",0)")
-	g.Ctx.Call("fillRect", barX, barY, int(barWidth*healthPercent), 3)
+	g.Ctx.SetFillStyle("rgb(" + strconv.Itoa(r) + "," + strconv.Itoa(gr) + ",0)")
+	g.Ctx.FillRect(barX, barY, math.Floor(barWidth*healthPercent), 3)
 
-	g.Ctx.Set("lineWidth", Theme.EnergyBarLineWidth)
-	g.Ctx.Set("strokeStyle", Theme.EnergyBarBorder)
-	g.Ctx.Call("strokeRect", barX, barY, int(barWidth), 3)
-	g.Ctx.Set("globalAlpha", 1)
+	g.Ctx.SetLineWidth(Theme.EnergyBarLineWidth)
+	g.Ctx.SetStrokeStyle(Theme.EnergyBarBorder)
+	g.Ctx.StrokeRect(barX, barY, math.Floor(barWidth), 3)
+	g.Ctx.SetGlobalAlpha(1)
+}
+
+// EnemyPickSlack widens the hit area of enemies for EnemyAt, so small
+// fighters can be clicked without pixel precision.
+const EnemyPickSlack = 24.0
+
+// EnemyAt returns the live on-screen enemy under world position (x, y), or
+// nil. Where hit areas overlap, the enemy whose center is closest wins.
+func (g *Game) EnemyAt(x, y float64) *Enemy {
+	var hit *Enemy
+	hitDistSq := math.MaxFloat64
+	for _, enemy := range g.Enemies {
+		if !enemy.IsAlive() {
+			continue
+		}
+		ex, ey := enemy.X, enemy.Y+enemy.YOffset
+		if !g.Camera.IsOnScreen(ex, ey, enemy.Radius) {
+			continue
+		}
 
-	e.OSD--
+		dx, dy := ex-x, ey-y
+		distSq := dx*dx + dy*dy
+		r := enemy.Radius + EnemyPickSlack
+		if distSq <= r*r && distSq < hitDistSq {
+			hitDistSq = distSq
+			hit = enemy
+		}
+	}
+	return hit
 }
@@ -6,6 +6,7 @@ import (
 	"github.com/gopherjs/gopherjs/js"
 	"github.com/simukka/starship-sorades-13k/audio"
 	"github.com/simukka/starship-sorades-13k/common"
+	"github.com/simukka/starship-sorades-13k/render"
 )
 
 // Game holds the complete game state.
@@ -16,8 +17,17 @@ type Game struct {
 	Ship     *Ship
 	Enemies  []*Enemy
 	Bases    []*Base
+	World    *World // Chunks around the camera and ships
 	GameSeed uint32
-	GameRNG  *common.SeededRNG
+	GameRNG  *common.SeededRNG // Gameplay randomness; part of the simulation state
+	FxRNG    *common.SeededRNG // Cosmetic randomness; never affects gameplay
+	Tick     uint32            // Simulation ticks since the game started
+	Mode     GameMode          // Infinite world or campaign
+	State    GameState         // Screen the game is on
+
+	Difficulty   Difficulty // Scales enemies and the damage the ship takes
+	GameOverTick uint32     // Tick the local ship was destroyed or the campaign won
+	Campaign     *Campaign  // Campaign progress, nil in infinite mode
 
 	// Object pools
 	Bullets    *BulletPool
@@ -29,27 +39,47 @@ type Game struct {
 
 	// Rendering
 	Canvas *js.Object
-	Ctx    *js.Object
+	Ctx    render.Renderer
 
 	// Input
-	Keys map[int]bool
+	Keyboard   *KeyboardInput // Local keyboard
+	Gamepad    *GamepadInput  // Local gamepad, if one is plugged in
+	Touch      *TouchInput    // On-screen controls of touch devices
+	Mouse      *MouseInput    // Mouse aiming and click-to-lock
+	LocalInput InputSource    // All local devices combined, the default Input of the local ship
+
+	BindingsScreen *BindingsScreen // Key rebinding screen
+	Shop           *ShopScreen     // Upgrade shop of the base the ship is docked in
+	Title          *TitleScreen    // Game mode and difficulty menu of the title screen
+
+	// Replay
+	Recording *Replay       // Inputs of the local ship since the last Reset
+	Playback  *ReplayPlayer // Non-nil while a replay drives the game
+	Demo      *Autopilot    // Non-nil while attract mode flies the ship
 
 	// Animation
 	AnimationFrameID int
 	LastFrameTime    float64
 
 	// Graphics assets
-	BulletImage    *js.Object
-	ExplosionImage *js.Object
-	TorpedoImages  []*js.Object
+	BulletImage    render.Image
+	ExplosionImage render.Image
+	TorpedoImages  []render.Image
 	TorpedoFrame   int
-	BonusImages    map[string]*js.Object
+	MineImage      render.Image
+	MissileImage   render.Image
+	ChargeImage    render.Image
+	BonusImages    map[string]render.Image
 	EnemyTypes     map[EnemyKind]EnemyType
 
+	// Enemy spawn configuration and wave rules
+	Spawns *SpawnTable
+
 	// Debug UI
 	// DebugUI      *DebugUI
 	StatsOverlay *StatsOverlay
 	ShipHUD      *ShipHUD
+	Radar        *Radar
 
 	// Collision detection
 	EnemyGrid  *SpatialGrid // Spatial hash for enemies
@@ -62,29 +92,13 @@ type Game struct {
 	Network *NetworkManager
 }
 
-// NewGame creates a new game instance.
-func NewGame(canvas, ctx *js.Object) *Game {
-	seed := common.NewSeededRNG(0)
-	g := &Game{
-		Enemies:     make([]*Enemy, 0, 64),
-		Bases:       make([]*Base, 0, 8),
-		GameRNG:     seed,
-		Bullets:     NewBulletPool(350),
-		Explosions:  NewExplosionPool(50),
-		Bonuses:     NewBonusPool(30),
-		Audio:       audio.NewAudioManager(seed, HEIGHT),
-		Keys:        make(map[int]bool),
-		BonusImages: make(map[string]*js.Object),
-		EnemyTypes:  make(map[EnemyKind]EnemyType, 4),
-		// DebugUI:      NewDebugUI(),
-		StatsOverlay: NewStatsOverlay(),
-		ShipHUD:      NewShipHUD(),
-		EnemyGrid:    NewSpatialGrid(WIDTH, HEIGHT, 64),
-		BulletGrid:   NewSpatialGrid(WIDTH, HEIGHT, 64),
-		Camera:       &Camera{X: 0, Y: 0},
-		Canvas:       canvas,
-		Ctx:          ctx,
-	}
+// NewGame creates a new game instance drawing to the given canvas element.
+func NewGame(canvas *js.Object) *Game {
+	g := newGame()
+	g.Canvas = canvas
+	g.Ctx = render.NewCanvas2D(canvas)
+	g.Recording = NewReplay(g.GameSeed)
+
 	// Initialize audio
 	sounds := g.Audio.Init()
 
@@ -96,20 +110,73 @@ func NewGame(canvas, ctx *js.Object) *Game {
 	// Initialize audio control panel (right-click to open)
 	g.Audio.InitControlPanel(g.Canvas)
 
-	g.initLevelDefaults()
-	g.initShipDefaults()
-
 	// Initialize graphics (background, sprites, etc.)
 	// Must be after initShipDefaults() so ship sprites can be rendered
 	g.InitializeGraphics()
 
 	g.SetupInputHandlers()
 
-	g.initBases()
+	// Designers can tune waves in waves.json without rebuilding
+	g.LoadSpawnTable(SpawnTableURL)
+
+	// The title screen and attract mode run until the first key press
+	g.StartDemo()
+
 	g.Start()
 	return g
 }
 
+// NewHeadlessGame creates a game without a canvas, audio output or input
+// handlers. Only the simulation state is set up, so the game can be advanced
+// with Step natively (tests, tools, server-side simulation) but never rendered.
+func NewHeadlessGame(seed uint32) *Game {
+	g := newGame()
+	g.SetGameSeed(seed)
+	g.Recording = NewReplay(seed)
+	g.State = StatePlaying
+	return g
+}
+
+// newGame creates the browser independent part of a game: world state,
+// object pools and collision grids.
+func newGame() *Game {
+	g := &Game{
+		Enemies:        make([]*Enemy, 0, 64),
+		Bases:          make([]*Base, 0, 8),
+		World:          NewWorld(0),
+		GameRNG:        common.NewSeededRNG(0),
+		FxRNG:          common.NewSeededRNG(common.FxSeed(0)),
+		Bullets:        NewBulletPool(350),
+		Explosions:     NewExplosionPool(50),
+		Bonuses:        NewBonusPool(30),
+		Audio:          audio.NewAudioManager(common.NewSeededRNG(0), HEIGHT),
+		Keyboard:       NewKeyboardInput(),
+		Gamepad:        NewGamepadInput(),
+		Touch:          NewTouchInput(),
+		Mouse:          NewMouseInput(),
+		BindingsScreen: NewBindingsScreen(),
+		Shop:           NewShopScreen(),
+		Title:          &TitleScreen{},
+		BonusImages:    make(map[string]render.Image),
+		EnemyTypes:     make(map[EnemyKind]EnemyType, EnemyKindCount),
+		Spawns:         DefaultSpawnTable(),
+		// DebugUI:      NewDebugUI(),
+		StatsOverlay: NewStatsOverlay(),
+		ShipHUD:      NewShipHUD(),
+		Radar:        NewRadar(),
+		EnemyGrid:    NewSpatialGrid(WIDTH, HEIGHT, 64),
+		BulletGrid:   NewSpatialGrid(WIDTH, HEIGHT, 64),
+		Camera:       &Camera{X: 0, Y: 0},
+	}
+	g.LocalInput = CombinedInput{g.Keyboard, g.Gamepad, g.Touch, g.Mouse, g.Shop}
+
+	g.initLevelDefaults()
+	g.initShipDefaults()
+	g.initEnemyTypes()
+	g.initBases()
+	return g
+}
+
 // initShipDefaults initializes ship state to default values.
 func (g *Game) initShipDefaults() {
 	g.Ships = append(g.Ships, &Ship{
@@ -128,18 +195,35 @@ func (g *Game) initShipDefaults() {
 		Shield: Shield{
 			MaxT: ShipMaxShield,
 		},
-		local:  true,
-		Paused: false,
+		local: true,
 	})
 
 	g.Ship = g.Ships[0]
 	g.Ship.AddWeapon()
 }
 
-// initBases initializes the starting base(s).
+// initBases initializes the starting base and generates the world around
+// it.
 func (g *Game) initBases() {
 	// Create a base at the world origin (where the ship starts)
 	g.Bases = append(g.Bases, NewBase(0, 0))
+
+	g.World.Clear()
+	g.UpdateWorld()
+}
+
+// initEnemyTypes sets the collision radius of every enemy kind.
+// Sprites are attached later by InitializeEnemyGraphics, so a headless game
+// uses the same hitboxes as the browser build.
+func (g *Game) initEnemyTypes() {
+	r := float64(ShipR)
+	g.EnemyTypes[SmallFighter] = EnemyType{R: r}
+	g.EnemyTypes[MediumFighter] = EnemyType{R: r}
+	g.EnemyTypes[TurretFighter] = EnemyType{R: r}
+	g.EnemyTypes[Boss] = EnemyType{R: float64(maxInt(WIDTH, HEIGHT) / 8)}
+	g.EnemyTypes[Kamikaze] = EnemyType{R: KamikazeR}
+	g.EnemyTypes[MineLayer] = EnemyType{R: r}
+	g.EnemyTypes[Carrier] = EnemyType{R: CarrierR}
 }
 
 // initLevelDefaults initializes level state to default values.
@@ -150,13 +234,12 @@ func (g *Game) initLevelDefaults() {
 		P:         0,
 		Y:         0,
 		Bomb:      0,
-		Paused:    false,
 		Text:      TextDisplay{MaxT: 90},
 		Points: Points{
 			Width:  32,
 			Height: 48,
 			Step:   24,
-			Images: make([]*js.Object, 10),
+			Images: make([]render.Image, 10),
 		},
 	}
 }
@@ -171,12 +254,12 @@ func (g *Game) Explode(x, y, size float64) {
 	exp.X = x
 	exp.Y = y
 	if size == 0 {
-		exp.Size = js.Global.Get("Math").Call("random").Float() * 64
+		exp.Size = g.FxRNG.Random() * 64
 	} else {
 		exp.Size = size
 	}
-	exp.Angle = js.Global.Get("Math").Call("random").Float()
-	exp.D = js.Global.Get("Math").Call("random").Float()*0.4 - 0.2
+	exp.Angle = g.FxRNG.Random()
+	exp.D = g.FxRNG.Random()*0.4 - 0.2
 	exp.Alpha = 1
 }
 
@@ -197,11 +280,6 @@ func (g *Game) SpawnBonus(x, y, xAcc, yAcc float64, bonusType string) {
 		}
 	}
 
-	// Lazy render bonus image if not cached
-	if _, ok := g.BonusImages[bonusType]; !ok {
-		g.RenderBonusImage(bonusType)
-	}
-
 	item := g.Bonuses.Acquire()
 	if item == nil {
 		return
@@ -226,37 +304,65 @@ func (g *Game) SpawnBonus(x, y, xAcc, yAcc float64, bonusType string) {
 	item.YAcc = yAcc/2 + Speed/2
 }
 
-// SpawnText displays a centered text message.
+// SpawnText displays a centered text message that drifts down and fades
+// out over duration ticks (0 for the default Text.MaxT, negative to keep it
+// on screen until the next message). The simulation only sets the message;
+// RenderText draws its image on demand, so waves can announce themselves in
+// headless games too.
 func (g *Game) SpawnText(text string, duration int) {
-	g.RenderTextImage(text)
+	g.Level.Text.Message = text
+	g.Level.Text.Image = nil
 	g.spawnTextWithDuration(duration)
-
-	if duration < 0 {
-		g.Ctx.Set("globalAlpha", 1)
-		g.Ctx.Call("drawImage", g.Level.Text.Image, g.Level.Text.X, g.Level.Text.Y)
-	}
 }
 
 // spawnTextWithDuration sets up text display position and timing.
-// Extracted for testability without browser APIs.
 func (g *Game) spawnTextWithDuration(duration int) {
-	g.Level.Text.X = (WIDTH - g.Level.Text.Image.Get("width").Int()) / 2
+	g.Level.Text.X = (WIDTH - TextImageWidth) / 2
 	g.Level.Text.Y = 16
 	g.Level.Text.YAcc = Speed / 2
 	if duration == 0 {
 		g.Level.Text.T = g.Level.Text.MaxT
 	} else if duration < 0 {
-		g.Level.Text.T = 0
+		g.Level.Text.T = -1
 	} else {
 		g.Level.Text.T = duration
 	}
 }
 
+// UpdateText moves the text message down while it fades out.
+func (g *Game) UpdateText() {
+	if g.Level.Text.T > 0 {
+		g.Level.Text.T--
+		g.Level.Text.Y += int(g.Level.Text.YAcc)
+	}
+}
+
+// RenderText draws the text message, rendering its image on first use.
+func (g *Game) RenderText() {
+	t := &g.Level.Text
+	if t.T == 0 || t.Message == "" {
+		return
+	}
+	if t.Image == nil {
+		g.RenderTextImage(t.Message)
+	}
+
+	alpha := 1.0
+	if t.T > 0 {
+		alpha = math.Min(float64(t.T)/float64(t.MaxT), 1)
+	}
+	g.Ctx.SetGlobalAlpha(alpha)
+	g.Ctx.DrawImage(t.Image, float64(t.X), float64(t.Y))
+	g.Ctx.SetGlobalAlpha(1)
+}
+
 // SetGameSeed sets the game seed for deterministic gameplay.
 func (g *Game) SetGameSeed(seed uint32) {
 	g.GameSeed = seed
 	g.GameRNG.SetSeed(seed)
+	g.FxRNG.SetSeed(common.FxSeed(seed))
 	g.Level.LevelNum = 0
+	g.SetWorldSeed(seed)
 }
 
 // GetGameSeed returns the current game seed.
@@ -284,8 +390,6 @@ func (g *Game) Start() {
 	if g.Audio.AudioCtx != nil && g.Audio.AudioCtx.Get("state").String() == "suspended" {
 		g.Audio.AudioCtx.Call("resume")
 	}
-
-	g.JoinMultiplayer("vipps")
 }
 
 // RemoveEnemy removes an enemy using swap-and-pop.
@@ -341,6 +445,9 @@ func (g *Game) FindNearestEntity(x, y float64) Entity {
 	return nearest
 }
 
+// DefaultRoomID is the multiplayer room joined when a session starts.
+const DefaultRoomID = "vipps"
+
 // JoinMultiplayer joins a multiplayer room or creates one if it doesn't exist.
 // The first player to join becomes the host.
 func (g *Game) JoinMultiplayer(roomID string) {
@@ -360,32 +467,24 @@ func (g *Game) LeaveMultiplayer() {
 	}
 }
 
-// ProcessInput handles player input.
-func (g *Game) ProcessInput() {
-	// Determine if we're a network client (not host)
-	isNetworkClient := g.Network != nil && g.Network.IsConnected() && !g.Network.IsHost()
-
-	// Fire weapon (X key) - only on host or single player
-	// Clients send input to host, host handles firing
-	if g.Keys[88] && !isNetworkClient {
-		g.Ship.Fire(g)
-	}
-
-	g.Ship.Move(g, g.Keys)
+// IsNetworkClient reports whether this game follows a remote host.
+// Clients only predict their own ship; the host simulates everything else.
+func (g *Game) IsNetworkClient() bool {
+	return g.Network != nil && g.Network.IsConnected() && !g.Network.IsHost()
 }
 
 // RenderBackground renders the scrolling background based on camera position.
 // Creates an infinite scrolling effect by tiling the background pattern.
 func (g *Game) RenderBackground() {
-	g.Ctx.Call("save")
-	bgWidth := g.Level.Background.Get("width").Float()
+	g.Ctx.Save()
+	bgWidth := float64(g.Level.Background.Width())
 
 	// Calculate offset based on camera position for parallax effect
 	offsetX := math.Mod(-g.Camera.X*0.5, bgWidth)
 	offsetY := math.Mod(-g.Camera.Y*0.5, bgWidth)
 
-	g.Ctx.Call("translate", offsetX, offsetY)
-	g.Ctx.Set("fillStyle", g.Level.BackgroundPattern)
-	g.Ctx.Call("fillRect", -bgWidth, -bgWidth, WIDTH+bgWidth*2, HEIGHT+bgWidth*2)
-	g.Ctx.Call("restore")
+	g.Ctx.Translate(offsetX, offsetY)
+	g.Ctx.SetFillPattern(g.Level.Background)
+	g.Ctx.FillRect(-bgWidth, -bgWidth, WIDTH+bgWidth*2, HEIGHT+bgWidth*2)
+	g.Ctx.Restore()
 }
@@ -1,10 +1,25 @@
 package game
 
 import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"image"
+	"image/png"
 	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
 	"testing"
+
+	"github.com/simukka/starship-sorades-13k/common"
+	"github.com/simukka/starship-sorades-13k/render"
 )
 
+var update = flag.Bool("update", false, "rewrite expected results in testdata")
+
 // =============================================================================
 // Ship Tests
 // =============================================================================
@@ -1107,3 +1122,3064 @@ func TestRotation_CounterClockwise(t *testing.T) {
 		t.Errorf("Angle after counter-clockwise rotation = %v, want %v", angle, -ShipRotationSpeed)
 	}
 }
+
+// =============================================================================
+// Headless Simulation Tests
+// =============================================================================
+
+func TestHeadless_NewGame(t *testing.T) {
+	g := NewHeadlessGame(42)
+
+	if g.GameSeed != 42 {
+		t.Errorf("GameSeed = %v, want 42", g.GameSeed)
+	}
+	if g.Ship == nil || len(g.Ships) != 1 {
+		t.Fatal("Headless game should have exactly one local ship")
+	}
+	if g.Canvas != nil || g.Ctx != nil {
+		t.Error("Headless game should not have a canvas")
+	}
+	if g.EnemyTypes[Boss].R <= g.EnemyTypes[SmallFighter].R {
+		t.Error("Enemy types should have their hitbox radius set without graphics")
+	}
+}
+
+func TestHeadless_StepAdvancesTick(t *testing.T) {
+	g := NewHeadlessGame(1)
+
+	for i := 0; i < 10; i++ {
+		g.Step(nil)
+	}
+
+	if g.Tick != 10 {
+		t.Errorf("Tick = %v, want 10", g.Tick)
+	}
+	if g.TorpedoFrame != 10%TorpedoFrameCount {
+		t.Errorf("TorpedoFrame = %v, want %v", g.TorpedoFrame, 10%TorpedoFrameCount)
+	}
+}
+
+func TestHeadless_StepThrustMovesShip(t *testing.T) {
+	g := NewHeadlessGame(1)
+
+	for i := 0; i < 30; i++ {
+		g.Step([]ControlState{{Keys: KeyUp}})
+	}
+
+	if g.Ship.Y >= 0 {
+		t.Errorf("Ship.Y = %v after thrusting up, want negative", g.Ship.Y)
+	}
+	if g.Camera.Y != g.Ship.Y {
+		t.Errorf("Camera.Y = %v, want to follow local ship at %v", g.Camera.Y, g.Ship.Y)
+	}
+}
+
+func TestHeadless_StepSpawnsEnemiesAndFires(t *testing.T) {
+	g := NewHeadlessGame(7)
+	g.Ship.X = BaseShieldRadius * 4 // Ships cannot fire inside the base shield
+
+	for i := 0; i < 600; i++ {
+		keys := uint16(KeyFire | KeyLeft)
+		if i%60 == 0 {
+			keys |= KeyLock
+		}
+		g.Step([]ControlState{{Keys: keys}})
+	}
+
+	if len(g.Enemies) == 0 {
+		t.Error("Enemies should spawn while stepping headless")
+	}
+	if g.Level.P == 0 && g.Bullets.ActiveCount == 0 {
+		t.Error("Firing should produce bullets or hits")
+	}
+}
+
+func TestHeadless_UpdateEnemiesRemovesAll(t *testing.T) {
+	g := NewHeadlessGame(1)
+
+	for i := 0; i < 4; i++ {
+		g.Enemies = append(g.Enemies, &Enemy{Health: 0, Radius: ShipR, Target: g.Ship})
+	}
+	g.UpdateEnemies()
+
+	if len(g.Enemies) != 0 {
+		t.Errorf("len(Enemies) = %v after all died, want 0", len(g.Enemies))
+	}
+}
+
+// =============================================================================
+// Determinism Tests
+// =============================================================================
+
+// scriptedKeys returns a fixed input pattern that thrusts, turns, locks and
+// fires, so enemies, bullets, bonuses and target locks all get exercised.
+func scriptedKeys(tick int) uint16 {
+	keys := uint16(KeyFire)
+	if tick < 40 || tick%90 < 20 {
+		keys |= KeyUp
+	}
+	if tick%120 < 30 {
+		keys |= KeyLeft
+	}
+	if tick%45 == 0 {
+		keys |= KeyLock
+	}
+	return keys
+}
+
+func TestDeterminism_SameSeedSameChecksum(t *testing.T) {
+	a := NewHeadlessGame(1234)
+	b := NewHeadlessGame(1234)
+
+	for i := 0; i < 900; i++ {
+		inputs := []ControlState{{Keys: scriptedKeys(i)}}
+		a.Step(inputs)
+		b.Step(inputs)
+		if a.Checksum() != b.Checksum() {
+			t.Fatalf("Simulations diverged at tick %d", a.Tick)
+		}
+	}
+	if len(a.Enemies) == 0 {
+		t.Error("Scripted run should have spawned enemies")
+	}
+}
+
+func TestDeterminism_DifferentSeedDiverges(t *testing.T) {
+	a := NewHeadlessGame(1)
+	b := NewHeadlessGame(2)
+
+	for i := 0; i < 300; i++ {
+		inputs := []ControlState{{Keys: scriptedKeys(i)}}
+		a.Step(inputs)
+		b.Step(inputs)
+	}
+	if a.Checksum() == b.Checksum() {
+		t.Error("Different seeds should produce different simulations")
+	}
+}
+
+func TestDeterminism_CosmeticRandomnessIgnored(t *testing.T) {
+	a := NewHeadlessGame(99)
+	b := NewHeadlessGame(99)
+
+	for i := 0; i < 300; i++ {
+		// Extra explosions draw from FxRNG only
+		b.Explode(0, 0, 0)
+		inputs := []ControlState{{Keys: scriptedKeys(i)}}
+		a.Step(inputs)
+		b.Step(inputs)
+	}
+	if a.Checksum() != b.Checksum() {
+		t.Error("Cosmetic randomness should not change the gameplay checksum")
+	}
+}
+
+func TestDeterminism_JoinPositionLeavesGameRNG(t *testing.T) {
+	g := NewHeadlessGame(5)
+	nm := &NetworkManager{game: g}
+	state := g.GameRNG.State()
+
+	x, y := nm.joinPosition("peer-1")
+	if g.GameRNG.State() != state {
+		t.Error("Placing a joining ship should not draw from the gameplay RNG")
+	}
+	if math.Abs(x-g.Ship.X) > 100 || math.Abs(y-g.Ship.Y) > 100 {
+		t.Errorf("joinPosition() = (%v, %v), want within 100 of the ship", x, y)
+	}
+	if x2, y2 := nm.joinPosition("peer-1"); x2 != x || y2 != y {
+		t.Error("The same player should always join at the same offset")
+	}
+}
+
+func TestDeterminism_ChecksumTracksState(t *testing.T) {
+	g := NewHeadlessGame(5)
+	before := g.Checksum()
+
+	g.Ship.X += 1
+	if g.Checksum() == before {
+		t.Error("Checksum should change when a ship moves")
+	}
+}
+
+// =============================================================================
+// Replay Tests
+// =============================================================================
+
+func TestReplay_RoundTrip(t *testing.T) {
+	r := NewReplay(777)
+	for i := 0; i < 500; i++ {
+		r.Record(ControlState{Keys: scriptedKeys(i)})
+	}
+
+	data, err := r.MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary() error = %v", err)
+	}
+	got, err := DecodeReplay(data)
+	if err != nil {
+		t.Fatalf("DecodeReplay() error = %v", err)
+	}
+
+	if got.Seed != 777 {
+		t.Errorf("Seed = %v, want 777", got.Seed)
+	}
+	if got.Ticks() != r.Ticks() {
+		t.Fatalf("Ticks() = %v, want %v", got.Ticks(), r.Ticks())
+	}
+	for i := range r.Inputs {
+		if got.Inputs[i] != r.Inputs[i] {
+			t.Fatalf("Inputs[%d] = %v, want %v", i, got.Inputs[i], r.Inputs[i])
+		}
+	}
+}
+
+func TestReplay_Compact(t *testing.T) {
+	r := NewReplay(1)
+	for i := 0; i < 1800; i++ {
+		r.Record(ControlState{Keys: KeyUp | KeyFire}) // A minute of held keys
+	}
+
+	data, _ := r.MarshalBinary()
+	if len(data) > 17 {
+		t.Errorf("Encoded size = %d bytes, want at most 17", len(data))
+	}
+}
+
+func TestReplay_DecodeErrors(t *testing.T) {
+	valid, _ := (&Replay{Seed: 1, Inputs: []ControlState{{Keys: KeyUp}, {Keys: KeyUp}, {Keys: KeyFire}}}).MarshalBinary()
+
+	tests := []struct {
+		name string
+		data []byte
+		want error
+	}{
+		{"empty", nil, ErrReplayTruncated},
+		{"bad magic", append([]byte("XXXX"), valid[4:]...), ErrReplayMagic},
+		{"bad version", append(append([]byte("SRPL"), 99), valid[5:]...), ErrReplayVersion},
+		{"truncated runs", valid[:len(valid)-1], ErrReplayTruncated},
+		{"trailing data", append(append([]byte{}, valid...), 1, 1), ErrReplayCorrupt},
+	}
+
+	for _, tt := range tests {
+		if _, err := DecodeReplay(tt.data); err != tt.want {
+			t.Errorf("%s: DecodeReplay() error = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestReplay_PlaybackReproducesSession(t *testing.T) {
+	g := NewHeadlessGame(4242)
+	for i := 0; i < 600; i++ {
+		g.Step([]ControlState{{Keys: scriptedKeys(i)}})
+	}
+	want := g.Checksum()
+
+	data, _ := g.Recording.MarshalBinary()
+	r, err := DecodeReplay(data)
+	if err != nil {
+		t.Fatalf("DecodeReplay() error = %v", err)
+	}
+
+	g.StartReplay(r)
+	if g.Tick != 0 || len(g.Enemies) != 0 {
+		t.Fatal("StartReplay should reset the simulation")
+	}
+	g.Playback.SetSpeed(ReplayMaxSpeed)
+	for !g.Playback.Finished(g) {
+		g.Playback.Advance(g)
+	}
+
+	if g.Checksum() != want {
+		t.Error("Replay playback should reproduce the recorded session")
+	}
+}
+
+func TestReplayPlayer_PauseStepSpeed(t *testing.T) {
+	g := NewHeadlessGame(1)
+	r := NewReplay(1)
+	for i := 0; i < 100; i++ {
+		r.Record(ControlState{Keys: KeyUp})
+	}
+	g.StartReplay(r)
+	p := g.Playback
+
+	p.HandleAction(ActionThrust) // Up doubles speed
+	if p.Speed != 2 {
+		t.Errorf("Speed = %v, want 2", p.Speed)
+	}
+	if n := p.Advance(g); n != 2 {
+		t.Errorf("Advance() at x2 ran %d ticks, want 2", n)
+	}
+
+	p.HandleAction(ActionPause) // P pauses
+	if n := p.Advance(g); n != 0 {
+		t.Errorf("Advance() while paused ran %d ticks, want 0", n)
+	}
+
+	p.HandleAction(ActionRotateRight) // Right steps one tick
+	if n := p.Advance(g); n != 1 {
+		t.Errorf("Advance() after step ran %d ticks, want 1", n)
+	}
+
+	p.SetSpeed(1000)
+	if p.Speed != ReplayMaxSpeed {
+		t.Errorf("Speed = %v, want clamped to %v", p.Speed, ReplayMaxSpeed)
+	}
+	if p.HandleAction(ActionFire) {
+		t.Error("Fire key should not be a playback control")
+	}
+}
+
+func TestReplayPlayer_StopsAtEnd(t *testing.T) {
+	g := NewHeadlessGame(1)
+	g.StartReplay(&Replay{Seed: 1, Inputs: make([]ControlState, 3)})
+	g.Playback.SetSpeed(ReplayMaxSpeed)
+
+	if n := g.Playback.Advance(g); n != 3 {
+		t.Errorf("Advance() ran %d ticks, want 3", n)
+	}
+	if !g.Playback.Finished(g) || g.Tick != 3 {
+		t.Errorf("Replay should finish at tick 3, got tick %d", g.Tick)
+	}
+}
+
+// TestReplay_Regressions replays every testdata/replays/*.srpl file and
+// compares the result with the matching .want file (the output of
+// cmd/sorades-replay). Drop a player's replay into that directory to turn a
+// bug report into a regression test; run with -update after intentional
+// gameplay changes.
+func TestReplay_Regressions(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("testdata", "replays", "*.srpl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) == 0 {
+		t.Fatal("No replays found in testdata/replays")
+	}
+
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r, err := DecodeReplay(data)
+		if err != nil {
+			t.Fatalf("%s: %v", file, err)
+		}
+		got := RunReplay(r).String()
+
+		wantFile := strings.TrimSuffix(file, ".srpl") + ".want"
+		if *update {
+			if err := os.WriteFile(wantFile, []byte(got), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		want, err := os.ReadFile(wantFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != string(want) {
+			t.Errorf("%s:\ngot:\n%s\nwant:\n%s", file, got, want)
+		}
+	}
+}
+
+func TestRunReplay_MatchesLiveGame(t *testing.T) {
+	g := NewHeadlessGame(31337)
+	for i := 0; i < 450; i++ {
+		g.Step([]ControlState{{Keys: scriptedKeys(i)}})
+	}
+
+	res := RunReplay(g.Recording)
+	if res.Hash != g.Checksum() || res.Ticks != g.Tick {
+		t.Errorf("RunReplay() = %+v, want hash %08x at tick %d", res, g.Checksum(), g.Tick)
+	}
+	if res.Health != g.Ship.E || res.Score != g.Level.P {
+		t.Errorf("RunReplay() health/score = %d/%d, want %d/%d", res.Health, res.Score, g.Ship.E, g.Level.P)
+	}
+}
+
+// =============================================================================
+// Renderer Tests
+// =============================================================================
+
+// recorder is a render.Surface that records the drawing operations it
+// receives, so rendering logic can be checked without a canvas.
+type recorder struct {
+	w, h int
+	ops  []string
+}
+
+func (r *recorder) record(op string, args ...float64) {
+	for _, a := range args {
+		op += " " + strconv.FormatFloat(a, 'f', -1, 64)
+	}
+	r.ops = append(r.ops, op)
+}
+
+func (r *recorder) has(op string) bool {
+	for _, o := range r.ops {
+		if o == op {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *recorder) Width() int                          { return r.w }
+func (r *recorder) Height() int                         { return r.h }
+func (r *recorder) Save()                               { r.record("save") }
+func (r *recorder) Restore()                            { r.record("restore") }
+func (r *recorder) Translate(x, y float64)              { r.record("translate", x, y) }
+func (r *recorder) Rotate(angle float64)                { r.record("rotate", angle) }
+func (r *recorder) Scale(x, y float64)                  { r.record("scale", x, y) }
+func (r *recorder) SetFillStyle(style string)           { r.record("fillStyle " + style) }
+func (r *recorder) SetFillPattern(img render.Image)     { r.record("fillPattern") }
+func (r *recorder) SetStrokeStyle(style string)         { r.record("strokeStyle " + style) }
+func (r *recorder) SetLineWidth(width float64)          { r.record("lineWidth", width) }
+func (r *recorder) SetLineJoin(join string)             { r.record("lineJoin " + join) }
+func (r *recorder) SetMiterLimit(limit float64)         { r.record("miterLimit", limit) }
+func (r *recorder) SetShadowBlur(blur float64)          { r.record("shadowBlur", blur) }
+func (r *recorder) SetShadowColor(color string)         { r.record("shadowColor " + color) }
+func (r *recorder) SetGlobalAlpha(alpha float64)        { r.record("globalAlpha", alpha) }
+func (r *recorder) SetCompositeOperation(op string)     { r.record("composite " + op) }
+func (r *recorder) SetFont(font string)                 { r.record("font " + font) }
+func (r *recorder) SetTextAlign(align string)           { r.record("textAlign " + align) }
+func (r *recorder) SetTextBaseline(baseline string)     { r.record("textBaseline " + baseline) }
+func (r *recorder) BeginPath()                          { r.record("beginPath") }
+func (r *recorder) ClosePath()                          { r.record("closePath") }
+func (r *recorder) MoveTo(x, y float64)                 { r.record("moveTo", x, y) }
+func (r *recorder) LineTo(x, y float64)                 { r.record("lineTo", x, y) }
+func (r *recorder) Arc(x, y, rad, start, end float64)   { r.record("arc", x, y, rad, start, end) }
+func (r *recorder) Fill()                               { r.record("fill") }
+func (r *recorder) Stroke()                             { r.record("stroke") }
+func (r *recorder) FillRect(x, y, w, h float64)         { r.record("fillRect", x, y, w, h) }
+func (r *recorder) StrokeRect(x, y, w, h float64)       { r.record("strokeRect", x, y, w, h) }
+func (r *recorder) FillText(t string, x, y, mw float64) { r.record("fillText "+t, x, y) }
+func (r *recorder) StrokeText(t string, x, y, mw float64) {
+	r.record("strokeText "+t, x, y)
+}
+func (r *recorder) DrawImage(img render.Image, x, y float64) { r.record("drawImage", x, y) }
+func (r *recorder) DrawImageScaled(img render.Image, x, y, w, h float64) {
+	r.record("drawImage", x, y, w, h)
+}
+func (r *recorder) NewImage(width, height int, draw func(s render.Surface)) render.Image {
+	img := &recorder{w: width, h: height}
+	draw(img)
+	return img
+}
+
+func TestRender_ShipAtScreenCenter(t *testing.T) {
+	g := NewHeadlessGame(1)
+	rec := &recorder{w: WIDTH, h: HEIGHT}
+	g.Ctx = rec
+
+	g.Ship.Render(g)
+
+	if !rec.has("translate " + strconv.Itoa(WIDTH/2) + " " + strconv.Itoa(HEIGHT/2)) {
+		t.Errorf("Local ship should be drawn at the screen center, got %v", rec.ops)
+	}
+}
+
+func TestRender_EnemyOffScreenSkipped(t *testing.T) {
+	g := NewHeadlessGame(1)
+	rec := &recorder{w: WIDTH, h: HEIGHT}
+	g.Ctx = rec
+
+	e := &Enemy{X: WIDTH * 4, Y: HEIGHT * 4, Radius: ShipR, Health: 1, MaxHealth: 1}
+	e.Render(g)
+
+	if len(rec.ops) != 0 {
+		t.Errorf("Off-screen enemy should not draw, got %v", rec.ops)
+	}
+}
+
+func TestRender_EnemyHealthBarWhenHit(t *testing.T) {
+	g := NewHeadlessGame(1)
+	rec := &recorder{w: WIDTH, h: HEIGHT}
+	g.Ctx = rec
+
+	e := &Enemy{X: 0, Y: 0, Radius: ShipR, Health: 1, MaxHealth: 2, OSD: ShipMaxOSD}
+	e.Render(g)
+
+	half := strconv.Itoa(ShipR) + " 3" // Bar is 2*Radius wide
+	filled := false
+	for _, op := range rec.ops {
+		if strings.HasPrefix(op, "fillRect ") && strings.HasSuffix(op, " "+half) {
+			filled = true
+		}
+	}
+	if !filled {
+		t.Errorf("Half health bar should be half filled, got %v", rec.ops)
+	}
+}
+
+func TestRender_StatsOverlayHidden(t *testing.T) {
+	g := NewHeadlessGame(1)
+	rec := &recorder{w: WIDTH, h: HEIGHT}
+
+	g.StatsOverlay.Render(rec, g)
+	if len(rec.ops) != 0 {
+		t.Errorf("Hidden stats overlay should not draw, got %v", rec.ops)
+	}
+
+	g.StatsOverlay.Toggle()
+	g.StatsOverlay.Render(rec, g)
+	if !rec.has("fillText GAME STATS [F10] " + strconv.Itoa(WIDTH-270) + " 36") {
+		t.Errorf("Visible stats overlay should draw its title, got %v", rec.ops)
+	}
+}
+
+// =============================================================================
+// Golden Image Tests
+// =============================================================================
+
+// checkGolden compares the image drawn on r with testdata/golden/name.png.
+// Run with -update after intentional theme or sprite changes and review
+// the new images before committing them.
+func checkGolden(t *testing.T, name string, r *render.Raster) {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if err := r.WritePNG(&buf); err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join("testdata", "golden", name+".png")
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(file, buf.Bytes(), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Compare decoded pixels, not bytes, so PNG encoder changes don't matter
+	gotImg, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantImg, err := png.Decode(bytes.NewReader(want))
+	if err != nil {
+		t.Fatalf("%s: %v", file, err)
+	}
+	if gotImg.Bounds() != wantImg.Bounds() {
+		t.Fatalf("%s: size %v, want %v", file, gotImg.Bounds(), wantImg.Bounds())
+	}
+	diff := 0
+	var first image.Point
+	b := gotImg.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if gotImg.At(x, y) != wantImg.At(x, y) {
+				if diff == 0 {
+					first = image.Pt(x, y)
+				}
+				diff++
+			}
+		}
+	}
+	if diff > 0 {
+		t.Errorf("%s: %d pixels differ, first at %v (run with -update to accept)", file, diff, first)
+	}
+}
+
+// newGoldenRaster returns a black raster of the given size whose origin is
+// moved so that screen point (x, y) is its top left corner.
+func newGoldenRaster(w, h int, x, y float64) *render.Raster {
+	r := render.NewRaster(w, h)
+	r.SetFillStyle("#000")
+	r.FillRect(0, 0, float64(w), float64(h))
+	r.Translate(-x, -y)
+	return r
+}
+
+func TestGolden_BaseRender(t *testing.T) {
+	g := NewHeadlessGame(1)
+	size := int(BaseShieldRadius)*2 + 16
+	r := newGoldenRaster(size, size, WIDTH/2-float64(size)/2, HEIGHT/2-float64(size)/2)
+	g.Ctx = r
+
+	g.Bases[0].Render(g)
+
+	checkGolden(t, "base", r)
+}
+
+func TestGolden_ShipHUD(t *testing.T) {
+	g := NewHeadlessGame(1)
+	s := g.Ship
+	s.X, s.Y = 1234.5, -678.9
+	s.VelX, s.VelY = 3.25, -1.5
+	s.Angle = math.Pi / 6
+	s.AddWeapon()
+	s.AddWeapon()
+	s.Target = &Enemy{X: s.X + 300, Y: s.Y - 400, Health: 1}
+
+	r := newGoldenRaster(240, HEIGHT, 0, 0)
+	g.ShipHUD.Render(r, s)
+
+	checkGolden(t, "ship-hud", r)
+}
+
+func TestGolden_StatsOverlay(t *testing.T) {
+	g := NewHeadlessGame(1234)
+	g.StatsOverlay.Toggle()
+	g.StatsOverlay.CurrentFPS = 60
+
+	r := newGoldenRaster(300, 380, WIDTH-300, 0)
+	g.StatsOverlay.Render(r, g)
+
+	checkGolden(t, "stats-overlay", r)
+}
+
+func TestGolden_EnemySprites(t *testing.T) {
+	g := NewHeadlessGame(1)
+	g.Ctx = render.NewRaster(1, 1)
+	g.InitializeEnemyGraphics()
+
+	for kind, name := range map[EnemyKind]string{
+		SmallFighter:  "enemy-small",
+		MediumFighter: "enemy-medium",
+		TurretFighter: "enemy-turret",
+		Boss:          "enemy-boss",
+		Kamikaze:      "enemy-kamikaze",
+		MineLayer:     "enemy-minelayer",
+		Carrier:       "enemy-carrier",
+	} {
+		img, ok := g.EnemyTypes[kind].Image.(*render.Raster)
+		if !ok {
+			t.Fatalf("%s: sprite was not drawn with the raster backend", name)
+		}
+		checkGolden(t, name, img)
+	}
+}
+
+// =============================================================================
+// Autopilot Tests
+// =============================================================================
+
+// flyAutopilot steps g for n ticks with the autopilot flying the local ship.
+func flyAutopilot(g *Game, p *Autopilot, n int) {
+	g.Ship.Input = p
+	for i := 0; i < n; i++ {
+		g.Step(g.PollInputs())
+	}
+}
+
+func TestAutopilot_DeterministicAndReplayable(t *testing.T) {
+	a := NewHeadlessGame(7)
+	b := NewHeadlessGame(7)
+	flyAutopilot(a, NewAutopilot(), 900)
+	flyAutopilot(b, NewAutopilot(), 900)
+
+	if a.Checksum() != b.Checksum() {
+		t.Fatalf("Autopilot sessions with the same seed diverged: %08x != %08x", a.Checksum(), b.Checksum())
+	}
+	if res := RunReplay(a.Recording); res.Hash != a.Checksum() {
+		t.Errorf("Replay of an autopilot session ended in %08x, want %08x", res.Hash, a.Checksum())
+	}
+}
+
+func TestAutopilot_LocksAndFires(t *testing.T) {
+	g := NewHeadlessGame(1)
+	g.Ship.X = BaseShieldRadius * 4
+	enemy := &Enemy{X: g.Ship.X + 250, Y: 0, Radius: ShipR, Health: 1000, MaxHealth: 1000, FireTimer: 1000}
+	g.Enemies = append(g.Enemies, enemy)
+
+	flyAutopilot(g, NewAutopilot(), 60)
+
+	if g.Ship.Target != enemy {
+		t.Error("Autopilot should lock onto the enemy in front of it")
+	}
+	if enemy.Health == 1000 {
+		t.Error("Autopilot should have hit the locked enemy")
+	}
+}
+
+func TestAutopilot_ReturnsToBaseWhenLow(t *testing.T) {
+	g := NewHeadlessGame(1)
+	g.Ship.X = BaseShieldRadius * 4
+	g.Ship.E = AutopilotLowHealth - 1
+	p := NewAutopilot()
+
+	for i := 0; i < 300 && !g.Ship.InBase; i++ {
+		g.Enemies = g.Enemies[:0]
+		flyAutopilot(g, p, 1)
+	}
+
+	if !g.Ship.InBase {
+		t.Errorf("Damaged ship should fly back into the base, stopped at (%.0f, %.0f)", g.Ship.X, g.Ship.Y)
+	}
+}
+
+func TestAutopilot_DodgesTorpedo(t *testing.T) {
+	for _, tt := range []struct {
+		name  string
+		pilot bool
+		hit   bool
+	}{
+		{"idle ship is hit", false, true},
+		{"autopilot dodges", true, false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewHeadlessGame(1)
+			g.Ship.X = BaseShieldRadius * 4
+			torpedo := g.Bullets.AcquireKind(TorpedoBullet)
+			torpedo.X, torpedo.Y = g.Ship.X-24*ShipR/2, 0
+			torpedo.XAcc = ShipR / 2
+
+			if tt.pilot {
+				g.Ship.Input = NewAutopilot()
+			}
+			for i := 0; i < 40; i++ {
+				g.Enemies = g.Enemies[:0]
+				g.Step(g.PollInputs())
+			}
+
+			if hit := g.Ship.E < 100; hit != tt.hit {
+				t.Errorf("Ship hit = %v, want %v", hit, tt.hit)
+			}
+		})
+	}
+}
+
+// =============================================================================
+// Input Source Tests
+// =============================================================================
+
+// fixedInput is an InputSource returning the same controls on every tick.
+type fixedInput ControlState
+
+func (f fixedInput) Poll(g *Game, s *Ship) ControlState { return ControlState(f) }
+
+func TestPollInputs_StopsAtLastControlledShip(t *testing.T) {
+	g := NewHeadlessGame(1)
+	remote := &Ship{}
+	g.Ships = append(g.Ships, remote)
+
+	if inputs := g.PollInputs(); len(inputs) != 0 {
+		t.Fatalf("PollInputs() = %v, want none without input sources", inputs)
+	}
+
+	g.Ship.Input = fixedInput{Keys: KeyUp}
+	inputs := g.PollInputs()
+	if len(inputs) != 1 || inputs[0].Keys != KeyUp {
+		t.Errorf("PollInputs() = %v, want only the local ship's input", inputs)
+	}
+
+	remote.Input = fixedInput{Keys: KeyLeft}
+	inputs = g.PollInputs()
+	if len(inputs) != 2 || inputs[1].Keys != KeyLeft {
+		t.Errorf("PollInputs() = %v, want the remote ship's input second", inputs)
+	}
+}
+
+func TestKeyboardInput_EncodesHeldKeys(t *testing.T) {
+	k := NewKeyboardInput()
+	k.KeyDown(38)
+	k.KeyDown(88)
+	k.KeyDown(37)
+	k.KeyUp(37)
+
+	if got := k.Poll(nil, nil).Keys; got != KeyUp|KeyFire {
+		t.Errorf("Keys = %#x, want %#x", got, KeyUp|KeyFire)
+	}
+}
+
+func TestNetworkInput_AppliedEveryTick(t *testing.T) {
+	g := NewHeadlessGame(1)
+	n := &NetworkInput{}
+	g.Ship.Input = n
+	n.Receive(&PlayerInputData{Keys: KeyUp})
+
+	for i := 0; i < 10; i++ {
+		g.Step(g.PollInputs())
+	}
+
+	if g.Ship.Y >= 0 {
+		t.Errorf("Ship.Y = %v, want the last received thrust applied on every tick", g.Ship.Y)
+	}
+}
+
+func TestReplayPlayer_AsInputSource(t *testing.T) {
+	rec := NewHeadlessGame(3)
+	for i := 0; i < 90; i++ {
+		rec.Step([]ControlState{{Keys: scriptedKeys(i)}})
+	}
+
+	g := NewHeadlessGame(3)
+	g.Ship.Input = NewReplayPlayer(rec.Recording)
+	for i := 0; i < 90; i++ {
+		g.Step(g.PollInputs())
+	}
+
+	if g.Checksum() != rec.Checksum() {
+		t.Errorf("Replayed state %08x, want %08x", g.Checksum(), rec.Checksum())
+	}
+}
+
+// =============================================================================
+// Gamepad Tests
+// =============================================================================
+
+func TestGamepad_StickAnalogControls(t *testing.T) {
+	p := NewGamepadInput()
+	p.Connect(0, "pad")
+	p.Update(GamepadState{Axes: []float64{1, -0.1}})
+
+	c := p.Poll(nil, nil)
+	if c.Turn != AnalogMax {
+		t.Errorf("Turn = %d, want %d for a full right stick", c.Turn, AnalogMax)
+	}
+	if c.Thrust != 0 {
+		t.Errorf("Thrust = %d, want 0 inside the dead zone", c.Thrust)
+	}
+
+	p.Update(GamepadState{Axes: []float64{0, -1}})
+	if c := p.Poll(nil, nil); c.Thrust != AnalogMax || c.Turn != 0 {
+		t.Errorf("Poll() = %+v, want full forward thrust for stick up", c)
+	}
+}
+
+func TestGamepad_ButtonMapping(t *testing.T) {
+	p := NewGamepadInput()
+	p.Connect(0, "pad")
+	buttons := make([]float64, 16)
+	buttons[GamepadRightTrigger] = 0.8
+	buttons[GamepadRightShoulder] = 1
+	p.Update(GamepadState{Buttons: buttons})
+
+	if got := p.Poll(nil, nil).Keys; got != KeyFire|KeyNextTarget {
+		t.Errorf("Keys = %#x, want %#x", got, KeyFire|KeyNextTarget)
+	}
+}
+
+func TestGamepad_HotPlug(t *testing.T) {
+	g := NewHeadlessGame(1)
+	g.Ship.Input = g.LocalInput
+	g.Gamepad.Connect(2, "pad")
+	g.UpdateGamepad(GamepadState{Axes: []float64{0, -1}}, true)
+
+	if c := g.PollInputs()[0]; c.Thrust != AnalogMax {
+		t.Fatalf("Thrust = %d, want the connected gamepad's throttle", c.Thrust)
+	}
+
+	g.UpdateGamepad(GamepadState{}, false)
+	if g.Gamepad.Connected() {
+		t.Error("Gamepad should be released after it disconnects")
+	}
+	if c := g.PollInputs()[0]; c != (ControlState{}) {
+		t.Errorf("PollInputs() = %+v, want neutral after unplugging", c)
+	}
+
+	g.Gamepad.Connect(1, "other pad")
+	if g.Gamepad.Index != 1 {
+		t.Errorf("Index = %d, want a newly plugged controller to take over", g.Gamepad.Index)
+	}
+}
+
+func TestGamepad_StartPauses(t *testing.T) {
+	g := NewHeadlessGame(1)
+	g.Gamepad.Connect(0, "pad")
+	start := make([]float64, 16)
+	start[GamepadStart] = 1
+
+	g.UpdateGamepad(GamepadState{Buttons: start}, true)
+	g.UpdateGamepad(GamepadState{Buttons: start}, true) // Held, not pressed again
+	if g.State != StatePaused {
+		t.Errorf("State = %v, want Start to pause the game once", g.State)
+	}
+}
+
+func TestGamepad_DpadPicksDifficulty(t *testing.T) {
+	g := newTitleGame()
+	g.Gamepad.Connect(0, "pad")
+	right := make([]float64, 16)
+	right[GamepadDpadRight] = 1
+
+	g.UpdateGamepad(GamepadState{Buttons: right}, true)
+	if g.State != StateTitle || g.Title.Difficulty != DifficultyHard {
+		t.Errorf("State = %v at %v, want the D-pad to pick hard on the title screen", g.State, g.Title.Difficulty)
+	}
+}
+
+func shipSpeed(s *Ship) float64 {
+	return math.Hypot(s.VelX, s.VelY)
+}
+
+func TestShip_AnalogMovement(t *testing.T) {
+	half := NewHeadlessGame(1)
+	full := NewHeadlessGame(1)
+	for i := 0; i < 3; i++ {
+		half.Step([]ControlState{{Turn: Analog(0.5), Thrust: Analog(0.5)}})
+		full.Step([]ControlState{{Turn: AnalogMax, Thrust: AnalogMax}})
+	}
+
+	if half.Ship.Angle <= 0 || half.Ship.Angle >= full.Ship.Angle {
+		t.Errorf("Angle = %v at half deflection, want between 0 and %v", half.Ship.Angle, full.Ship.Angle)
+	}
+	if shipSpeed(half.Ship) >= shipSpeed(full.Ship) {
+		t.Errorf("Half throttle speed %v should be below full throttle %v", shipSpeed(half.Ship), shipSpeed(full.Ship))
+	}
+}
+
+func TestShip_CycleTargetLock(t *testing.T) {
+	g := NewHeadlessGame(1)
+	near := &Enemy{X: 200, Radius: ShipR, Health: 10}
+	far := &Enemy{X: 400, Radius: ShipR, Health: 10}
+	g.Enemies = append(g.Enemies, far, near)
+
+	for _, want := range []*Enemy{near, far, near} {
+		g.Ship.CycleTargetLock(g)
+		if g.Ship.LockingOn != want {
+			t.Fatalf("LockingOn = %v, want enemy at x=%v", g.Ship.LockingOn, want.X)
+		}
+	}
+}
+
+func TestReplay_AnalogRoundTrip(t *testing.T) {
+	r := NewReplay(5)
+	r.Record(ControlState{Keys: KeyFire, Turn: -AnalogMax, Thrust: 64})
+	r.Record(ControlState{Turn: 3})
+
+	data, _ := r.MarshalBinary()
+	got, err := DecodeReplay(data)
+	if err != nil {
+		t.Fatalf("DecodeReplay() error = %v", err)
+	}
+	for i := range r.Inputs {
+		if got.Inputs[i] != r.Inputs[i] {
+			t.Errorf("Inputs[%d] = %+v, want %+v", i, got.Inputs[i], r.Inputs[i])
+		}
+	}
+}
+
+// =============================================================================
+// Touch Control Tests
+// =============================================================================
+
+func TestTouch_MultiTouch(t *testing.T) {
+	ti := NewTouchInput()
+	ti.TouchStart(1, TouchStickX, TouchStickY)
+	ti.TouchMove(1, TouchStickX+TouchStickRadius, TouchStickY-2*TouchStickRadius)
+	ti.TouchStart(2, TouchFireX, TouchFireY)
+
+	c := ti.Poll(nil, nil)
+	if c.Keys != KeyFire {
+		t.Errorf("Keys = %#x, want fire held by the second finger", c.Keys)
+	}
+	if c.Turn <= 0 || c.Thrust <= c.Turn {
+		t.Errorf("Turn, Thrust = %d, %d, want right turn and stronger forward thrust", c.Turn, c.Thrust)
+	}
+
+	// Lifting the stick finger keeps fire pressed
+	ti.TouchEnd(1)
+	if c := ti.Poll(nil, nil); c != (ControlState{Keys: KeyFire}) {
+		t.Errorf("Poll() = %+v, want only fire after releasing the stick", c)
+	}
+}
+
+func TestTouch_FingerStaysOnControl(t *testing.T) {
+	ti := NewTouchInput()
+	ti.TouchStart(7, TouchLockX, TouchLockY)
+	ti.TouchMove(7, WIDTH/4, HEIGHT/2) // Slides onto the joystick half
+	ti.TouchStart(8, WIDTH/2+10, 10)   // Outside every control
+
+	if c := ti.Poll(nil, nil); c != (ControlState{Keys: KeyLock}) {
+		t.Errorf("Poll() = %+v, want lock held and no stick input", c)
+	}
+
+	ti.TouchEnd(7)
+	ti.TouchEnd(8)
+	if c := ti.Poll(nil, nil); c != (ControlState{}) {
+		t.Errorf("Poll() = %+v, want neutral with no fingers down", c)
+	}
+}
+
+func TestTouch_HiddenWithoutTouchScreen(t *testing.T) {
+	g := NewHeadlessGame(1)
+	r := &recorder{}
+	g.Touch.Render(r)
+
+	if len(r.ops) != 0 {
+		t.Errorf("Touch controls drew %d ops without a touch screen", len(r.ops))
+	}
+}
+
+func TestGolden_TouchControls(t *testing.T) {
+	ti := NewTouchInput()
+	ti.TouchStart(1, TouchStickX, TouchStickY)
+	ti.TouchMove(1, TouchStickX+40, TouchStickY-200)
+	ti.TouchStart(2, TouchFireX, TouchFireY)
+
+	r := newGoldenRaster(WIDTH, 600, 0, HEIGHT-600)
+	ti.Render(r)
+
+	checkGolden(t, "touch-controls", r)
+}
+
+// =============================================================================
+// Key Binding Tests
+// =============================================================================
+
+func TestBindings_Defaults(t *testing.T) {
+	b := DefaultBindings()
+	tests := []struct {
+		keyCode int
+		want    Action
+	}{
+		{37, ActionRotateLeft},
+		{68, ActionRotateRight}, // D
+		{87, ActionThrust},      // W
+		{32, ActionFire},        // Space
+		{84, ActionLock},        // T
+		{27, ActionPause},       // Esc
+		{121, ActionStats},      // F10
+	}
+	for _, tt := range tests {
+		if got, ok := b.Lookup(tt.keyCode); !ok || got != tt.want {
+			t.Errorf("Lookup(%d) = %v, %v, want %v", tt.keyCode, got, ok, tt.want)
+		}
+	}
+}
+
+func TestBindings_RebindKeyboard(t *testing.T) {
+	k := NewKeyboardInput()
+	k.Bindings.Clear(ActionFire)
+	k.Bindings.Bind(ActionFire, 16) // Shift
+	k.Bindings.Bind(ActionThrust, 88)
+
+	k.KeyDown(16)
+	k.KeyDown(88)
+	if got := k.Poll(nil, nil).Keys; got != KeyFire|KeyUp {
+		t.Errorf("Keys = %#x, want %#x after rebinding", got, KeyFire|KeyUp)
+	}
+
+	k.ReleaseAll()
+	if got := k.Poll(nil, nil).Keys; got != 0 {
+		t.Errorf("Keys = %#x, want none after ReleaseAll", got)
+	}
+}
+
+func TestBindings_JSONRoundTrip(t *testing.T) {
+	b := Bindings{16: ActionFire, 90: ActionLock}
+	data, err := json.Marshal(b)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+
+	var got Bindings
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if len(got) != 2 || got[16] != ActionFire || got[90] != ActionLock {
+		t.Errorf("Unmarshal(%s) = %v, want %v", data, got, b)
+	}
+
+	// Actions of newer versions are skipped
+	if err := json.Unmarshal([]byte(`{"16":"fire","17":"warp"}`), &got); err != nil || len(got) != 1 {
+		t.Errorf("Unmarshal() = %v, %v, want only the known action", got, err)
+	}
+}
+
+func TestBindingsScreen_Rebind(t *testing.T) {
+	b := DefaultBindings()
+	s := NewBindingsScreen()
+	s.Open()
+
+	// Select Fire, rebind it to Shift
+	for i := 0; i < int(ActionFire); i++ {
+		s.HandleKey(b, bindingsKeyDown)
+	}
+	s.HandleKey(b, bindingsKeyRebind)
+	if !s.HandleKey(b, 16) {
+		t.Fatal("Binding a key should report a change")
+	}
+	if a, _ := b.Lookup(16); a != ActionFire {
+		t.Errorf("Shift bound to %v, want %v", a, ActionFire)
+	}
+
+	// Clear and restore the defaults
+	s.HandleKey(b, bindingsKeyClear)
+	if keys := b.KeysFor(ActionFire); len(keys) != 0 {
+		t.Errorf("KeysFor(Fire) = %v after clearing, want none", keys)
+	}
+	s.HandleKey(b, bindingsKeyReset)
+	if len(b) != len(DefaultBindings()) {
+		t.Errorf("len(bindings) = %d after reset, want %d", len(b), len(DefaultBindings()))
+	}
+
+	s.HandleKey(b, bindingsKeyClose)
+	if s.Visible {
+		t.Error("Esc should close the screen")
+	}
+}
+
+func TestBindingsScreen_KeepsOwnKey(t *testing.T) {
+	b := DefaultBindings()
+	s := NewBindingsScreen()
+	s.Open()
+
+	// Taking F2 for Fire would leave the screen unreachable
+	s.Selected = ActionFire
+	s.HandleKey(b, bindingsKeyRebind)
+	s.HandleKey(b, 113)
+	if a, _ := b.Lookup(113); a != ActionBindings {
+		t.Errorf("F2 bound to %v, want it kept for %v", a, ActionBindings)
+	}
+
+	s.Selected = ActionBindings
+	if s.HandleKey(b, bindingsKeyClear) {
+		t.Error("The screen's own action should not be cleared")
+	}
+}
+
+func TestGolden_BindingsScreen(t *testing.T) {
+	b := DefaultBindings()
+	s := NewBindingsScreen()
+	s.Open()
+	s.HandleKey(b, bindingsKeyDown)
+	s.HandleKey(b, bindingsKeyRebind)
+
+	r := newGoldenRaster(680, 640, (WIDTH-680)/2, (HEIGHT-640)/2)
+	s.Render(r, b)
+
+	checkGolden(t, "bindings-screen", r)
+}
+
+// =============================================================================
+// Mouse Aiming Tests
+// =============================================================================
+
+func TestEnemyAt_HitTest(t *testing.T) {
+	g := NewHeadlessGame(1)
+	small := &Enemy{X: 300, Y: 0, Radius: 20, Health: 10}
+	big := &Enemy{X: 380, Y: 0, Radius: 100, Health: 10}
+	offscreen := &Enemy{X: WIDTH * 2, Y: 0, Radius: 100, Health: 10}
+	g.Enemies = append(g.Enemies, big, small, offscreen)
+
+	tests := []struct {
+		name string
+		x, y float64
+		want *Enemy
+	}{
+		{"small enemy wins where it overlaps", 300, 10, small},
+		{"slack around the edge", 380, 100 + EnemyPickSlack - 1, big},
+		{"empty space", -500, 300, nil},
+		{"off screen", WIDTH * 2, 0, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := g.EnemyAt(tt.x, tt.y); got != tt.want {
+				t.Errorf("EnemyAt(%v, %v) = %v, want %v", tt.x, tt.y, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMouse_ClickLocksEnemyUnderCursor(t *testing.T) {
+	g := NewHeadlessGame(1)
+	g.Ship.Input = g.LocalInput
+	near := &Enemy{X: 150, Y: 0, Radius: ShipR, Health: 10, FireTimer: 1000}
+	far := &Enemy{X: -600, Y: 200, Radius: ShipR, Health: 10, FireTimer: 1000}
+	g.Enemies = append(g.Enemies, near, far)
+
+	// Click on the farther enemy; T would pick the nearest one
+	sx, sy := g.Camera.WorldToScreen(far.X, far.Y)
+	g.Mouse.Click(sx+5, sy-5)
+	g.Step(g.PollInputs())
+
+	if g.Ship.LockingOn != far {
+		t.Fatalf("LockingOn = %v, want the clicked enemy", g.Ship.LockingOn)
+	}
+	if c := g.Mouse.Poll(g, g.Ship); c.Keys&KeyLockAt != 0 {
+		t.Error("A click should be reported for one tick only")
+	}
+}
+
+func TestMouse_SteeringTurnsTowardCursor(t *testing.T) {
+	g := NewHeadlessGame(1)
+	g.Ship.Input = g.LocalInput
+	g.Mouse.ToggleSteering()
+	g.Mouse.Move(WIDTH/2+300, HEIGHT/2) // Right of the ship
+
+	for i := 0; i < 30; i++ {
+		g.Step(g.PollInputs())
+	}
+
+	if math.Abs(g.Ship.Angle-math.Pi/2) > 0.05 {
+		t.Errorf("Angle = %v, want the ship facing the cursor at %v", g.Ship.Angle, math.Pi/2)
+	}
+}
+
+func TestReplay_MouseLockReplays(t *testing.T) {
+	rec := NewHeadlessGame(9)
+	rec.Ship.Input = rec.LocalInput
+	for i := 0; i < 300; i++ {
+		if i%50 == 25 {
+			rec.Mouse.Click(WIDTH/2+float64(i), HEIGHT/2-200)
+		}
+		rec.Step(rec.PollInputs())
+	}
+
+	data, _ := rec.Recording.MarshalBinary()
+	r, err := DecodeReplay(data)
+	if err != nil {
+		t.Fatalf("DecodeReplay() error = %v", err)
+	}
+	if res := RunReplay(r); res.Hash != rec.Checksum() {
+		t.Errorf("Replay ended in %08x, want %08x", res.Hash, rec.Checksum())
+	}
+}
+
+// =============================================================================
+// Campaign Tests
+// =============================================================================
+
+func newCampaignGame(seed uint32) *Game {
+	g := NewHeadlessGame(seed)
+	g.Mode = ModeCampaign
+	g.Reset(seed)
+	return g
+}
+
+func TestCampaign_Waves(t *testing.T) {
+	if len(CampaignWaves) != 13 {
+		t.Fatalf("len(CampaignWaves) = %d, want 13", len(CampaignWaves))
+	}
+
+	final := CampaignWaves[len(CampaignWaves)-1]
+	hasBoss := false
+	for _, s := range final.Spawns {
+		hasBoss = hasBoss || s.Kind == Boss
+	}
+	if !hasBoss {
+		t.Error("The final wave should be a boss wave")
+	}
+}
+
+func TestCampaign_WaveProgression(t *testing.T) {
+	g := newCampaignGame(42)
+
+	if g.Campaign == nil || g.Campaign.Wave != 0 || g.Campaign.Phase != CampaignIntro {
+		t.Fatalf("Campaign = %+v, want wave 0 intro", g.Campaign)
+	}
+	if g.Level.LevelNum != 1 || g.Level.LevelSeed != common.LevelSeed(42, 1) {
+		t.Errorf("Level = %d seed %d, want 1 seed %d", g.Level.LevelNum, g.Level.LevelSeed, common.LevelSeed(42, 1))
+	}
+	if g.Level.Text.Message != "WAVE 1" || g.Level.Text.T == 0 {
+		t.Errorf("Text = %q for %d ticks, want the wave title shown", g.Level.Text.Message, g.Level.Text.T)
+	}
+
+	for i := 0; i < CampaignIntroTicks; i++ {
+		g.Step(nil)
+	}
+	if g.Campaign.Phase != CampaignFighting || len(g.Enemies) != 4 {
+		t.Fatalf("Phase = %d with %d enemies, want fighting 4", g.Campaign.Phase, len(g.Enemies))
+	}
+
+	for _, e := range g.Enemies {
+		e.Health = 0
+	}
+	g.Step(nil)
+	g.Step(nil)
+	if g.Campaign.Phase != CampaignCleared || g.Level.Text.Message != "WAVE 1 CLEARED" {
+		t.Fatalf("Phase = %d, text %q, want wave 1 cleared", g.Campaign.Phase, g.Level.Text.Message)
+	}
+
+	for i := 0; i < CampaignClearedTicks; i++ {
+		g.Step(nil)
+	}
+	if g.Campaign.Wave != 1 || g.Level.LevelNum != 2 || g.Level.LevelSeed != common.LevelSeed(42, 2) {
+		t.Errorf("Wave %d level %d, want wave 1 in level 2 with its own seed", g.Campaign.Wave, g.Level.LevelNum)
+	}
+}
+
+func TestCampaign_FinalWaveEndsCampaign(t *testing.T) {
+	g := newCampaignGame(42)
+	g.Campaign.Wave = len(CampaignWaves) - 1
+	g.Campaign.Phase = CampaignFighting
+
+	g.Step(nil)
+
+	if g.Campaign.Phase != CampaignComplete {
+		t.Fatalf("Phase = %d, want complete", g.Campaign.Phase)
+	}
+	if g.Level.Text.Message != "CAMPAIGN COMPLETE" || g.Level.Text.T >= 0 {
+		t.Errorf("Text = %q for %d ticks, want it kept on screen", g.Level.Text.Message, g.Level.Text.T)
+	}
+
+	g.Step(nil)
+	if g.Campaign.Phase != CampaignComplete || len(g.Enemies) != 0 {
+		t.Error("No waves should follow the final one")
+	}
+}
+
+func TestCampaign_FlyingAwayDoesNotClearWave(t *testing.T) {
+	g := newCampaignGame(42)
+	g.startWave(len(CampaignWaves) - 1)
+	for i := 0; i <= CampaignIntroTicks; i++ {
+		g.Step(nil)
+	}
+	if g.Campaign.Phase != CampaignFighting || len(g.Enemies) == 0 {
+		t.Fatalf("Phase = %d with %d enemies, want the final wave fighting", g.Campaign.Phase, len(g.Enemies))
+	}
+	n := len(g.Enemies)
+
+	g.Ship.X += 2 * EnemyDespawnDistance
+	g.Step(nil)
+	g.Step(nil)
+
+	if g.Campaign.Phase != CampaignFighting || len(g.Enemies) != n {
+		t.Fatalf("Phase = %d with %d of %d enemies after flying away, want the wave still fighting",
+			g.Campaign.Phase, len(g.Enemies), n)
+	}
+	for _, e := range g.Enemies {
+		if d := math.Hypot(e.X-g.Ship.X, e.Y+e.YOffset-g.Ship.Y); d > EnemyDespawnDistance {
+			t.Errorf("%s is %.0f away, want it brought back", EnemyKindNames[e.Kind], d)
+		}
+	}
+}
+
+func TestCampaign_VictoryScreenAndRestart(t *testing.T) {
+	g := newCampaignGame(42)
+	g.State = StatePlaying
+	g.Campaign.Wave = len(CampaignWaves) - 1
+	g.Campaign.Phase = CampaignFighting
+	g.Ship.Points = 900
+
+	g.AdvanceFrame()
+	if g.State != StateVictory || g.GameOverTick != g.Tick {
+		t.Fatalf("State = %v, want the victory screen at tick %d", g.State, g.Tick)
+	}
+
+	g.HandleStateAction(ActionFire, true)
+	if g.State != StateVictory {
+		t.Fatal("Fire should not restart before GameOverDelay")
+	}
+	for i := 0; i < GameOverDelay; i++ {
+		g.AdvanceFrame()
+	}
+	g.HandleStateAction(ActionFire, true)
+	if g.State != StatePlaying || g.Campaign == nil || g.Campaign.Wave != 0 || g.Ship.Points != 0 {
+		t.Errorf("State = %v, want the campaign restarted from the first wave", g.State)
+	}
+}
+
+func TestCampaign_InfiniteModeUnchanged(t *testing.T) {
+	g := NewHeadlessGame(42)
+	g.Step(nil)
+	if g.Campaign != nil || g.Level.LevelNum != 0 {
+		t.Error("The infinite world should not run the campaign")
+	}
+}
+
+func TestReplay_CampaignRoundTrip(t *testing.T) {
+	rec := newCampaignGame(5)
+	rec.Ship.Input = NewAutopilot()
+	for i := 0; i < 600; i++ {
+		rec.Step(rec.PollInputs())
+	}
+
+	data, _ := rec.Recording.MarshalBinary()
+	r, err := DecodeReplay(data)
+	if err != nil {
+		t.Fatalf("DecodeReplay() error = %v", err)
+	}
+	if r.Mode != ModeCampaign {
+		t.Fatalf("Mode = %v, want campaign", r.Mode)
+	}
+	if res := RunReplay(r); res.Hash != rec.Checksum() {
+		t.Errorf("Replay ended in %08x, want %08x", res.Hash, rec.Checksum())
+	}
+
+	bad := append(data[:len(data)-1:len(data)-1], 7)
+	if _, err := DecodeReplay(bad); err != ErrReplayCorrupt {
+		t.Errorf("Unknown mode: error = %v, want %v", err, ErrReplayCorrupt)
+	}
+}
+
+// =============================================================================
+// Spawn Table Tests
+// =============================================================================
+
+func TestSpawnTable_ShippedFileMatchesDefaults(t *testing.T) {
+	data, err := os.ReadFile("../" + SpawnTableURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	table, err := ParseSpawnTable(data)
+	if err != nil {
+		t.Fatalf("ParseSpawnTable() error = %v", err)
+	}
+	if !reflect.DeepEqual(table, DefaultSpawnTable()) {
+		t.Errorf("%s differs from DefaultSpawnTable()", SpawnTableURL)
+	}
+}
+
+func TestSpawnTable_PartialDocumentKeepsDefaults(t *testing.T) {
+	table, err := ParseSpawnTable([]byte(`{"waves": {"targetBase": 2, "rules": [{"kind": "boss"}]}}`))
+	if err != nil {
+		t.Fatalf("ParseSpawnTable() error = %v", err)
+	}
+
+	if table.Waves.TargetBase != 2 || table.Waves.TargetPerPoints != 500 {
+		t.Errorf("Waves = %+v, want targetBase 2 and the default scaling", table.Waves)
+	}
+	want := []SpawnRule{{Kind: Boss, Chance: 1}}
+	if !reflect.DeepEqual(table.Waves.Rules, want) {
+		t.Errorf("Rules = %+v, want %+v", table.Waves.Rules, want)
+	}
+	if table.Enemies[SmallFighter] != enemyConfigs[SmallFighter] {
+		t.Error("Enemy kinds left out should keep their defaults")
+	}
+
+	table, err = ParseSpawnTable([]byte(`{"enemies": {"medium": {"healthBase": 20}}}`))
+	if err != nil {
+		t.Fatalf("ParseSpawnTable() error = %v", err)
+	}
+	medium := enemyConfigs[MediumFighter]
+	medium.HealthBase = 20
+	if table.Enemies[MediumFighter] != medium {
+		t.Errorf("Medium fighter = %+v, want the defaults with healthBase 20", table.Enemies[MediumFighter])
+	}
+}
+
+func TestSpawnTable_AcceptsHasFireDir(t *testing.T) {
+	table, err := ParseSpawnTable([]byte(`{"enemies": {"turret": {"healthBase": 25, "hasFireDir": true}}}`))
+	if err != nil {
+		t.Fatalf("ParseSpawnTable() error = %v, want older tables to load", err)
+	}
+	if table.Enemies[TurretFighter].HealthBase != 25 {
+		t.Errorf("Turret healthBase = %d, want 25", table.Enemies[TurretFighter].HealthBase)
+	}
+}
+
+func TestSpawnTable_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+		want []string
+	}{
+		{"syntax", "{\n  \"waves\": {,}\n}", []string{"line 2, column 13"}},
+		{"unknown field", `{"enemies": {"small": {"helth": 3}}}`, []string{`unknown field "helth"`}},
+		{"unknown kind", `{"waves": {"rules": [{"kind": "dragon"}]}}`, []string{`unknown enemy kind "dragon"`}},
+		{"invalid values", `{"enemies": {"medium": {"healthBase": 0, "tRange": -1}}, "waves": {"rules": [{"kind": "small", "chance": 2}]}}`,
+			[]string{"enemies.medium.healthBase", "enemies.medium.tRange", "waves.rules[0].chance"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseSpawnTable([]byte(tt.doc))
+			if err == nil {
+				t.Fatal("ParseSpawnTable() error = nil")
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("error %q does not mention %q", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestCheckWaveSpawn_FollowsSpawnTable(t *testing.T) {
+	g := NewHeadlessGame(1)
+	g.Spawns.Waves = WaveRules{TargetBase: 1, Rules: []SpawnRule{{Kind: MediumFighter, Chance: 1}}}
+
+	g.CheckWaveSpawn()
+
+	if len(g.Enemies) != enemyConfigs[MediumFighter].CountBase {
+		t.Fatalf("Spawned %d enemies, want %d", len(g.Enemies), enemyConfigs[MediumFighter].CountBase)
+	}
+	for _, e := range g.Enemies {
+		if e.Kind != MediumFighter {
+			t.Errorf("Spawned %v, want only medium fighters", EnemyKindNames[e.Kind])
+		}
+	}
+}
+
+// =============================================================================
+// Game State Tests
+// =============================================================================
+
+// newTitleGame returns a headless game on the title screen with a demo
+// flying behind it.
+func newTitleGame() *Game {
+	g := NewHeadlessGame(1)
+	g.StartDemo()
+	return g
+}
+
+func TestState_TitleStartsSelectedMode(t *testing.T) {
+	g := newTitleGame()
+	if g.State != StateTitle || g.Demo == nil {
+		t.Fatalf("State = %v, want the title screen over a demo", g.State)
+	}
+
+	if !g.HandleStateAction(ActionReverse, true) || g.Title.Selected != ModeCampaign {
+		t.Fatalf("Selected = %v, want down to pick the campaign", g.Title.Selected)
+	}
+	if g.HandleStateAction(ActionFullscreen, true) {
+		t.Error("Fullscreen should still work on the title screen")
+	}
+
+	g.HandleStateAction(0, false) // Any key
+	if g.State != StatePlaying || g.Demo != nil || g.Mode != ModeCampaign {
+		t.Errorf("State = %v in %v, want the campaign started", g.State, g.Mode)
+	}
+	if _, ok := g.Ship.Input.(CombinedInput); !ok {
+		t.Error("The player should control the ship")
+	}
+}
+
+func TestState_PauseFreezesGame(t *testing.T) {
+	g := NewHeadlessGame(1)
+	g.Ship.Input = g.LocalInput
+
+	g.HandleStateAction(ActionPause, true)
+	if g.State != StatePaused {
+		t.Fatalf("State = %v, want paused", g.State)
+	}
+	if !g.HandleStateAction(ActionFire, true) {
+		t.Error("Ship controls should be swallowed while paused")
+	}
+
+	g.AdvanceFrame()
+	if g.Tick != 0 {
+		t.Errorf("Tick = %d, want the simulation frozen", g.Tick)
+	}
+
+	g.HandleStateAction(ActionPause, true)
+	g.AdvanceFrame()
+	if g.State != StatePlaying || g.Tick != 1 {
+		t.Errorf("State = %v at tick %d, want resumed", g.State, g.Tick)
+	}
+}
+
+func TestState_PauseInMultiplayerKeepsRunning(t *testing.T) {
+	g := NewHeadlessGame(1)
+	g.Network = &NetworkManager{isHost: true, connected: true}
+	g.Ship.Input = fixedInput{Keys: KeyUp}
+	remote := &Ship{X: 100, Y: 100, E: 100, Input: fixedInput{Keys: KeyUp}}
+	g.Ships = append(g.Ships, remote)
+
+	g.HandleStateAction(ActionPause, true)
+	g.AdvanceFrame()
+	if g.State != StatePaused || g.Tick != 1 {
+		t.Fatalf("State = %v at tick %d, want paused with the world running", g.State, g.Tick)
+	}
+	if g.Ship.VelY != 0 {
+		t.Errorf("Local ship VelY = %v, want no input while paused", g.Ship.VelY)
+	}
+	if remote.VelY == 0 {
+		t.Error("The other players should keep playing")
+	}
+}
+
+func TestState_GameOverAndRestart(t *testing.T) {
+	g := NewHeadlessGame(1)
+	g.Ship.Input = g.LocalInput
+	for i := 0; i < 10; i++ {
+		g.Step(g.PollInputs())
+	}
+	g.Ship.Points = 1200
+	g.Ship.E = 0
+
+	g.UpdateState()
+	if g.State != StateGameOver || g.GameOverTick != g.Tick {
+		t.Fatalf("State = %v, want game over at tick %d", g.State, g.Tick)
+	}
+
+	g.HandleStateAction(ActionFire, true)
+	if g.State != StateGameOver {
+		t.Fatal("Fire should not restart before GameOverDelay")
+	}
+
+	for i := 0; i < GameOverDelay; i++ {
+		g.Step(g.PollInputs())
+	}
+	g.HandleStateAction(ActionFire, true)
+	if g.State != StatePlaying || g.Tick != 0 {
+		t.Fatalf("State = %v at tick %d, want a fresh session", g.State, g.Tick)
+	}
+	if !g.Ship.IsAlive() || g.Ship.Points != 0 || g.Level.P != 0 {
+		t.Error("Restart should reset the ship and the level")
+	}
+	if _, ok := g.Ship.Input.(CombinedInput); !ok {
+		t.Error("The player should control the restarted ship")
+	}
+}
+
+func TestState_GameOverReturnsToTitle(t *testing.T) {
+	g := NewHeadlessGame(1)
+	g.Ship.E = 0
+	g.UpdateState()
+
+	g.HandleStateAction(ActionPause, true)
+	if g.State != StateTitle || g.Demo == nil {
+		t.Errorf("State = %v, want the title screen", g.State)
+	}
+}
+
+func TestState_ReplayDoesNotEndInGameOver(t *testing.T) {
+	g := NewHeadlessGame(1)
+	g.StartReplay(NewReplay(1))
+	g.Ship.E = 0
+
+	g.UpdateState()
+	if g.State != StatePlaying {
+		t.Errorf("State = %v, want replays to play to the end", g.State)
+	}
+}
+
+func TestGolden_TitleScreen(t *testing.T) {
+	g := newTitleGame()
+	r := newGoldenRaster(WIDTH/2, HEIGHT, WIDTH/4, 0)
+
+	g.RenderStateScreen(r)
+
+	checkGolden(t, "title-screen", r)
+}
+
+func TestGolden_GameOverScreen(t *testing.T) {
+	g := NewHeadlessGame(1)
+	g.Ship.Points = 4200
+	g.Ship.E = 0
+	g.UpdateState()
+	g.Tick += GameOverDelay
+	r := newGoldenRaster(WIDTH/2, HEIGHT, WIDTH/4, 0)
+
+	g.RenderStateScreen(r)
+
+	checkGolden(t, "game-over-screen", r)
+}
+
+// =============================================================================
+// Bomb Tests
+// =============================================================================
+
+// spawnTorpedo adds a torpedo at (x, y) and returns it.
+func spawnTorpedo(g *Game, x, y float64) *Bullet {
+	b := g.Bullets.AcquireKind(TorpedoBullet)
+	b.X, b.Y = x, y
+	b.E = 1
+	return b
+}
+
+func TestBomb_ClearsNearbyTorpedoes(t *testing.T) {
+	g := NewHeadlessGame(1)
+	spawnTorpedo(g, 100, 100)
+	far := spawnTorpedo(g, BombBaseRadius*2, 0)
+
+	g.Ship.DetonateBomb(g)
+
+	if g.Bullets.ActiveCount != 1 || g.Bullets.Pool[0] != far {
+		t.Errorf("%d torpedoes left, want only the distant one", g.Bullets.ActiveCount)
+	}
+	if g.Level.Bomb != MaxBomb {
+		t.Errorf("Level.Bomb = %d, want the screen flashing", g.Level.Bomb)
+	}
+}
+
+func TestBomb_DamagesEnemiesInRange(t *testing.T) {
+	g := NewHeadlessGame(1)
+	near := &Enemy{X: 200, Y: 0, Radius: ShipR, Health: 20}
+	far := &Enemy{X: BombBaseRadius * 3, Y: 0, Radius: ShipR, Health: 20}
+	g.Enemies = append(g.Enemies, near, far)
+
+	g.Ship.DetonateBomb(g)
+
+	if near.Health != 20-BombDamage {
+		t.Errorf("Near enemy health = %d, want %d", near.Health, 20-BombDamage)
+	}
+	if far.Health != 20 {
+		t.Errorf("Far enemy health = %d, want it out of reach", far.Health)
+	}
+}
+
+func TestBomb_ScalesWithWeapons(t *testing.T) {
+	g := NewHeadlessGame(1)
+	single := g.Ship.BombRadius()
+	g.Ship.AddWeapon()
+	g.Ship.AddWeapon()
+
+	if got := g.Ship.BombRadius(); got != single+2*BombRadiusPerWeapon {
+		t.Errorf("BombRadius() = %v, want %v", got, single+2*BombRadiusPerWeapon)
+	}
+
+	e := &Enemy{X: 0, Y: -300, Radius: ShipR, Health: 50}
+	g.Enemies = append(g.Enemies, e)
+	g.Ship.DetonateBomb(g)
+	if e.Health != 50-3*BombDamage {
+		t.Errorf("Enemy health = %d, want %d damage from 3 weapons", e.Health, 3*BombDamage)
+	}
+}
+
+func TestBomb_PickupDetonates(t *testing.T) {
+	g := NewHeadlessGame(1)
+	g.Ship.X, g.Ship.Y = 500, 500 // SpawnBonus treats 0 as "unset"
+	spawnTorpedo(g, 800, 500)
+	g.SpawnBonus(g.Ship.X, g.Ship.Y, 0, 0, "B")
+
+	g.UpdateBonuses()
+
+	if g.Bonuses.ActiveCount != 0 || g.Bullets.ActiveCount != 0 {
+		t.Errorf("%d bonuses and %d torpedoes left, want the bomb picked up and set off",
+			g.Bonuses.ActiveCount, g.Bullets.ActiveCount)
+	}
+}
+
+func TestBomb_ClientLeavesEffectToHost(t *testing.T) {
+	g := NewHeadlessGame(1)
+	g.Network = &NetworkManager{connected: true}
+	g.Ship.X, g.Ship.Y = 500, 500 // SpawnBonus treats 0 as "unset"
+	spawnTorpedo(g, 800, 500)
+	g.SpawnBonus(g.Ship.X, g.Ship.Y, 0, 0, "B")
+
+	g.UpdateBonuses()
+
+	if g.Bullets.ActiveCount != 1 {
+		t.Error("A network client should not clear torpedoes itself")
+	}
+}
+
+// =============================================================================
+// Enemy Behavior Tests
+// =============================================================================
+
+// stubBehavior records how often the enemy update calls it.
+type stubBehavior struct {
+	updates, fires int
+}
+
+func (s *stubBehavior) Update(g *Game, e *Enemy)               { s.updates++ }
+func (s *stubBehavior) Fire(g *Game, e *Enemy) bool            { s.fires++; return false }
+func (s *stubBehavior) Render(g *Game, e *Enemy, x, y float64) {}
+
+func TestBehavior_EveryKindRegistered(t *testing.T) {
+	for kind := SmallFighter; kind <= Boss; kind++ {
+		if _, ok := enemyBehaviors[kind]; !ok {
+			t.Errorf("No behavior registered for %v", kind)
+		}
+	}
+}
+
+func TestBehavior_RegisteredBehaviorDrivesEnemy(t *testing.T) {
+	const kind = EnemyKind(100)
+	if _, ok := BehaviorFor(kind).(Fighter); !ok {
+		t.Fatal("Kinds without a behavior should behave like fighters")
+	}
+
+	stub := &stubBehavior{}
+	RegisterBehavior(kind, stub)
+	t.Cleanup(func() { delete(enemyBehaviors, kind) })
+
+	g := NewHeadlessGame(1)
+	e := &Enemy{Kind: kind, X: 100, Y: 100, Radius: ShipR, Health: 1}
+	if !e.Update(g) {
+		t.Fatal("Enemy should survive the update")
+	}
+	if stub.updates != 1 || stub.fires != 1 {
+		t.Errorf("Behavior updated %d and fired %d times, want once each", stub.updates, stub.fires)
+	}
+	if e.X != 100 || e.Y != 100 {
+		t.Error("Enemy moved although its behavior did not move it")
+	}
+}
+
+func TestBehavior_MoveByBlockedByShield(t *testing.T) {
+	g := NewHeadlessGame(1)
+	g.Bases = []*Base{NewBase(0, 0)}
+	e := &Enemy{X: BaseShieldRadius + ShipR*2, Y: 0, Radius: ShipR}
+
+	if !e.MoveBy(g, 0, 10) || e.VelY != 10 {
+		t.Error("Moving along the shield should not be blocked")
+	}
+	x := e.X
+	if e.MoveBy(g, -ShipR*2, 0) || e.X != x || e.VelX != 0 {
+		t.Error("Moving into the shield should be blocked and stop the enemy")
+	}
+}
+
+func TestBehavior_KamikazeRamsShip(t *testing.T) {
+	g := NewHeadlessGame(1)
+	g.Ship.X, g.Ship.Y = 500, 500 // Outside the base shield
+	g.Ship.Timeout = -1
+	e := g.NewEnemy(Kamikaze, 500+KamikazeR/2, 500, 4)
+	g.Enemies = append(g.Enemies, e)
+	energy := g.Ship.E
+
+	g.UpdateEnemies()
+	if g.Ship.E != energy-KamikazeDamage {
+		t.Errorf("Ship energy = %d, want %d after being rammed", g.Ship.E, energy-KamikazeDamage)
+	}
+	if e.IsAlive() {
+		t.Error("Kamikaze should be destroyed by ramming")
+	}
+
+	g.UpdateEnemies()
+	if len(g.Enemies) != 0 {
+		t.Error("Rammed kamikaze should be removed")
+	}
+	if g.Ship.Points != 0 || g.Bonuses.ActiveCount != 0 {
+		t.Errorf("Ramming gave %d points and %d bonuses, want no reward", g.Ship.Points, g.Bonuses.ActiveCount)
+	}
+}
+
+func TestBehavior_MineLayerDropsMines(t *testing.T) {
+	g := NewHeadlessGame(1)
+	g.Ship.X, g.Ship.Y = 500, 500
+	e := g.NewEnemy(MineLayer, 500, 500+MineLayerRange, 12)
+	e.Target = g.Ship
+	e.FireTimer = 1
+
+	if !BehaviorFor(MineLayer).Fire(g, e) {
+		t.Fatal("Mine layer should drop a mine when its timer runs out")
+	}
+	mine := g.Bullets.Pool[0]
+	if mine.Kind != MineBullet || mine.X != e.X || mine.T != MineLifetime {
+		t.Errorf("Dropped %+v, want a mine at the mine layer", *mine)
+	}
+	if e.FireTimer != MineLayerInterval {
+		t.Errorf("FireTimer = %d, want %d", e.FireTimer, MineLayerInterval)
+	}
+}
+
+func TestBehavior_MineGoesOffNearShip(t *testing.T) {
+	g := NewHeadlessGame(1)
+	g.Ship.X, g.Ship.Y = 500, 500
+	g.Ship.Timeout = -1
+	mine := g.Bullets.AcquireKind(MineBullet)
+	mine.X, mine.Y, mine.T = 500+MineTriggerRadius*2, 500, MineLifetime
+	energy := g.Ship.E
+
+	g.UpdateBullets()
+	if g.Bullets.ActiveCount != 1 || mine.X != 500+MineTriggerRadius*2 {
+		t.Fatal("A mine should stay put while the ship is away")
+	}
+
+	g.Ship.X += MineTriggerRadius * 1.5
+	g.UpdateBullets()
+	if g.Bullets.ActiveCount != 0 || g.Ship.E != energy-MineDamage {
+		t.Errorf("Mine left %d bullets and energy %d, want it gone and %d damage",
+			g.Bullets.ActiveCount, g.Ship.E, MineDamage)
+	}
+}
+
+func TestBehavior_MineExpires(t *testing.T) {
+	g := NewHeadlessGame(1)
+	mine := g.Bullets.AcquireKind(MineBullet)
+	mine.X, mine.Y, mine.T = 100, 100, 2
+
+	for i := 0; i < 3; i++ {
+		g.UpdateBullets()
+	}
+	if g.Bullets.ActiveCount != 0 {
+		t.Error("Mine should disarm when its lifetime runs out")
+	}
+}
+
+func TestBehavior_CarrierLaunchesFighters(t *testing.T) {
+	g := NewHeadlessGame(1)
+	carrier := g.NewEnemy(Carrier, 500, 500, 40)
+	carrier.Target = g.Ship
+
+	for i := 0; i < (CarrierLaunches+2)*CarrierInterval; i++ {
+		BehaviorFor(Carrier).Fire(g, carrier)
+	}
+	if len(g.Enemies) != CarrierLaunches || carrier.Launched != CarrierLaunches {
+		t.Fatalf("Carrier launched %d fighters, want %d", len(g.Enemies), CarrierLaunches)
+	}
+	for _, e := range g.Enemies {
+		if e.Kind != SmallFighter || e.X != carrier.X {
+			t.Errorf("Launched %v at x %v, want small fighters from the carrier", e.Kind, e.X)
+		}
+	}
+}
+
+func TestBehavior_NewKindsSpawnInTheInfiniteWorld(t *testing.T) {
+	g := NewHeadlessGame(1)
+	g.Ship.Points = 4500
+	for kind := Kamikaze; kind < EnemyKindCount; kind++ {
+		if !g.SpawnEnemyNearShip(kind, g.Ship) {
+			t.Errorf("%s has no spawn configuration", EnemyKindNames[kind])
+		}
+	}
+	for kind := SmallFighter; kind < EnemyKindCount; kind++ {
+		if _, err := kind.MarshalText(); err != nil {
+			t.Errorf("%s has no spawn table name: %v", EnemyKindNames[kind], err)
+		}
+	}
+}
+
+func TestNetwork_ClientKeepsMines(t *testing.T) {
+	g := NewHeadlessGame(1)
+	nm := &NetworkManager{game: g}
+	nm.updateBullets(&WorldStateData{Bullets: []BulletState{
+		{Kind: MineBullet, X: 10, T: MineLifetime},
+		{Kind: TorpedoBullet, X: 20},
+	}})
+
+	if g.Bullets.ActiveCount != 2 || g.Bullets.Pool[0].Kind != MineBullet {
+		t.Errorf("Client bullets = %d, first %v, want the mine kept", g.Bullets.ActiveCount, g.Bullets.Pool[0].Kind)
+	}
+}
+
+// =============================================================================
+// Boss Tests
+// =============================================================================
+
+// newTestBoss adds a boss with 30 health to g, centered on world position
+// (x, y), with its turrets held fire.
+func newTestBoss(g *Game, x, y float64) *Enemy {
+	e := g.NewEnemy(Boss, x, y, 30)
+	e.Y -= e.YOffset
+	e.Target = g.Ship
+	g.Enemies = append(g.Enemies, e)
+	return e
+}
+
+// shootAt fires a player bullet at world position (x, y) into e.
+func shootAt(g *Game, e *Enemy, x, y float64) bool {
+	b := &Bullet{Kind: StandardBullet, X: x, Y: y}
+	return e.Collision(g, b)
+}
+
+// destroyParts destroys every part of e of the given kind.
+func destroyParts(e *Enemy, kind BossPartKind) {
+	for _, p := range e.Parts {
+		if p.Kind == kind {
+			p.Health = 0
+		}
+	}
+}
+
+func TestBoss_HasParts(t *testing.T) {
+	g := NewHeadlessGame(1)
+	e := newTestBoss(g, 0, 0)
+
+	turrets, generators := 0, 0
+	for _, p := range e.Parts {
+		switch p.Kind {
+		case PartTurret:
+			turrets++
+		case PartShieldGenerator:
+			generators++
+		}
+		if p.Health != p.MaxHealth || p.Health <= 0 {
+			t.Errorf("Part %v health %d/%d, want full", p.Kind, p.Health, p.MaxHealth)
+		}
+	}
+	if turrets != 2 || generators != 1 {
+		t.Errorf("Boss has %d turrets and %d shield generators, want 2 and 1", turrets, generators)
+	}
+	if small := g.NewEnemy(SmallFighter, 0, 0, 8); len(small.Parts) != 0 {
+		t.Error("Only bosses should have parts")
+	}
+}
+
+func TestBoss_PartsTakeHitsAndShieldCore(t *testing.T) {
+	g := NewHeadlessGame(1)
+	e := newTestBoss(g, 500, 500)
+	turret := e.Parts[0]
+
+	px, py := e.PartPosition(turret)
+	if !shootAt(g, e, px, py) || turret.Health != turret.MaxHealth-1 || turret.OSD != ShipMaxOSD {
+		t.Errorf("Turret health %d OSD %d after a hit, want %d and %d",
+			turret.Health, turret.OSD, turret.MaxHealth-1, ShipMaxOSD)
+	}
+
+	if !shootAt(g, e, e.X, e.Y) || e.Health != 30 {
+		t.Errorf("Shielded core health = %d, want the bullet absorbed", e.Health)
+	}
+
+	destroyParts(e, PartShieldGenerator)
+	if !shootAt(g, e, e.X, e.Y) || e.Health != 29 {
+		t.Errorf("Exposed core health = %d, want 29", e.Health)
+	}
+}
+
+func TestBoss_PartsTurnWithBoss(t *testing.T) {
+	g := NewHeadlessGame(1)
+	e := newTestBoss(g, 0, 0)
+	p := e.Parts[0]
+
+	e.Angle = math.Pi / 2
+	x, y := e.PartPosition(p)
+	if math.Abs(x-(-p.OffsetY)) > 1e-9 || math.Abs(y-p.OffsetX) > 1e-9 {
+		t.Errorf("Part at (%v, %v) after a quarter turn, want (%v, %v)", x, y, -p.OffsetY, p.OffsetX)
+	}
+}
+
+func TestBoss_PhasesFollowDestroyedParts(t *testing.T) {
+	g := NewHeadlessGame(1)
+	e := newTestBoss(g, 500, 500)
+
+	BehaviorFor(Boss).Update(g, e)
+	if e.Phase != BossPhaseShielded {
+		t.Fatalf("Phase = %d, want shielded", e.Phase)
+	}
+
+	destroyParts(e, PartShieldGenerator)
+	BehaviorFor(Boss).Update(g, e)
+	if e.Phase != BossPhaseExposed || g.Level.Text.Message != "SHIELD DOWN" {
+		t.Errorf("Phase = %d with %q, want exposed and announced", e.Phase, g.Level.Text.Message)
+	}
+	if !e.Parts[2].Destroyed {
+		t.Error("Destroyed shield generator should have been blown up")
+	}
+
+	destroyParts(e, PartTurret)
+	BehaviorFor(Boss).Update(g, e)
+	if e.Phase != BossPhaseEnraged || g.Level.Text.Message != "BOSS ENRAGED" {
+		t.Errorf("Phase = %d with %q, want enraged and announced", e.Phase, g.Level.Text.Message)
+	}
+}
+
+func TestBoss_TurretsFireUntilDestroyed(t *testing.T) {
+	g := NewHeadlessGame(1)
+	e := newTestBoss(g, 500, 500)
+	e.FireTimer = math.MaxInt32 // Hold the core
+	turret := e.Parts[0]
+	turret.FireTimer = 1
+	e.Parts[1].FireTimer = math.MaxInt32
+
+	BehaviorFor(Boss).Fire(g, e)
+	px, py := e.PartPosition(turret)
+	if g.Bullets.ActiveCount != 1 || g.Bullets.Pool[0].X != px || g.Bullets.Pool[0].Y != py {
+		t.Fatalf("%d torpedoes fired, want one from the turret", g.Bullets.ActiveCount)
+	}
+
+	turret.Health = 0
+	turret.FireTimer = 1
+	BehaviorFor(Boss).Fire(g, e)
+	if g.Bullets.ActiveCount != 1 {
+		t.Error("Destroyed turret should not fire")
+	}
+}
+
+func TestBoss_EnragedCoreFiresSpread(t *testing.T) {
+	g := NewHeadlessGame(1)
+	e := newTestBoss(g, 500, 500)
+	destroyParts(e, PartShieldGenerator)
+	destroyParts(e, PartTurret)
+	BehaviorFor(Boss).Update(g, e)
+	e.FireTimer = 1
+
+	BehaviorFor(Boss).Fire(g, e)
+	if g.Bullets.ActiveCount != 3 {
+		t.Errorf("Enraged boss fired %d torpedoes, want a spread of 3", g.Bullets.ActiveCount)
+	}
+}
+
+func TestBoss_BombSparesShieldedCore(t *testing.T) {
+	g := NewHeadlessGame(1)
+	e := newTestBoss(g, 0, -200)
+
+	g.Ship.DetonateBomb(g)
+	if e.Health != 30 {
+		t.Errorf("Shielded core health = %d, want 30", e.Health)
+	}
+	for _, p := range e.Parts {
+		if p.Health != p.MaxHealth-BombDamage {
+			t.Errorf("Part %v health = %d, want %d", p.Kind, p.Health, p.MaxHealth-BombDamage)
+		}
+	}
+}
+
+func TestBoss_PartsSyncToClients(t *testing.T) {
+	host := NewHeadlessGame(1)
+	e := newTestBoss(host, 500, 500)
+	destroyParts(e, PartShieldGenerator)
+	e.Parts[0].Health--
+
+	client := NewHeadlessGame(1)
+	nm := &NetworkManager{game: client}
+	nm.updateEnemies(&WorldStateData{Enemies: []EnemyState{
+		{Kind: Boss, Health: e.Health, Parts: partHealth(e)},
+	}})
+
+	got := client.Enemies[0]
+	if !reflect.DeepEqual(partHealth(got), partHealth(e)) {
+		t.Errorf("Client parts = %v, want %v", partHealth(got), partHealth(e))
+	}
+	if got.Phase != BossPhaseExposed {
+		t.Errorf("Client phase = %d, want exposed", got.Phase)
+	}
+	if partHealth(host.NewEnemy(SmallFighter, 0, 0, 8)) != nil {
+		t.Error("Enemies without parts should not send part health")
+	}
+}
+
+func TestGolden_BossParts(t *testing.T) {
+	g := NewHeadlessGame(1)
+	size := int(g.EnemyTypes[Boss].R)*2 + 32
+	r := newGoldenRaster(size, size, WIDTH/2-float64(size)/2, HEIGHT/2-float64(size)/2)
+	g.Ctx = r
+	g.InitializeEnemyGraphics()
+
+	e := newTestBoss(g, 0, 0)
+	e.Parts[0].Health /= 2
+	e.Parts[0].OSD = ShipMaxOSD
+	e.Render(g)
+
+	checkGolden(t, "boss-parts", r)
+}
+
+// =============================================================================
+// Weapon Tests
+// =============================================================================
+
+// newWeaponTestGame returns a game with its ship out of the base, carrying
+// weapon kind k.
+func newWeaponTestGame(k WeaponKind) *Game {
+	g := NewHeadlessGame(1)
+	g.Ship.X, g.Ship.Y = 500, 500
+	g.Ship.GiveWeapon(k)
+	return g
+}
+
+// holdFire applies ticks ticks of held fire to the ship and releases it.
+func holdFire(g *Game, ticks int) {
+	for i := 0; i < ticks; i++ {
+		g.Ship.ApplyInput(g, ControlState{Keys: KeyFire}, true)
+	}
+	g.Ship.ApplyInput(g, ControlState{}, true)
+}
+
+func TestWeapon_PickupsAndSwitching(t *testing.T) {
+	g := NewHeadlessGame(1)
+	s := g.Ship
+
+	for _, k := range []WeaponKind{WeaponMissile, WeaponCharge} {
+		item := &Bonus{Type: WeaponDrop(s), X: s.X, Y: s.Y}
+		if !s.Pickup(g, item) {
+			t.Fatalf("Ship missed the %q pickup", item.Type)
+		}
+		if k == WeaponCharge {
+			break
+		}
+		if s.Weapon != k || !s.HasWeapon(k) {
+			t.Errorf("Weapon = %v after the pickup, want %v", s.Weapon, k)
+		}
+	}
+	if s.Weapon != WeaponLaser {
+		t.Errorf("Second weapon drop gave %v, want the laser", s.Weapon)
+	}
+	if s.HasWeapon(WeaponCharge) {
+		t.Error("Charge shot should not be carried before its pickup")
+	}
+
+	// Switching is edge triggered and skips weapons the ship lacks
+	s.ApplyInput(g, ControlState{Keys: KeySwitchWeapon}, true)
+	s.ApplyInput(g, ControlState{Keys: KeySwitchWeapon}, true)
+	if s.Weapon != WeaponSpread {
+		t.Errorf("Weapon = %v after one switch from the laser, want the spread gun", s.Weapon)
+	}
+	s.ApplyInput(g, ControlState{}, true)
+	s.ApplyInput(g, ControlState{Keys: KeySwitchWeapon}, true)
+	if s.Weapon != WeaponMissile {
+		t.Errorf("Weapon = %v after switching on, want missiles", s.Weapon)
+	}
+}
+
+func TestWeapon_DropsFromConfiguredEnemies(t *testing.T) {
+	g := NewHeadlessGame(1)
+	g.Ship.X, g.Ship.Y = 500, 500
+	e := g.NewEnemy(Carrier, 500, 200, 0)
+	e.Target = g.Ship
+	g.Enemies = append(g.Enemies, e)
+
+	g.UpdateEnemies()
+	types := map[string]bool{}
+	for i := 0; i < g.Bonuses.ActiveCount; i++ {
+		types[g.Bonuses.Pool[i].Type] = true
+	}
+	if !types["M"] {
+		t.Errorf("Destroyed carrier left %v, want a missile pickup", types)
+	}
+	if WeaponDrop(&Ship{Arsenal: 1<<WeaponMissile | 1<<WeaponLaser | 1<<WeaponCharge}) != "+" {
+		t.Error("Ships carrying every weapon should get weapon upgrades")
+	}
+}
+
+func TestWeapon_ClientsSyncArsenalFromHost(t *testing.T) {
+	g := NewHeadlessGame(1)
+	nm := &NetworkManager{game: g, playerID: "me"}
+	nm.reconcileLocalShip(&WorldStateData{Ships: []ShipState{
+		{ID: "me", Health: 100, Weapon: WeaponLaser, Arsenal: 1<<WeaponMissile | 1<<WeaponLaser},
+	}})
+	if g.Ship.Weapon != WeaponLaser || !g.Ship.HasWeapon(WeaponMissile) {
+		t.Errorf("Client ship weapon %v arsenal %b after sync, want the host's", g.Ship.Weapon, g.Ship.Arsenal)
+	}
+	g.Ship.NextWeapon(g)
+	if g.Ship.Weapon != WeaponSpread {
+		t.Errorf("Weapon = %v after switching on from the laser, want the spread gun", g.Ship.Weapon)
+	}
+
+	remote := &Ship{NetworkID: "other"}
+	g.Ships = append(g.Ships, remote)
+	nm.updateRemoteShips(&WorldStateData{Ships: []ShipState{
+		{ID: "other", Health: 100, Weapon: WeaponCharge, Arsenal: 1 << WeaponCharge, Charge: 20},
+	}})
+	if remote.Weapon != WeaponCharge || remote.Charge != 20 {
+		t.Errorf("Remote ship weapon %v charge %d, want the charge shot at 20", remote.Weapon, remote.Charge)
+	}
+
+	// A weapon the ship does not carry is not selected
+	remote.SetArsenal(WeaponMissile, 0, 0)
+	if remote.Weapon != WeaponSpread || remote.Arsenal != 0 {
+		t.Errorf("Weapon = %v arsenal %b, want the spread gun only", remote.Weapon, remote.Arsenal)
+	}
+}
+
+func TestWeapon_MissilesHomeWithoutLock(t *testing.T) {
+	g := newWeaponTestGame(WeaponMissile)
+	e := g.NewEnemy(SmallFighter, 800, 500, 50)
+	e.Y -= e.YOffset
+	e.Target = g.Ship
+	g.Enemies = append(g.Enemies, e)
+
+	g.Ship.ApplyInput(g, ControlState{Keys: KeyFire}, true)
+	if g.Bullets.ActiveCount != 1 || g.Bullets.Pool[0].Kind != MissileBullet {
+		t.Fatalf("%d bullets fired, want one missile", g.Bullets.ActiveCount)
+	}
+	g.Ship.ApplyInput(g, ControlState{Keys: KeyFire}, true)
+	if g.Bullets.ActiveCount != 1 {
+		t.Error("Missiles should reload between volleys")
+	}
+
+	// Fired straight up, the missile turns toward the enemy on the right
+	for i := 0; i < MissileMaxT && g.Bullets.ActiveCount > 0; i++ {
+		g.UpdateBullets()
+	}
+	if e.Health != 50-MissileDamage {
+		t.Errorf("Enemy health = %d, want %d after a missile hit", e.Health, 50-MissileDamage)
+	}
+}
+
+func TestWeapon_LaserBurnsFirstEnemyInLine(t *testing.T) {
+	g := newWeaponTestGame(WeaponLaser)
+	near := g.NewEnemy(SmallFighter, 500, 300, 10)
+	far := g.NewEnemy(SmallFighter, 500, 150, 10)
+	for _, e := range []*Enemy{far, near} {
+		e.Y -= e.YOffset
+		g.Enemies = append(g.Enemies, e)
+	}
+
+	g.Ship.ApplyInput(g, ControlState{Keys: KeyFire}, true)
+	if near.Health != 10-LaserDamage || far.Health != 10 {
+		t.Errorf("Health near %d far %d, want only the near enemy burned", near.Health, far.Health)
+	}
+	beam := g.Bullets.Pool[0]
+	if g.Bullets.ActiveCount != 1 || beam.Kind != LaserBullet {
+		t.Fatalf("%d bullets fired, want a laser beam", g.Bullets.ActiveCount)
+	}
+	if want := 500 - near.Y - near.Radius*0.6; math.Abs(-beam.YAcc-want) > 1e-9 {
+		t.Errorf("Beam length = %v, want %v to the near enemy", -beam.YAcc, want)
+	}
+
+	// The beam burns again after LaserInterval ticks and only lasts a tick
+	for i := 0; i < LaserInterval; i++ {
+		g.Ship.ApplyInput(g, ControlState{Keys: KeyFire}, true)
+		g.UpdateBullets()
+	}
+	if near.Health != 10-2*LaserDamage {
+		t.Errorf("Near enemy health = %d, want %d", near.Health, 10-2*LaserDamage)
+	}
+	if g.Bullets.ActiveCount != 1 {
+		t.Errorf("%d beams shown, want one", g.Bullets.ActiveCount)
+	}
+}
+
+func TestWeapon_LaserHitsBossPartsBeforeCore(t *testing.T) {
+	g := newWeaponTestGame(WeaponLaser)
+	e := newTestBoss(g, 500, 0)
+	gen := e.Parts[2]
+
+	hit, part, _ := g.LaserHit(500, 500, 0, -1, LaserRange)
+	if hit != e || part != gen {
+		t.Errorf("Laser hit %v part %v, want the shield generator", hit, part)
+	}
+	if hit, _, length := g.LaserHit(500, 500, 0, 1, LaserRange); hit != nil || length != LaserRange {
+		t.Errorf("Laser pointed away hit %v at %v, want nothing", hit, length)
+	}
+}
+
+func TestWeapon_ChargeShotScalesWithCharge(t *testing.T) {
+	g := newWeaponTestGame(WeaponCharge)
+
+	holdFire(g, ChargeMax/2)
+	half := g.Bullets.Pool[0]
+	if g.Bullets.ActiveCount != 1 || half.Kind != ChargeBullet {
+		t.Fatalf("%d bullets fired, want one charge shot on release", g.Bullets.ActiveCount)
+	}
+	if half.E <= 1 || half.E >= ChargeMaxDamage {
+		t.Errorf("Half charge damage = %d, want between 1 and %d", half.E, ChargeMaxDamage)
+	}
+
+	holdFire(g, ChargeMax*2)
+	if full := g.Bullets.Pool[1]; full.E != ChargeMaxDamage {
+		t.Errorf("Full charge damage = %d, want %d", full.E, ChargeMaxDamage)
+	}
+	if g.Ship.Charge != 0 {
+		t.Errorf("Charge = %d after release, want 0", g.Ship.Charge)
+	}
+
+	e := g.NewEnemy(SmallFighter, 0, 0, 30)
+	if !e.Collision(g, &Bullet{Kind: ChargeBullet, X: e.X, Y: e.Y, E: ChargeMaxDamage}) ||
+		e.Health != 30-ChargeMaxDamage {
+		t.Errorf("Enemy health = %d after a full charge shot, want %d", e.Health, 30-ChargeMaxDamage)
+	}
+}
+
+func TestWeapon_ChargeNotFiredOnClients(t *testing.T) {
+	g := newWeaponTestGame(WeaponCharge)
+	for i := 0; i < 10; i++ {
+		g.Ship.ApplyInput(g, ControlState{Keys: KeyFire}, false)
+	}
+	g.Ship.ApplyInput(g, ControlState{}, false)
+	if g.Bullets.ActiveCount != 0 || g.Ship.Charge != 0 {
+		t.Errorf("Client fired %d bullets with charge %d left, want none", g.Bullets.ActiveCount, g.Ship.Charge)
+	}
+}
+
+func TestGolden_WeaponProjectiles(t *testing.T) {
+	g := NewHeadlessGame(1)
+	r := newGoldenRaster(256, 128, WIDTH/2-128, HEIGHT/2-64)
+	g.Ctx = r
+	g.InitializeGraphics()
+
+	for _, b := range []*Bullet{
+		{Kind: MissileBullet, X: -96, Y: 0, XAcc: 10, YAcc: -10},
+		{Kind: ChargeBullet, X: -32, Y: 0},
+		{Kind: LaserBullet, X: 16, Y: 48, XAcc: 96, YAcc: -96},
+	} {
+		b.Render(g)
+	}
+
+	checkGolden(t, "weapon-projectiles", r)
+}
+
+// =============================================================================
+// Shop Tests
+// =============================================================================
+
+// newDockedGame returns a game with its ship docked in the base and the
+// given points to spend.
+func newDockedGame(points int) *Game {
+	g := NewHeadlessGame(1)
+	g.State = StatePlaying
+	g.Ship.Points = points
+	g.Ship.InBase = true
+	return g
+}
+
+func TestShop_BuySpendsPointsWhileDocked(t *testing.T) {
+	g := newDockedGame(1000)
+	s := g.Ship
+
+	if !s.Buy(g, UpgradeThrust) || s.Points != 600 || s.Upgrades[UpgradeThrust] != 1 {
+		t.Fatalf("After buying thrust: points %d level %d, want 600 and 1", s.Points, s.Upgrades[UpgradeThrust])
+	}
+	if price, _ := s.UpgradePrice(UpgradeThrust); price != 800 {
+		t.Errorf("Second thrust level costs %d, want 800", price)
+	}
+	if s.Buy(g, UpgradeThrust) {
+		t.Error("Bought an upgrade without enough points")
+	}
+
+	s.Points = 10000
+	s.InBase = false
+	if s.Buy(g, UpgradeLock) {
+		t.Error("Bought an upgrade outside the base")
+	}
+
+	s.InBase = true
+	for s.Buy(g, UpgradeLock) {
+	}
+	if s.Upgrades[UpgradeLock] != UpgradeMaxLevel {
+		t.Errorf("Lock level = %d, want the max %d", s.Upgrades[UpgradeLock], UpgradeMaxLevel)
+	}
+	if _, ok := s.UpgradePrice(UpgradeLock); ok {
+		t.Error("Fully upgraded lock should have no price")
+	}
+	if s.Buy(g, UpgradeCount) {
+		t.Error("Bought an unknown upgrade")
+	}
+}
+
+func TestShop_UpgradesImproveShip(t *testing.T) {
+	g := newDockedGame(100000)
+	s := g.Ship
+	for u := Upgrade(0); u < UpgradeCount; u++ {
+		s.Buy(g, u)
+	}
+
+	if s.MaxEnergy() != ShipMaxEnergy+EnergyPerUpgrade {
+		t.Errorf("MaxEnergy() = %d, want %d", s.MaxEnergy(), ShipMaxEnergy+EnergyPerUpgrade)
+	}
+	if s.ThrustAcc() <= ShipThrustAcc || s.RotationSpeed() <= ShipRotationSpeed {
+		t.Errorf("Thrust %v and rotation %v should exceed the defaults", s.ThrustAcc(), s.RotationSpeed())
+	}
+	if s.Shield.MaxT != ShipMaxShield+ShieldPerUpgrade {
+		t.Errorf("Shield capacity = %d, want %d", s.Shield.MaxT, ShipMaxShield+ShieldPerUpgrade)
+	}
+	for len(s.Weapons) < MaxWeapons+WeaponSlotsPerUpgrade+1 {
+		s.AddWeapon()
+		if len(s.Weapons) == s.MaxWeapons() {
+			break
+		}
+	}
+	if len(s.Weapons) != MaxWeapons+WeaponSlotsPerUpgrade {
+		t.Errorf("Ship carries %d weapons, want %d", len(s.Weapons), MaxWeapons+WeaponSlotsPerUpgrade)
+	}
+	if s.lockTime(30) != 24 || s.lockTime(1) != 1 {
+		t.Errorf("Lock times %d and %d, want 24 and 1", s.lockTime(30), s.lockTime(1))
+	}
+
+	// Docking repairs up to the raised energy
+	s.X, s.Y = 0, 0
+	s.E = ShipMaxEnergy
+	s.RepairTimer = 0
+	s.Update(g)
+	if s.E != ShipMaxEnergy+1 {
+		t.Errorf("Energy = %d after a repair tick, want %d", s.E, ShipMaxEnergy+1)
+	}
+}
+
+func TestShop_PurchasesGoThroughInput(t *testing.T) {
+	g := newDockedGame(2000)
+	g.Ship.Input = g.LocalInput
+	if !g.OpenShop() {
+		t.Fatal("Shop should open while docked")
+	}
+	g.Shop.HandleKey(shopKeyDown)
+	g.Shop.HandleKey(shopKeyBuy)
+	g.Shop.HandleKey(shopKeyBuy)
+
+	for i := 0; i < 4; i++ {
+		g.Step(g.PollInputs())
+	}
+	if g.Ship.Upgrades[UpgradeThrust] != 2 || g.Ship.Points != 2000-400-800 {
+		t.Errorf("Thrust level %d with %d points, want 2 and %d",
+			g.Ship.Upgrades[UpgradeThrust], g.Ship.Points, 2000-400-800)
+	}
+
+	// Purchases replay like any other input
+	data, _ := g.Recording.MarshalBinary()
+	r, err := DecodeReplay(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	replayed := newDockedGame(2000)
+	for _, c := range r.Inputs {
+		replayed.Step([]ControlState{c})
+	}
+	if replayed.Ship.Upgrades != g.Ship.Upgrades {
+		t.Errorf("Replayed upgrades %v, want %v", replayed.Ship.Upgrades, g.Ship.Upgrades)
+	}
+}
+
+func TestShop_ClosesWhenLeavingBase(t *testing.T) {
+	g := newDockedGame(0)
+	g.OpenShop()
+	g.Ship.InBase = false
+	g.UpdateState()
+	if g.Shop.Visible {
+		t.Error("Shop should close when the ship leaves the base")
+	}
+	if g.OpenShop() {
+		t.Error("Shop should not open outside a base")
+	}
+}
+
+func TestShop_ClientsWaitForHost(t *testing.T) {
+	g := newDockedGame(1000)
+	g.Ship.ApplyInput(g, ControlState{Keys: KeyBuy, Buy: UpgradeEnergy}, false)
+	if g.Ship.Upgraded() {
+		t.Fatal("Client bought an upgrade itself")
+	}
+
+	nm := &NetworkManager{game: g, playerID: "me"}
+	nm.reconcileLocalShip(&WorldStateData{Ships: []ShipState{
+		{ID: "me", Points: 500, Upgrades: []int{1, 0, 0, 2}},
+	}})
+	if g.Ship.Points != 500 || g.Ship.Upgrades[UpgradeEnergy] != 1 || g.Ship.Shield.MaxT != ShipMaxShield+2*ShieldPerUpgrade {
+		t.Errorf("Client ship points %d upgrades %v shield %d after sync",
+			g.Ship.Points, g.Ship.Upgrades, g.Ship.Shield.MaxT)
+	}
+	if got := upgradeLevels(g.Ship); !reflect.DeepEqual(got, []int{1, 0, 0, 2, 0, 0}) {
+		t.Errorf("upgradeLevels() = %v", got)
+	}
+}
+
+func TestGolden_ShopScreen(t *testing.T) {
+	g := newDockedGame(1000)
+	g.Ship.Buy(g, UpgradeEnergy)
+	g.OpenShop()
+	g.Shop.HandleKey(shopKeyDown)
+
+	r := newGoldenRaster(680, 400, (WIDTH-680)/2, (HEIGHT-400)/2)
+	g.Shop.Render(r, g)
+
+	checkGolden(t, "shop-screen", r)
+}
+
+// =============================================================================
+// World Chunk Tests
+// =============================================================================
+
+func TestWorld_ChunksDependOnlyOnSeedAndCoords(t *testing.T) {
+	coords := []ChunkCoord{{0, 0}, {1, 0}, {0, 1}, {-3, 2}, {40, -17}}
+	a, b, other := NewWorld(7), NewWorld(7), NewWorld(8)
+
+	differs := false
+	for _, c := range coords {
+		if !reflect.DeepEqual(a.Generate(c), b.Generate(c)) {
+			t.Errorf("Chunk %v differs between worlds with the same seed", c)
+		}
+		if !reflect.DeepEqual(a.Generate(c), other.Generate(c)) {
+			differs = true
+		}
+	}
+	if !differs {
+		t.Error("Worlds with different seeds generated the same chunks")
+	}
+	if common.LevelSeed(7, 1, 2) == common.LevelSeed(7, 2, 1) {
+		t.Error("Chunk seeds should depend on the order of the coordinates")
+	}
+}
+
+func TestWorld_OriginChunkKeepsStartingBase(t *testing.T) {
+	for seed := uint32(0); seed < 20; seed++ {
+		g := NewHeadlessGame(seed)
+		if b := g.Bases[0]; b.X != 0 || b.Y != 0 || b.Generated {
+			t.Fatalf("Seed %d: first base at (%v, %v) generated %v, want the starting base", seed, b.X, b.Y, b.Generated)
+		}
+
+		origin := g.World.Chunks[ChunkCoord{}]
+		if len(origin.Bases) != 0 {
+			t.Errorf("Seed %d: origin chunk generated %d bases", seed, len(origin.Bases))
+		}
+		for _, belt := range origin.Belts {
+			if math.Hypot(belt.X, belt.Y) < belt.extent()+BaseShieldRadius {
+				t.Errorf("Seed %d: asteroid belt at (%v, %v) overlaps the starting base", seed, belt.X, belt.Y)
+			}
+		}
+		for _, l := range origin.Landmarks {
+			if math.Hypot(l.X, l.Y) < l.Radius+BaseShieldRadius {
+				t.Errorf("Seed %d: landmark at (%v, %v) overlaps the starting base", seed, l.X, l.Y)
+			}
+		}
+	}
+}
+
+func TestWorld_ChunksFollowCamera(t *testing.T) {
+	g := NewHeadlessGame(7)
+	if len(g.World.Chunks) != 9 {
+		t.Fatalf("%d chunks loaded at the start, want 9", len(g.World.Chunks))
+	}
+	home := g.World.Chunks[ChunkCoord{1, 1}]
+
+	// Fly ten chunks to the right
+	g.Ship.X = 10 * ChunkSize
+	g.Camera.X = g.Ship.X
+	g.UpdateWorld()
+
+	if len(g.World.Chunks) != 9 {
+		t.Errorf("%d chunks loaded after moving, want 9", len(g.World.Chunks))
+	}
+	if _, ok := g.World.Chunks[ChunkCoord{}]; ok {
+		t.Error("Origin chunk should be unloaded when far away")
+	}
+	if _, ok := g.World.Chunks[ChunkCoord{11, 1}]; !ok {
+		t.Error("Chunks around the camera should be loaded")
+	}
+
+	bases := 1
+	for _, c := range g.World.Chunks {
+		bases += len(c.Bases)
+	}
+	if len(g.Bases) != bases || g.Bases[0].Generated {
+		t.Errorf("%d bases, want the starting base and the %d bases of the loaded chunks", len(g.Bases), bases-1)
+	}
+
+	// Coming back regenerates the same chunk
+	g.Ship.X, g.Camera.X = 0, 0
+	g.UpdateWorld()
+	if !reflect.DeepEqual(g.World.Chunks[ChunkCoord{1, 1}], home) {
+		t.Error("Chunk changed after unloading and loading it again")
+	}
+}
+
+func TestWorld_ChunksStayLoadedNearBorder(t *testing.T) {
+	g := NewHeadlessGame(7)
+	loaded := g.World.Chunks[ChunkCoord{-1, 0}]
+
+	// Crossing into the next chunk keeps the chunks behind the ship
+	g.Ship.X = ChunkSize
+	g.Camera.X = g.Ship.X
+	g.UpdateWorld()
+
+	if g.World.Chunks[ChunkCoord{-1, 0}] != loaded {
+		t.Error("Chunk within ChunkUnloadRadius was unloaded")
+	}
+}
+
+func TestWorld_PeersWithSameSeedShareBases(t *testing.T) {
+	host, client := NewHeadlessGame(99), NewHeadlessGame(5)
+	nm := &NetworkManager{game: client}
+	data, _ := json.Marshal(PlayerJoinData{PlayerID: "host", IsHost: true, Seed: host.GameSeed})
+	nm.handlePlayerJoin("host", data)
+
+	if client.World.Seed != host.GameSeed {
+		t.Fatalf("Client world seed %d, want the host's %d", client.World.Seed, host.GameSeed)
+	}
+	if len(client.Bases) != len(host.Bases) {
+		t.Fatalf("Client has %d bases, host %d", len(client.Bases), len(host.Bases))
+	}
+	for i := range host.Bases {
+		if client.Bases[i].X != host.Bases[i].X || client.Bases[i].Y != host.Bases[i].Y {
+			t.Errorf("Base %d at (%v, %v) on the client, (%v, %v) on the host", i,
+				client.Bases[i].X, client.Bases[i].Y, host.Bases[i].X, host.Bases[i].Y)
+		}
+	}
+}
+
+func TestGolden_WorldLandmarks(t *testing.T) {
+	g := NewHeadlessGame(1)
+	g.World.Clear()
+	chunk := &Chunk{Landmarks: []Landmark{
+		{Kind: LandmarkNebula, X: -250, Y: 0, Radius: 180, Angle: 0.3},
+		{Kind: LandmarkBeacon, X: 0, Y: -160, Radius: 24},
+		{Kind: LandmarkWreck, X: -10, Y: 90, Radius: 50, Angle: 0.5},
+	}}
+	rng := common.NewSeededRNG(3)
+	chunk.addAsteroids(rng, AsteroidBelt{X: 260, Y: 0, Angle: math.Pi / 2, Length: 400, Width: 300})
+	chunk.addDebris(rng, chunk.Landmarks[2])
+	g.World.Chunks[ChunkCoord{}] = chunk
+
+	r := newGoldenRaster(800, 480, WIDTH/2-400, HEIGHT/2-240)
+	g.Ctx = r
+	g.RenderWorld()
+
+	checkGolden(t, "world-landmarks", r)
+}
+
+// =============================================================================
+// Obstacle Tests
+// =============================================================================
+
+// newObstacleGame returns a game whose origin chunk holds nothing but an
+// obstacle of the given kind at (x, y).
+func newObstacleGame(kind ObstacleKind, x, y, r float64) (*Game, *Obstacle) {
+	g := NewHeadlessGame(1)
+	o := &Obstacle{Kind: kind, X: x, Y: y, R: r}
+	if kind == ObstacleDebris {
+		o.Health = DebrisHealth
+	}
+	chunk := &Chunk{}
+	chunk.addObstacle(o)
+	chunk.index()
+	g.World.Chunks[ChunkCoord{}] = chunk
+	return g, o
+}
+
+func TestObstacle_GeneratedInsideChunks(t *testing.T) {
+	w := NewWorld(3)
+	counts := map[ObstacleKind]int{}
+	for x := -4; x <= 4; x++ {
+		for y := -4; y <= 4; y++ {
+			c := ChunkCoord{x, y}
+			chunk := w.Generate(c)
+			w.Chunks[c] = chunk
+			for i, o := range chunk.Obstacles {
+				counts[o.Kind]++
+				if o.ID != (ObstacleID{c, i}) {
+					t.Errorf("Obstacle %d of chunk %v has ID %v", i, c, o.ID)
+				}
+				if ChunkAt(o.X-o.R, o.Y-o.R) != c || ChunkAt(o.X+o.R, o.Y+o.R) != c {
+					t.Errorf("Obstacle at (%v, %v) sticks out of chunk %v", o.X, o.Y, c)
+				}
+			}
+		}
+	}
+	if counts[ObstacleAsteroid] == 0 || counts[ObstacleDebris] == 0 {
+		t.Fatalf("Generated %d asteroids and %d debris, want both", counts[ObstacleAsteroid], counts[ObstacleDebris])
+	}
+
+	// Every obstacle is found through the grids
+	g := &Game{World: w}
+	for _, chunk := range w.Chunks {
+		for _, o := range chunk.Obstacles {
+			if g.ObstacleAt(o.X+o.R, o.Y, ShipObstacleR) == nil {
+				t.Fatalf("Obstacle %v not found at its edge", o.ID)
+			}
+		}
+	}
+}
+
+func TestObstacle_StopsBullets(t *testing.T) {
+	g, _ := newObstacleGame(ObstacleAsteroid, 500, 300, 50)
+
+	b := g.Bullets.AcquireKind(StandardBullet)
+	b.X, b.Y, b.YAcc, b.T = 500, 400, -40, 10
+	if !b.Update(g) {
+		t.Fatal("Bullet should fly until it reaches the asteroid")
+	}
+	if b.Update(g) {
+		t.Error("Bullet flew through the asteroid")
+	}
+
+	torpedo := g.Bullets.AcquireKind(TorpedoBullet)
+	torpedo.X, torpedo.Y, torpedo.YAcc = 500, 230, 40
+	if torpedo.Update(g) {
+		t.Error("Torpedo flew through the asteroid")
+	}
+}
+
+func TestObstacle_DebrisBreaks(t *testing.T) {
+	g, o := newObstacleGame(ObstacleDebris, 500, 300, 20)
+
+	for i := 0; i < DebrisHealth; i++ {
+		b := g.Bullets.AcquireKind(StandardBullet)
+		b.X, b.Y, b.T = 500, 300, 10
+		if b.Update(g) {
+			t.Fatalf("Bullet %d was not stopped by the debris", i)
+		}
+	}
+	if o.Solid() || g.World.Damage[o.ID] != DebrisHealth {
+		t.Fatalf("Debris health %d damage %d after %d hits, want broken", o.Health, g.World.Damage[o.ID], DebrisHealth)
+	}
+
+	b := g.Bullets.AcquireKind(StandardBullet)
+	b.X, b.Y, b.T = 500, 300, 10
+	if !b.Update(g) {
+		t.Error("Broken debris should not stop bullets")
+	}
+}
+
+func TestObstacle_UnloadingForgetsDamage(t *testing.T) {
+	w := NewWorld(3)
+	var debris *Obstacle
+	for x := 0; debris == nil && x < 50; x++ {
+		for _, o := range w.Generate(ChunkCoord{x, 0}).Obstacles {
+			if o.Kind == ObstacleDebris {
+				debris = o
+				break
+			}
+		}
+	}
+	if debris == nil {
+		t.Fatal("No debris generated")
+	}
+
+	g := NewHeadlessGame(3)
+	g.Ship.X, g.Ship.Y = debris.ID.Chunk.Center()
+	g.Camera.X, g.Camera.Y = g.Ship.X, g.Ship.Y
+	g.UpdateWorld()
+	o := g.World.Chunks[debris.ID.Chunk].Obstacles[debris.ID.Index]
+	g.DamageObstacle(o, DebrisHealth)
+
+	if len(obstacleDamage(g.World)) != 1 {
+		t.Fatalf("Damage = %v, want the broken debris", g.World.Damage)
+	}
+
+	// Fly away and back
+	g.Ship.X += 10 * ChunkSize
+	g.Camera.X = g.Ship.X
+	g.UpdateWorld()
+	if len(g.World.Damage) != 0 {
+		t.Errorf("Damage = %v after unloading its chunk, want none", g.World.Damage)
+	}
+	g.World.SetDamage(map[ObstacleID]int{debris.ID: DebrisHealth})
+	if len(g.World.Damage) != 0 {
+		t.Errorf("Damage = %v synced for an unloaded chunk, want none", g.World.Damage)
+	}
+	g.Ship.X -= 10 * ChunkSize
+	g.Camera.X = g.Ship.X
+	g.UpdateWorld()
+
+	if o := g.World.Chunks[debris.ID.Chunk].Obstacles[debris.ID.Index]; !o.Solid() {
+		t.Error("Debris stayed broken after its chunk was unloaded")
+	}
+}
+
+func TestObstacle_ShipBounces(t *testing.T) {
+	for _, hurt := range []bool{true, false} {
+		g, o := newObstacleGame(ObstacleAsteroid, 500, 400, 50)
+		s := g.Ship
+		s.X, s.Y = 500, 400+o.R+ShipObstacleR+4
+		s.VelX, s.VelY = 0, -ShipMaxSpeed
+		s.Timeout = -1
+		energy := s.E
+
+		s.ApplyInput(g, ControlState{}, hurt)
+
+		if d := math.Hypot(s.X-o.X, s.Y-o.Y); d < o.R+ShipObstacleR-1e-9 {
+			t.Errorf("Ship is %v inside the asteroid", o.R+ShipObstacleR-d)
+		}
+		if s.VelY <= 0 {
+			t.Errorf("Ship velocity %v after the impact, want it bounced back", s.VelY)
+		}
+		if hurt && s.E >= energy || !hurt && s.E != energy {
+			t.Errorf("hurt=%v: energy %d -> %d", hurt, energy, s.E)
+		}
+	}
+}
+
+func TestObstacle_EnemySteersAround(t *testing.T) {
+	g, o := newObstacleGame(ObstacleAsteroid, 500, 500, 60)
+	e := &Enemy{X: 500, Y: 800, Radius: ShipR}
+
+	for i := 0; i < 60; i++ {
+		if !e.MoveBy(g, 0, -10) {
+			t.Fatalf("Enemy got stuck at (%v, %v)", e.X, e.Y)
+		}
+		if math.Hypot(e.X-o.X, e.Y-o.Y) < o.R+e.Radius*0.6 {
+			t.Fatalf("Enemy flew into the asteroid at (%v, %v)", e.X, e.Y)
+		}
+	}
+	if e.Y > 400 {
+		t.Errorf("Enemy at (%v, %v) did not get past the asteroid", e.X, e.Y)
+	}
+}
+
+func TestObstacle_LaserStopsAtObstacle(t *testing.T) {
+	g, o := newObstacleGame(ObstacleAsteroid, 500, 300, 50)
+	e := g.NewEnemy(SmallFighter, 500, 100, 5)
+	g.Enemies = append(g.Enemies, e)
+	s := g.Ship
+	s.X, s.Y = 500, 500
+	s.GiveWeapon(WeaponLaser)
+
+	s.fireLaser(g)
+
+	if e.Health != 5 {
+		t.Error("Laser burned an enemy behind an asteroid")
+	}
+	beam := g.Bullets.Pool[0]
+	if length := -beam.YAcc; math.Abs(length-(s.Y-o.Y-o.R)) > 1e-9 {
+		t.Errorf("Beam length %v, want it to end on the asteroid", length)
+	}
+}
+
+func TestObstacle_DamageSyncedToClients(t *testing.T) {
+	host, ho := newObstacleGame(ObstacleDebris, 500, 300, 20)
+	client, co := newObstacleGame(ObstacleDebris, 500, 300, 20)
+	host.DamageObstacle(ho, DebrisHealth)
+
+	data, _ := json.Marshal(WorldStateData{Obstacles: obstacleDamage(host.World)})
+	var state WorldStateData
+	if err := json.Unmarshal(data, &state); err != nil {
+		t.Fatal(err)
+	}
+	nm := &NetworkManager{game: client}
+	nm.updateObstacles(&state)
+
+	if co.Solid() {
+		t.Error("Debris the host broke is still there on the client")
+	}
+	if host.Checksum() != client.Checksum() {
+		t.Error("Host and client obstacle damage hash differently")
+	}
+}
+
+// =============================================================================
+// Radar Tests
+// =============================================================================
+
+func TestRadar_Project(t *testing.T) {
+	r := NewRadar()
+	r.Range = 1000
+
+	if x, y, in := r.Project(500, 500, 1000, 500); !in || x != r.X+r.Radius/2 || y != r.Y {
+		t.Errorf("Project() half range right = (%v, %v) %v, want (%v, %v) in range", x, y, in, r.X+r.Radius/2, r.Y)
+	}
+	if x, y, in := r.Project(500, 500, 500, -2500); in || x != r.X || y != r.Y-r.Radius {
+		t.Errorf("Project() far above = (%v, %v) %v, want pinned to (%v, %v)", x, y, in, r.X, r.Y-r.Radius)
+	}
+}
+
+func TestRadar_CycleRanges(t *testing.T) {
+	r := NewRadar()
+	if r.Range != EnemySpawnDistance*1.25 {
+		t.Errorf("Default range %v, want room around EnemySpawnDistance", r.Range)
+	}
+
+	r.Cycle()
+	if !r.Visible || r.Range != RadarRanges[len(RadarRanges)-1] {
+		t.Errorf("After one cycle: visible %v range %v, want the largest range", r.Visible, r.Range)
+	}
+	r.Cycle()
+	if r.Visible {
+		t.Error("Cycling past the largest range should hide the radar")
+	}
+	r.Cycle()
+	if !r.Visible || r.Range != RadarRanges[0] {
+		t.Errorf("After hiding: visible %v range %v, want the smallest range", r.Visible, r.Range)
+	}
+}
+
+func TestGolden_Radar(t *testing.T) {
+	g := NewHeadlessGame(1)
+	g.Ship.X, g.Ship.Y, g.Ship.Angle = 500, 500, math.Pi/4
+	for i, kind := range []EnemyKind{SmallFighter, MediumFighter, Kamikaze, Carrier, Boss} {
+		a := float64(i) * 2 * math.Pi / 5
+		g.Enemies = append(g.Enemies, g.NewEnemy(kind, 500+math.Sin(a)*1800, 500-math.Cos(a)*1800, 5))
+	}
+	g.Ship.Target = g.Enemies[1]
+	torpedo := g.Bullets.AcquireKind(TorpedoBullet)
+	torpedo.X, torpedo.Y = 900, 300
+	ally := &Ship{X: -300, Y: 1400, Angle: -math.Pi / 2, E: 100}
+	g.Ships = append(g.Ships, ally)
+
+	r := newGoldenRaster(200, 200, g.Radar.X-100, g.Radar.Y-100)
+	g.Radar.Render(r, g)
+
+	checkGolden(t, "radar", r)
+}
+
+// =============================================================================
+// Difficulty Tests
+// =============================================================================
+
+func TestDifficulty_TitlePicksDifficulty(t *testing.T) {
+	g := newTitleGame()
+	if g.Title.Difficulty != DifficultyNormal {
+		t.Fatalf("Difficulty = %v, want normal by default", g.Title.Difficulty)
+	}
+
+	g.HandleStateAction(ActionRotateLeft, true)
+	g.HandleStateAction(ActionRotateLeft, true)
+	if g.Title.Difficulty != DifficultyEasy {
+		t.Errorf("Difficulty = %v, want easy to be the easiest", g.Title.Difficulty)
+	}
+	for i := 0; i < 4; i++ {
+		g.HandleStateAction(ActionRotateRight, true)
+	}
+	if g.Title.Difficulty != DifficultyInsane {
+		t.Errorf("Difficulty = %v, want insane to be the hardest", g.Title.Difficulty)
+	}
+
+	g.HandleStateAction(0, false) // Any key
+	if g.Difficulty != DifficultyInsane || g.Recording.Difficulty != DifficultyInsane {
+		t.Errorf("Started at %v recording %v, want insane", g.Difficulty, g.Recording.Difficulty)
+	}
+	g.StartDemo()
+	if g.Difficulty != DifficultyNormal || g.Title.Difficulty != DifficultyInsane {
+		t.Errorf("Demo at %v with %v picked, want a normal demo keeping the pick", g.Difficulty, g.Title.Difficulty)
+	}
+}
+
+func TestDifficulty_ScalesEnemiesAndDamage(t *testing.T) {
+	g := NewHeadlessGame(1)
+	g.Ship.X, g.Ship.Y = 500, 500
+	g.Difficulty = DifficultyHard
+
+	g.SpawnEnemies(SmallFighter, g.Ship, 3, 10, EnemySpawnDistance)
+	if len(g.Enemies) != 4 {
+		t.Errorf("Spawned %d enemies, want 3 scaled to 4", len(g.Enemies))
+	}
+	if e := g.Enemies[0]; e.Health != 14 || e.MaxHealth != 14 {
+		t.Errorf("Health = %d/%d, want 10 scaled to 14", e.Health, e.MaxHealth)
+	}
+
+	g.Ship.Timeout = -1
+	g.Ship.Hurt(g, 10)
+	if g.Ship.E != ShipMaxEnergy-15 {
+		t.Errorf("E = %d after a hit of 10, want %d", g.Ship.E, ShipMaxEnergy-15)
+	}
+
+	if DifficultyEasy.Damage(1) != 1 || DifficultyEasy.EnemyHealth(1) != 1 || DifficultyEasy.SpawnCount(1) != 1 {
+		t.Error("Easy should not scale hits, health or spawns below one")
+	}
+	for _, n := range []int{0, 1, 7, 45} {
+		d := DifficultyNormal
+		if d.Damage(n) != n || d.EnemyHealth(n) != n || d.SpawnCount(n) != n || (n > 0 && d.FireInterval(n) != n) {
+			t.Errorf("Normal scaled %d", n)
+		}
+	}
+}
+
+func TestDifficulty_ScalesFireRateAndTorpedoSpeed(t *testing.T) {
+	fire := func(d Difficulty) (*Enemy, float64) {
+		g := NewHeadlessGame(1)
+		g.Difficulty = d
+		e := g.NewEnemy(SmallFighter, 500, 200, 5)
+		e.Target = g.Ship
+		e.FireTimer = 1
+		if !(Fighter{}).Fire(g, e) {
+			t.Fatalf("%v: fighter did not fire", d)
+		}
+		torpedo := g.Bullets.Pool[g.Bullets.ActiveCount-1]
+		return e, math.Hypot(torpedo.XAcc, torpedo.YAcc)
+	}
+
+	normal, normalSpeed := fire(DifficultyNormal)
+	insane, insaneSpeed := fire(DifficultyInsane)
+	if want := int(math.Round(float64(normal.FireTimer) / 1.6)); insane.FireTimer != want {
+		t.Errorf("Insane fire timer = %d, want %d", insane.FireTimer, want)
+	}
+	if math.Abs(insaneSpeed-normalSpeed*1.4) > 1e-9 {
+		t.Errorf("Insane torpedo speed = %v, want %v", insaneSpeed, normalSpeed*1.4)
+	}
+}
+
+func TestReplay_DifficultyRoundTrip(t *testing.T) {
+	run := func(d Difficulty) *Game {
+		g := NewHeadlessGame(9)
+		g.Difficulty = d
+		g.Reset(9)
+		g.Ship.Input = NewAutopilot()
+		for i := 0; i < 600; i++ {
+			g.Step(g.PollInputs())
+		}
+		return g
+	}
+
+	rec := run(DifficultyHard)
+	data, _ := rec.Recording.MarshalBinary()
+	r, err := DecodeReplay(data)
+	if err != nil {
+		t.Fatalf("DecodeReplay() error = %v", err)
+	}
+	if r.Difficulty != DifficultyHard {
+		t.Fatalf("Difficulty = %v, want hard", r.Difficulty)
+	}
+	res := RunReplay(r)
+	if res.Hash != rec.Checksum() || res.Difficulty != DifficultyHard {
+		t.Errorf("Replay ended in %08x at %v, want %08x at hard", res.Hash, res.Difficulty, rec.Checksum())
+	}
+	if run(DifficultyNormal).Checksum() == rec.Checksum() {
+		t.Error("Sessions at different difficulties should not hash the same")
+	}
+
+	bad := append([]byte{}, data...)
+	bad[len(replayMagic)+1+4] = byte(DifficultyCount)
+	if _, err := DecodeReplay(bad); err != ErrReplayCorrupt {
+		t.Errorf("Unknown difficulty: error = %v, want %v", err, ErrReplayCorrupt)
+	}
+}
+
+func TestDifficulty_RoomID(t *testing.T) {
+	if got := DifficultyNormal.RoomID(DefaultRoomID); got != DefaultRoomID {
+		t.Errorf("Normal room = %q, want the default room", got)
+	}
+	if got := DifficultyInsane.RoomID(DefaultRoomID); got != DefaultRoomID+"-insane" {
+		t.Errorf("Insane room = %q, want its own room", got)
+	}
+	for d := Difficulty(0); d < DifficultyCount; d++ {
+		if ParseDifficulty(d.String()) != d {
+			t.Errorf("ParseDifficulty(%q) != %v", d.String(), d)
+		}
+	}
+}
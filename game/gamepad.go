@@ -0,0 +1,196 @@
+package game
+
+import "math"
+
+// Buttons and axes of the W3C "standard" gamepad mapping, which browsers
+// use for common controllers (Xbox, PlayStation and most USB pads).
+const (
+	GamepadY              = 3
+	GamepadLeftShoulder   = 4
+	GamepadRightShoulder  = 5
+	GamepadRightTrigger   = 7
+	GamepadStart          = 9
+	GamepadDpadUp         = 12
+	GamepadDpadDown       = 13
+	GamepadDpadLeft       = 14
+	GamepadDpadRight      = 15
+	GamepadAxisLeftStickX = 0
+	GamepadAxisLeftStickY = 1
+)
+
+// Gamepad tuning.
+const (
+	// GamepadDeadZone is the stick deflection ignored around the center, so
+	// worn sticks and resting thumbs do not make the ship drift. It applies
+	// to the touch joystick as well.
+	GamepadDeadZone = 0.15
+	// GamepadPressThreshold is the value at which analog buttons such as the
+	// triggers count as pressed.
+	GamepadPressThreshold = 0.5
+)
+
+// GamepadState is a snapshot of a gamepad as reported by the browser.
+// Button values range from 0 (released) to 1 (fully pressed), axes from -1
+// to 1 with up and left negative.
+type GamepadState struct {
+	Axes    []float64
+	Buttons []float64
+}
+
+// Axis returns axis i after applying the dead zone.
+func (gs GamepadState) Axis(i int) float64 {
+	if i >= len(gs.Axes) {
+		return 0
+	}
+	return applyDeadZone(gs.Axes[i])
+}
+
+// applyDeadZone zeroes stick deflections v within GamepadDeadZone of the
+// center and rescales the rest so that the output still covers [-1, 1].
+func applyDeadZone(v float64) float64 {
+	if math.Abs(v) < GamepadDeadZone {
+		return 0
+	}
+	return math.Copysign((math.Abs(v)-GamepadDeadZone)/(1-GamepadDeadZone), v)
+}
+
+// Pressed reports whether button i is held.
+func (gs GamepadState) Pressed(i int) bool {
+	return i < len(gs.Buttons) && gs.Buttons[i] >= GamepadPressThreshold
+}
+
+// GamepadInput reads the controls from a gamepad: the left stick rotates
+// and throttles, the right trigger fires, the left shoulder locks onto the
+// nearest enemy and the right shoulder cycles the lock to the next one. The
+// D-pad works like the arrow keys. The browser is polled once per frame and
+// the snapshot is fed to Update; Poll samples it once per tick.
+//
+// Controllers can be plugged in and out at any time. The first connected
+// one is used; when it disconnects the input returns to neutral.
+type GamepadInput struct {
+	Index int    // Browser gamepad index of the active controller, -1 if none
+	ID    string // Browser description of the active controller
+	state GamepadState
+	prev  GamepadState
+}
+
+// NewGamepadInput creates a gamepad source with no controller connected.
+func NewGamepadInput() *GamepadInput {
+	return &GamepadInput{Index: -1}
+}
+
+// Connected reports whether a controller is active.
+func (p *GamepadInput) Connected() bool {
+	return p.Index >= 0
+}
+
+// Connect makes the controller at index the active one unless another one
+// is already in use.
+func (p *GamepadInput) Connect(index int, id string) {
+	if p.Connected() {
+		return
+	}
+	p.Index = index
+	p.ID = id
+	p.state = GamepadState{}
+	p.prev = GamepadState{}
+}
+
+// Disconnect releases the controller at index if it is the active one.
+func (p *GamepadInput) Disconnect(index int) {
+	if index != p.Index {
+		return
+	}
+	p.Index = -1
+	p.ID = ""
+	p.state = GamepadState{}
+	p.prev = GamepadState{}
+}
+
+// Update stores the latest snapshot of the active controller.
+func (p *GamepadInput) Update(state GamepadState) {
+	p.prev = p.state
+	p.state = state
+}
+
+// JustPressed reports whether button i went down with the last Update.
+func (p *GamepadInput) JustPressed(i int) bool {
+	return p.state.Pressed(i) && !p.prev.Pressed(i)
+}
+
+// AnyJustPressed reports whether any button went down with the last Update.
+func (p *GamepadInput) AnyJustPressed() bool {
+	for i := range p.state.Buttons {
+		if p.JustPressed(i) {
+			return true
+		}
+	}
+	return false
+}
+
+// Poll implements InputSource.
+func (p *GamepadInput) Poll(g *Game, s *Ship) ControlState {
+	gs := p.state
+	c := ControlState{
+		Turn:   Analog(gs.Axis(GamepadAxisLeftStickX)),
+		Thrust: Analog(-gs.Axis(GamepadAxisLeftStickY)),
+	}
+
+	buttons := []struct {
+		button int
+		key    uint16
+	}{
+		{GamepadRightTrigger, KeyFire},
+		{GamepadLeftShoulder, KeyLock},
+		{GamepadRightShoulder, KeyNextTarget},
+		{GamepadY, KeySwitchWeapon},
+		{GamepadDpadLeft, KeyLeft},
+		{GamepadDpadRight, KeyRight},
+		{GamepadDpadUp, KeyUp},
+		{GamepadDpadDown, KeyDown},
+	}
+	for _, b := range buttons {
+		if gs.Pressed(b.button) {
+			c.Keys |= b.key
+		}
+	}
+	return c
+}
+
+// gamepadActions are the buttons that work the screens (see
+// HandleStateAction) like their keyboard actions.
+var gamepadActions = []struct {
+	button int
+	action Action
+}{
+	{GamepadStart, ActionPause},
+	{GamepadRightTrigger, ActionFire},
+	{GamepadDpadUp, ActionThrust},
+	{GamepadDpadDown, ActionReverse},
+	{GamepadDpadLeft, ActionRotateLeft},
+	{GamepadDpadRight, ActionRotateRight},
+}
+
+// UpdateGamepad feeds the browser's snapshot of the active controller to
+// the game once per frame. connected is false when the controller has gone
+// away. Start pauses like P, the D-pad picks the mode (up and down) and the
+// difficulty (left and right) on the title screen, the right trigger
+// restarts after a game over and any other button starts the game from the
+// title screen.
+func (g *Game) UpdateGamepad(state GamepadState, connected bool) {
+	if !connected {
+		g.Gamepad.Disconnect(g.Gamepad.Index)
+		return
+	}
+	g.Gamepad.Update(state)
+
+	for _, b := range gamepadActions {
+		if g.Gamepad.JustPressed(b.button) {
+			g.HandleStateAction(b.action, true)
+			return
+		}
+	}
+	if g.State == StateTitle && g.Gamepad.AnyJustPressed() {
+		g.HandleStateAction(0, false)
+	}
+}
@@ -4,7 +4,7 @@ import (
 	"math"
 	"strconv"
 
-	"github.com/gopherjs/gopherjs/js"
+	"github.com/simukka/starship-sorades-13k/render"
 )
 
 // maxInt returns the maximum of two integers.
@@ -15,196 +15,241 @@ func maxInt(a, b int) int {
 	return b
 }
 
-// RenderToCanvas creates an off-screen canvas and renders to it.
-func RenderToCanvas(width, height int, renderFn func(canvas, ctx *js.Object)) *js.Object {
-	document := js.Global.Get("document")
-	canvas := document.Call("createElement", "canvas")
-	canvas.Set("width", width)
-	canvas.Set("height", height)
-	ctx := canvas.Call("getContext", "2d")
-	renderFn(canvas, ctx)
-	return canvas
-}
-
 // InitializeGraphics renders all static game graphics.
 func (g *Game) InitializeGraphics() {
 	// Score digit sprites (0-9)
 	for i := 0; i < 10; i++ {
 		num := i // capture for closure
-		g.Level.Points.Images[i] = RenderToCanvas(g.Level.Points.Width, g.Level.Points.Height,
-			func(canvas, ctx *js.Object) {
-				ctx.Set("shadowBlur", Theme.DefaultShadowBlur)
-				ctx.Set("font", "bold "+strconv.Itoa(canvas.Get("width").Int()*13/10)+"px "+Theme.ScoreFont)
-				ctx.Set("textAlign", "center")
-				ctx.Set("textBaseline", "middle")
-				ctx.Set("lineWidth", 2)
-				ctx.Set("lineJoin", "round")
-				ctx.Set("shadowColor", Theme.ScoreGlow)
-				ctx.Call("strokeText", strconv.Itoa(num), canvas.Get("width").Int()/2, canvas.Get("height").Int()/2)
-				ctx.Set("strokeStyle", Theme.ScoreColor)
-				ctx.Call("strokeText", strconv.Itoa(num), canvas.Get("width").Int()/2, canvas.Get("height").Int()/2)
+		g.Level.Points.Images[i] = g.Ctx.NewImage(g.Level.Points.Width, g.Level.Points.Height,
+			func(ctx render.Surface) {
+				ctx.SetShadowBlur(Theme.DefaultShadowBlur)
+				ctx.SetFont("bold " + strconv.Itoa(ctx.Width()*13/10) + "px " + Theme.ScoreFont)
+				ctx.SetTextAlign("center")
+				ctx.SetTextBaseline("middle")
+				ctx.SetLineWidth(2)
+				ctx.SetLineJoin("round")
+				ctx.SetShadowColor(Theme.ScoreGlow)
+				ctx.StrokeText(strconv.Itoa(num), float64(ctx.Width()/2), float64(ctx.Height()/2), 0)
+				ctx.SetStrokeStyle(Theme.ScoreColor)
+				ctx.StrokeText(strconv.Itoa(num), float64(ctx.Width()/2), float64(ctx.Height()/2), 0)
 			})
 	}
 
 	// Background tile
-	g.Level.Background = RenderToCanvas(256, 256, func(canvas, ctx *js.Object) {
-		ctx.Set("fillStyle", Theme.BackgroundColor)
-		ctx.Call("fillRect", 0, 0, canvas.Get("width").Int(), canvas.Get("height").Int())
-		ctx.Set("globalCompositeOperation", "lighter")
-
-		ctx.Call("beginPath")
-		w := canvas.Get("width").Float()
-		h := canvas.Get("height").Float()
+	g.Level.Background = g.Ctx.NewImage(256, 256, func(ctx render.Surface) {
+		ctx.SetFillStyle(Theme.BackgroundColor)
+		ctx.FillRect(0, 0, float64(ctx.Width()), float64(ctx.Height()))
+		ctx.SetCompositeOperation("lighter")
+
+		ctx.BeginPath()
+		w := float64(ctx.Width())
+		h := float64(ctx.Height())
 		for i := 5; i >= 0; i-- {
 			fi := float64(i)
-			ctx.Call("moveTo", w*(fi+1)/4, -h)
-			ctx.Call("lineTo", w*(fi-2)/4, h*2)
-			ctx.Call("moveTo", -w, h*(fi-2)/4)
-			ctx.Call("lineTo", w*2, h*(fi+1)/4)
+			ctx.MoveTo(w*(fi+1)/4, -h)
+			ctx.LineTo(w*(fi-2)/4, h*2)
+			ctx.MoveTo(-w, h*(fi-2)/4)
+			ctx.LineTo(w*2, h*(fi+1)/4)
 		}
-		ctx.Set("lineWidth", 3)
-		ctx.Set("shadowBlur", Theme.DefaultShadowBlur)
-		ctx.Set("strokeStyle", Theme.BackgroundLineColor)
-		ctx.Set("shadowColor", Theme.BackgroundGlow)
-		ctx.Call("stroke")
-
-		ctx.Set("shadowBlur", 0)
-		ctx.Set("globalAlpha", 0.25)
-		ctx.Call("translate", w, 0)
-		ctx.Call("scale", -1, 1)
-		ctx.Call("drawImage", canvas, 0, 0)
+		ctx.SetLineWidth(3)
+		ctx.SetShadowBlur(Theme.DefaultShadowBlur)
+		ctx.SetStrokeStyle(Theme.BackgroundLineColor)
+		ctx.SetShadowColor(Theme.BackgroundGlow)
+		ctx.Stroke()
+
+		ctx.SetShadowBlur(0)
+		ctx.SetGlobalAlpha(0.25)
+		ctx.Translate(w, 0)
+		ctx.Scale(-1, 1)
+		ctx.DrawImage(ctx, 0, 0)
 	})
 
-	// Create repeating pattern from background tile
-	g.Level.BackgroundPattern = g.Ctx.Call("createPattern", g.Level.Background, "repeat")
-
 	// Player ship sprite
 	for _, s := range g.Ships {
-		s.Image = RenderToCanvas(ShipR, ShipR*2, func(canvas, ctx *js.Object) {
-			w := canvas.Get("width").Float()
-			h := canvas.Get("height").Float()
+		s.Image = g.Ctx.NewImage(ShipR, ShipR*2, func(ctx render.Surface) {
+			w := float64(ctx.Width())
+			h := float64(ctx.Height())
 
-			ctx.Call("beginPath")
+			ctx.BeginPath()
 			for i := 4; i >= 0; i-- {
 				fi := float64(i)
-				ctx.Call("moveTo", w/2, h*(1+fi)/10)
-				ctx.Call("lineTo", w*(11+fi)/16, h*(15-fi)/16)
-				ctx.Call("lineTo", w*(5-fi)/16, h*(15-fi)/16)
-				ctx.Call("closePath")
+				ctx.MoveTo(w/2, h*(1+fi)/10)
+				ctx.LineTo(w*(11+fi)/16, h*(15-fi)/16)
+				ctx.LineTo(w*(5-fi)/16, h*(15-fi)/16)
+				ctx.ClosePath()
 			}
-			lineWidth := int(w / 17)
-			ctx.Set("lineWidth", lineWidth)
-			ctx.Set("shadowBlur", lineWidth*2)
-			ctx.Set("strokeStyle", Theme.ShipColor)
-			ctx.Set("shadowColor", Theme.ShipGlow)
-			ctx.Call("stroke")
-			ctx.Call("stroke")
+			lineWidth := math.Floor(w / 17)
+			ctx.SetLineWidth(lineWidth)
+			ctx.SetShadowBlur(lineWidth * 2)
+			ctx.SetStrokeStyle(Theme.ShipColor)
+			ctx.SetShadowColor(Theme.ShipGlow)
+			ctx.Stroke()
+			ctx.Stroke()
 
 			// Center diamond
 			p := w / 6
-			ctx.Call("beginPath")
-			ctx.Call("moveTo", w/2-p, h/2)
-			ctx.Call("lineTo", w/2, h/2+p)
-			ctx.Call("lineTo", w/2+p, h/2)
-			ctx.Call("lineTo", w/2, h/2-p)
-			ctx.Call("closePath")
-			ctx.Set("strokeStyle", Theme.ShipCenterColor)
-			ctx.Set("shadowColor", Theme.ShipCenterColor)
-			ctx.Call("stroke")
-			ctx.Call("stroke")
+			ctx.BeginPath()
+			ctx.MoveTo(w/2-p, h/2)
+			ctx.LineTo(w/2, h/2+p)
+			ctx.LineTo(w/2+p, h/2)
+			ctx.LineTo(w/2, h/2-p)
+			ctx.ClosePath()
+			ctx.SetStrokeStyle(Theme.ShipCenterColor)
+			ctx.SetShadowColor(Theme.ShipCenterColor)
+			ctx.Stroke()
+			ctx.Stroke()
 		})
 
-		s.Shield.Image = RenderToCanvas(ShipR*2, ShipR*2, func(canvas, ctx *js.Object) {
-			w := canvas.Get("width").Float()
+		s.Shield.Image = g.Ctx.NewImage(ShipR*2, ShipR*2, func(ctx render.Surface) {
+			w := float64(ctx.Width())
 			d := 8.0
-			ctx.Set("lineWidth", 18)
-			ctx.Set("shadowBlur", Theme.ShieldShadowBlur)
-			ctx.Set("strokeStyle", Theme.ShieldColor)
-			ctx.Set("shadowColor", Theme.ShieldGlowColor)
-			ctx.Call("beginPath")
-			ctx.Call("arc", w/2, w/2, w/2+9-d, 0, math.Pi*2)
-			ctx.Call("stroke")
-
-			ctx.Set("lineWidth", 26+d)
-			ctx.Set("shadowBlur", 0)
-			ctx.Call("beginPath")
-			ctx.Call("arc", w/2, w/2, w/2+13+d/2-d, 0, math.Pi*2)
-			ctx.Call("stroke")
+			ctx.SetLineWidth(18)
+			ctx.SetShadowBlur(Theme.ShieldShadowBlur)
+			ctx.SetStrokeStyle(Theme.ShieldColor)
+			ctx.SetShadowColor(Theme.ShieldGlowColor)
+			ctx.BeginPath()
+			ctx.Arc(w/2, w/2, w/2+9-d, 0, math.Pi*2)
+			ctx.Stroke()
+
+			ctx.SetLineWidth(26 + d)
+			ctx.SetShadowBlur(0)
+			ctx.BeginPath()
+			ctx.Arc(w/2, w/2, w/2+13+d/2-d, 0, math.Pi*2)
+			ctx.Stroke()
 		})
 	}
 
 	// Bullet sprite
-	g.BulletImage = RenderToCanvas(BulletR*2, BulletR*2, func(canvas, ctx *js.Object) {
-		w := canvas.Get("width").Float()
-		h := canvas.Get("height").Float()
+	g.BulletImage = g.Ctx.NewImage(BulletR*2, BulletR*2, func(ctx render.Surface) {
+		w := float64(ctx.Width())
+		h := float64(ctx.Height())
 		p := 6.0
-		ctx.Call("beginPath")
-		ctx.Call("moveTo", w/2, p)
-		ctx.Call("lineTo", w-p, h/2)
-		ctx.Call("lineTo", w/2, h-p)
-		ctx.Call("lineTo", p, h/2)
-		ctx.Call("closePath")
-		ctx.Set("lineWidth", Theme.BulletLineWidth)
-		ctx.Set("shadowBlur", Theme.BulletShadowBlur)
-		ctx.Set("strokeStyle", Theme.BulletColor)
-		ctx.Set("shadowColor", Theme.BulletGlow)
-		ctx.Call("stroke")
-		ctx.Call("stroke")
+		ctx.BeginPath()
+		ctx.MoveTo(w/2, p)
+		ctx.LineTo(w-p, h/2)
+		ctx.LineTo(w/2, h-p)
+		ctx.LineTo(p, h/2)
+		ctx.ClosePath()
+		ctx.SetLineWidth(Theme.BulletLineWidth)
+		ctx.SetShadowBlur(Theme.BulletShadowBlur)
+		ctx.SetStrokeStyle(Theme.BulletColor)
+		ctx.SetShadowColor(Theme.BulletGlow)
+		ctx.Stroke()
+		ctx.Stroke()
 	})
 
 	// Explosion sprite
-	g.ExplosionImage = RenderToCanvas(16, 16, func(canvas, ctx *js.Object) {
-		w := canvas.Get("width").Float()
-		h := canvas.Get("height").Float()
-		ctx.Set("fillStyle", Theme.ExplosionColor)
-		ctx.Set("shadowBlur", Theme.ExplosionShadowBlur)
-		ctx.Set("shadowColor", Theme.ExplosionGlow)
+	g.ExplosionImage = g.Ctx.NewImage(16, 16, func(ctx render.Surface) {
+		w := float64(ctx.Width())
+		h := float64(ctx.Height())
+		ctx.SetFillStyle(Theme.ExplosionColor)
+		ctx.SetShadowBlur(Theme.ExplosionShadowBlur)
+		ctx.SetShadowColor(Theme.ExplosionGlow)
 		p := 6.0
 
 		for i := 0; i < 5; i++ {
-			ctx.Call("fillRect", p, p, w-p*2, h-p*2)
+			ctx.FillRect(p, p, w-p*2, h-p*2)
 		}
 
-		ctx.Set("lineWidth", 0.3)
-		ctx.Set("strokeStyle", Theme.ExplosionLineColor)
+		ctx.SetLineWidth(0.3)
+		ctx.SetStrokeStyle(Theme.ExplosionLineColor)
 		pp := p * 0.8
-		ctx.Call("beginPath")
-		ctx.Call("moveTo", pp, pp)
-		ctx.Call("lineTo", w-pp, h-pp)
-		ctx.Call("moveTo", w-pp, pp)
-		ctx.Call("lineTo", pp, h-pp)
-		ctx.Call("stroke")
+		ctx.BeginPath()
+		ctx.MoveTo(pp, pp)
+		ctx.LineTo(w-pp, h-pp)
+		ctx.MoveTo(w-pp, pp)
+		ctx.LineTo(pp, h-pp)
+		ctx.Stroke()
 	})
 
 	// Torpedo animation frames
-	frameCount := 8
-	g.TorpedoImages = make([]*js.Object, frameCount)
-	for i := 0; i < frameCount; i++ {
+	g.TorpedoImages = make([]render.Image, TorpedoFrameCount)
+	for i := 0; i < TorpedoFrameCount; i++ {
 		idx := i // capture
-		g.TorpedoImages[i] = RenderToCanvas(TorpedoR*2, TorpedoR*2, func(canvas, ctx *js.Object) {
-			w := canvas.Get("width").Float()
-			h := canvas.Get("height").Float()
+		g.TorpedoImages[i] = g.Ctx.NewImage(TorpedoR*2, TorpedoR*2, func(ctx render.Surface) {
+			w := float64(ctx.Width())
+			h := float64(ctx.Height())
 
-			ctx.Call("translate", w/2, h/2)
-			ctx.Call("rotate", math.Pi/-2*float64(idx)/float64(frameCount))
-			ctx.Call("translate", -w/2, -h/2)
+			ctx.Translate(w/2, h/2)
+			ctx.Rotate(math.Pi / -2 * float64(idx) / float64(TorpedoFrameCount))
+			ctx.Translate(-w/2, -h/2)
 
 			p := 6.0
-			ctx.Call("beginPath")
-			ctx.Set("lineWidth", Theme.TorpedoLineWidth)
-			ctx.Set("shadowBlur", Theme.DefaultShadowBlur)
-			ctx.Set("strokeStyle", Theme.TorpedoColor)
-			ctx.Set("shadowColor", Theme.TorpedoGlow)
-			ctx.Call("moveTo", w/2, p)
-			ctx.Call("lineTo", w-p, h/2)
-			ctx.Call("lineTo", w/2, h-p)
-			ctx.Call("lineTo", p, h/2)
-			ctx.Call("closePath")
-			ctx.Call("stroke")
-			ctx.Call("stroke")
+			ctx.BeginPath()
+			ctx.SetLineWidth(Theme.TorpedoLineWidth)
+			ctx.SetShadowBlur(Theme.DefaultShadowBlur)
+			ctx.SetStrokeStyle(Theme.TorpedoColor)
+			ctx.SetShadowColor(Theme.TorpedoGlow)
+			ctx.MoveTo(w/2, p)
+			ctx.LineTo(w-p, h/2)
+			ctx.LineTo(w/2, h-p)
+			ctx.LineTo(p, h/2)
+			ctx.ClosePath()
+			ctx.Stroke()
+			ctx.Stroke()
 		})
 	}
 
+	// Mine: a spiked ring around a red core
+	g.MineImage = g.Ctx.NewImage(MineR*2, MineR*2, func(ctx render.Surface) {
+		w := float64(ctx.Width())
+		c := w / 2
+
+		ctx.SetLineWidth(Theme.TorpedoLineWidth)
+		ctx.SetShadowBlur(Theme.DefaultShadowBlur)
+		ctx.SetStrokeStyle(Theme.MineColor)
+		ctx.SetShadowColor(Theme.MineGlow)
+		ctx.BeginPath()
+		ctx.Arc(c, c, c*0.5, 0, math.Pi*2)
+		for i := 0.0; i < math.Pi*2; i += math.Pi / 3 {
+			ctx.MoveTo(c+math.Sin(i)*c*0.5, c+math.Cos(i)*c*0.5)
+			ctx.LineTo(c+math.Sin(i)*(c-4), c+math.Cos(i)*(c-4))
+		}
+		ctx.Stroke()
+
+		ctx.SetFillStyle(Theme.MineColor)
+		ctx.BeginPath()
+		ctx.Arc(c, c, c*0.2, 0, math.Pi*2)
+		ctx.Fill()
+	})
+
+	// Missile: a slim dart pointing up, turned along its heading when drawn
+	g.MissileImage = g.Ctx.NewImage(MissileR*2, MissileR*2, func(ctx render.Surface) {
+		w := float64(ctx.Width())
+		c := w / 2
+
+		ctx.SetLineWidth(Theme.BulletLineWidth)
+		ctx.SetShadowBlur(Theme.BulletShadowBlur)
+		ctx.SetStrokeStyle(Theme.MissileColor)
+		ctx.SetShadowColor(Theme.MissileGlow)
+		ctx.BeginPath()
+		ctx.MoveTo(c, 3)
+		ctx.LineTo(c+c*0.4, w-4)
+		ctx.LineTo(c, w-c*0.5)
+		ctx.LineTo(c-c*0.4, w-4)
+		ctx.ClosePath()
+		ctx.Stroke()
+	})
+
+	// Charge shot: a glowing orb with a hot core
+	g.ChargeImage = g.Ctx.NewImage(ChargeR*2, ChargeR*2, func(ctx render.Surface) {
+		w := float64(ctx.Width())
+		c := w / 2
+
+		ctx.SetLineWidth(Theme.BulletLineWidth)
+		ctx.SetShadowBlur(Theme.ShieldShadowBlur)
+		ctx.SetStrokeStyle(Theme.ChargeColor)
+		ctx.SetShadowColor(Theme.ChargeGlow)
+		ctx.BeginPath()
+		ctx.Arc(c, c, c*0.6, 0, math.Pi*2)
+		ctx.Stroke()
+
+		ctx.SetFillStyle(Theme.ChargeColor)
+		ctx.BeginPath()
+		ctx.Arc(c, c, c*0.3, 0, math.Pi*2)
+		ctx.Fill()
+	})
+
 	// Enemy sprites
 	g.InitializeEnemyGraphics()
 
@@ -218,16 +263,16 @@ func (g *Game) InitializeEnemyGraphics() {
 	// Enemy type 0 - small fighter (1984-1998 Rainbow Apple era)
 	g.EnemyTypes[SmallFighter] = EnemyType{
 		R: r,
-		Image: RenderToCanvas(int(r*2), int(r*2), func(canvas, ctx *js.Object) {
-			w := canvas.Get("width").Float()
-			h := canvas.Get("height").Float()
+		Image: g.Ctx.NewImage(int(r*2), int(r*2), func(ctx render.Surface) {
+			w := float64(ctx.Width())
+			h := float64(ctx.Height())
 
-			ctx.Set("lineWidth", Theme.EnemyLineWidth)
-			ctx.Set("shadowBlur", Theme.DefaultShadowBlur)
-			ctx.Set("strokeStyle", Theme.EnemySmallColor)
-			ctx.Set("shadowColor", Theme.EnemySmallGlow)
-			ctx.Set("miterLimit", 128)
-			ctx.Call("beginPath")
+			ctx.SetLineWidth(Theme.EnemyLineWidth)
+			ctx.SetShadowBlur(Theme.DefaultShadowBlur)
+			ctx.SetStrokeStyle(Theme.EnemySmallColor)
+			ctx.SetShadowColor(Theme.EnemySmallGlow)
+			ctx.SetMiterLimit(128)
+			ctx.BeginPath()
 
 			for i := 4; i >= 0; i-- {
 				fi := float64(i)
@@ -236,16 +281,16 @@ func (g *Game) InitializeEnemyGraphics() {
 				x2 := w * (11 - fi) / 26
 				y2 := h * (1 + fi) / 9
 
-				ctx.Call("moveTo", w/2, h*(12-fi)/12-6)
-				ctx.Call("lineTo", w-x1, y1)
-				ctx.Call("lineTo", w-x2, y2)
-				ctx.Call("lineTo", x2, y2)
-				ctx.Call("lineTo", x1, y1)
-				ctx.Call("closePath")
+				ctx.MoveTo(w/2, h*(12-fi)/12-6)
+				ctx.LineTo(w-x1, y1)
+				ctx.LineTo(w-x2, y2)
+				ctx.LineTo(x2, y2)
+				ctx.LineTo(x1, y1)
+				ctx.ClosePath()
 			}
 
-			ctx.Call("stroke")
-			ctx.Call("stroke")
+			ctx.Stroke()
+			ctx.Stroke()
 			renderHeart(ctx, w/2, h/2, r)
 		}),
 	}
@@ -253,14 +298,14 @@ func (g *Game) InitializeEnemyGraphics() {
 	// Enemy type 1 - medium fighter (1998-2001 Bondi Blue iMac era)
 	g.EnemyTypes[MediumFighter] = EnemyType{
 		R: r,
-		Image: RenderToCanvas(int(r*2), int(r*2), func(canvas, ctx *js.Object) {
-			w := canvas.Get("width").Float()
-			h := canvas.Get("height").Float()
+		Image: g.Ctx.NewImage(int(r*2), int(r*2), func(ctx render.Surface) {
+			w := float64(ctx.Width())
+			h := float64(ctx.Height())
 
-			ctx.Set("lineWidth", Theme.EnemyLineWidth)
-			ctx.Set("shadowBlur", Theme.DefaultShadowBlur)
-			ctx.Set("strokeStyle", Theme.EnemyMediumColor)
-			ctx.Set("shadowColor", Theme.EnemyMediumGlow)
+			ctx.SetLineWidth(Theme.EnemyLineWidth)
+			ctx.SetShadowBlur(Theme.DefaultShadowBlur)
+			ctx.SetStrokeStyle(Theme.EnemyMediumColor)
+			ctx.SetShadowColor(Theme.EnemyMediumGlow)
 
 			for i := 4; i >= 0; i-- {
 				fi := float64(i)
@@ -269,16 +314,16 @@ func (g *Game) InitializeEnemyGraphics() {
 				x2 := w * (8 - fi) / 22
 				y2 := h * (1 + fi) / 11
 
-				ctx.Call("moveTo", w/2, h*(6-fi)/12)
-				ctx.Call("lineTo", w-x1, y1)
-				ctx.Call("lineTo", w-x2, y2)
-				ctx.Call("lineTo", x2, y2)
-				ctx.Call("lineTo", x1, y1)
-				ctx.Call("closePath")
+				ctx.MoveTo(w/2, h*(6-fi)/12)
+				ctx.LineTo(w-x1, y1)
+				ctx.LineTo(w-x2, y2)
+				ctx.LineTo(x2, y2)
+				ctx.LineTo(x1, y1)
+				ctx.ClosePath()
 			}
 
-			ctx.Call("stroke")
-			ctx.Call("stroke")
+			ctx.Stroke()
+			ctx.Stroke()
 			renderHeart(ctx, w/2, h/2, r)
 		}),
 	}
@@ -286,15 +331,15 @@ func (g *Game) InitializeEnemyGraphics() {
 	// Enemy type 2 - turret (2001-2007 iPod era - white/chrome)
 	g.EnemyTypes[TurretFighter] = EnemyType{
 		R: r,
-		Image: RenderToCanvas(int(r*2), int(r*2), func(canvas, ctx *js.Object) {
-			w := canvas.Get("width").Float()
+		Image: g.Ctx.NewImage(int(r*2), int(r*2), func(ctx render.Surface) {
+			w := float64(ctx.Width())
 
-			ctx.Set("lineWidth", Theme.EnemyLineWidth)
-			ctx.Set("shadowBlur", Theme.DefaultShadowBlur)
-			ctx.Set("strokeStyle", Theme.EnemyTurretColor)
-			ctx.Set("shadowColor", Theme.EnemyTurretGlow)
-			ctx.Set("miterLimit", 32)
-			ctx.Call("beginPath")
+			ctx.SetLineWidth(Theme.EnemyLineWidth)
+			ctx.SetShadowBlur(Theme.DefaultShadowBlur)
+			ctx.SetStrokeStyle(Theme.EnemyTurretColor)
+			ctx.SetShadowColor(Theme.EnemyTurretGlow)
+			ctx.SetMiterLimit(32)
+			ctx.BeginPath()
 
 			// Outer spiky ring
 			for i := 0.0; i < math.Pi*2; i += math.Pi / 4 {
@@ -304,15 +349,15 @@ func (g *Game) InitializeEnemyGraphics() {
 				y := w/2 + math.Cos(i+d)*rr
 
 				if i == 0 {
-					ctx.Call("moveTo", x, y)
+					ctx.MoveTo(x, y)
 				} else {
-					ctx.Call("lineTo", x, y)
+					ctx.LineTo(x, y)
 				}
 
 				d -= math.Pi / 1.45
-				ctx.Call("lineTo", w/2+math.Sin(i+d)*rr, w/2+math.Cos(i+d)*rr)
+				ctx.LineTo(w/2+math.Sin(i+d)*rr, w/2+math.Cos(i+d)*rr)
 			}
-			ctx.Call("closePath")
+			ctx.ClosePath()
 
 			// Inner octagon
 			for i := 0.0; i < math.Pi*2; i += math.Pi / 4 {
@@ -321,15 +366,15 @@ func (g *Game) InitializeEnemyGraphics() {
 				y := w/2 + math.Cos(i)*rr
 
 				if i == 0 {
-					ctx.Call("moveTo", x, y)
+					ctx.MoveTo(x, y)
 				} else {
-					ctx.Call("lineTo", x, y)
+					ctx.LineTo(x, y)
 				}
 			}
-			ctx.Call("closePath")
+			ctx.ClosePath()
 
-			ctx.Call("stroke")
-			ctx.Call("stroke")
+			ctx.Stroke()
+			ctx.Stroke()
 			renderHeart(ctx, w/2, w/2, r)
 		}),
 	}
@@ -338,120 +383,218 @@ func (g *Game) InitializeEnemyGraphics() {
 	bossR := float64(maxInt(WIDTH, HEIGHT) / 8)
 	g.EnemyTypes[Boss] = EnemyType{
 		R: bossR,
-		Image: RenderToCanvas(int(bossR*2), int(bossR*2), func(canvas, ctx *js.Object) {
-			w := canvas.Get("width").Float()
-			h := canvas.Get("height").Float()
+		Image: g.Ctx.NewImage(int(bossR*2), int(bossR*2), func(ctx render.Surface) {
+			w := float64(ctx.Width())
+			h := float64(ctx.Height())
 
-			ctx.Set("lineWidth", Theme.EnemyLineWidth*1.5)
-			ctx.Set("shadowBlur", Theme.DefaultShadowBlur*2)
-			ctx.Set("strokeStyle", Theme.EnemyBossColor)
-			ctx.Set("shadowColor", Theme.EnemyBossAccent)
-			ctx.Set("miterLimit", 32)
-			ctx.Call("beginPath")
+			ctx.SetLineWidth(Theme.EnemyLineWidth * 1.5)
+			ctx.SetShadowBlur(Theme.DefaultShadowBlur * 2)
+			ctx.SetStrokeStyle(Theme.EnemyBossColor)
+			ctx.SetShadowColor(Theme.EnemyBossAccent)
+			ctx.SetMiterLimit(32)
+			ctx.BeginPath()
 
 			for i := 6; i >= 0; i-- {
 				fi := float64(i)
-				ctx.Call("moveTo", w/2, h*fi/12+6)
+				ctx.MoveTo(w/2, h*fi/12+6)
 				x1 := w*(11+fi)/18 - 6
 				y1 := h * (25 - fi) / 28
-				ctx.Call("lineTo", x1, y1)
+				ctx.LineTo(x1, y1)
 				x2 := w*(16-fi)/16 - 6
 				y2 := h * (fi + 4) / 28
-				ctx.Call("lineTo", x2, y2)
-				ctx.Call("lineTo", w/2, h*(fi+30)/36-6)
-				ctx.Call("lineTo", w-x2, y2)
-				ctx.Call("lineTo", w-x1, y1)
-				ctx.Call("closePath")
+				ctx.LineTo(x2, y2)
+				ctx.LineTo(w/2, h*(fi+30)/36-6)
+				ctx.LineTo(w-x2, y2)
+				ctx.LineTo(w-x1, y1)
+				ctx.ClosePath()
 			}
 
-			ctx.Call("stroke")
-			ctx.Call("stroke")
+			ctx.Stroke()
+			ctx.Stroke()
 			renderHeart(ctx, w/2, h*0.8, bossR)
 		}),
 	}
+
+	// Kamikaze - a narrow dart (2014 Apple Watch Edition gold)
+	g.EnemyTypes[Kamikaze] = EnemyType{
+		R: KamikazeR,
+		Image: g.Ctx.NewImage(int(KamikazeR*2), int(KamikazeR*2), func(ctx render.Surface) {
+			w := float64(ctx.Width())
+			h := float64(ctx.Height())
+
+			ctx.SetLineWidth(Theme.EnemyLineWidth)
+			ctx.SetShadowBlur(Theme.DefaultShadowBlur)
+			ctx.SetStrokeStyle(Theme.EnemyKamikazeColor)
+			ctx.SetShadowColor(Theme.EnemyKamikazeGlow)
+			ctx.SetMiterLimit(32)
+			ctx.BeginPath()
+
+			for i := 2; i >= 0; i-- {
+				fi := float64(i)
+				ctx.MoveTo(w/2, h-6-fi*6)
+				ctx.LineTo(w*(4-fi)/5-3, h*(1+fi)/8+6)
+				ctx.LineTo(w/2, h*(2+fi)/6)
+				ctx.LineTo(w-w*(4-fi)/5+3, h*(1+fi)/8+6)
+				ctx.ClosePath()
+			}
+
+			ctx.Stroke()
+			ctx.Stroke()
+			renderHeart(ctx, w/2, h/2, KamikazeR)
+		}),
+	}
+
+	// Mine layer - a hexagon with a bay (2019 Midnight Green)
+	g.EnemyTypes[MineLayer] = EnemyType{
+		R: r,
+		Image: g.Ctx.NewImage(int(r*2), int(r*2), func(ctx render.Surface) {
+			w := float64(ctx.Width())
+			c := w / 2
+
+			ctx.SetLineWidth(Theme.EnemyLineWidth)
+			ctx.SetShadowBlur(Theme.DefaultShadowBlur)
+			ctx.SetStrokeStyle(Theme.EnemyMineLayerColor)
+			ctx.SetShadowColor(Theme.EnemyMineLayerGlow)
+			ctx.BeginPath()
+
+			// Outer hexagon
+			for i := 0; i < 6; i++ {
+				a := math.Pi / 3 * float64(i)
+				x := c + math.Sin(a)*(c-6)
+				y := c + math.Cos(a)*(c-6)
+				if i == 0 {
+					ctx.MoveTo(x, y)
+				} else {
+					ctx.LineTo(x, y)
+				}
+			}
+			ctx.ClosePath()
+
+			// Mine bay
+			ctx.MoveTo(c+c*0.3, c)
+			ctx.Arc(c, c, c*0.3, 0, math.Pi*2)
+
+			ctx.Stroke()
+			ctx.Stroke()
+			renderHeart(ctx, c, c*0.5, r)
+		}),
+	}
+
+	// Carrier - a wide hull with a flight deck (2021 Sierra Blue)
+	g.EnemyTypes[Carrier] = EnemyType{
+		R: CarrierR,
+		Image: g.Ctx.NewImage(int(CarrierR*2), int(CarrierR*2), func(ctx render.Surface) {
+			w := float64(ctx.Width())
+			h := float64(ctx.Height())
+
+			ctx.SetLineWidth(Theme.EnemyLineWidth)
+			ctx.SetShadowBlur(Theme.DefaultShadowBlur)
+			ctx.SetStrokeStyle(Theme.EnemyCarrierColor)
+			ctx.SetShadowColor(Theme.EnemyCarrierGlow)
+			ctx.SetMiterLimit(32)
+			ctx.BeginPath()
+
+			// Hull
+			ctx.MoveTo(w/2, h-6)
+			ctx.LineTo(w-6, h*0.6)
+			ctx.LineTo(w-w/5, h/6)
+			ctx.LineTo(w/5, h/6)
+			ctx.LineTo(6, h*0.6)
+			ctx.ClosePath()
+
+			// Flight deck
+			for i := 0; i < 3; i++ {
+				fi := float64(i)
+				ctx.MoveTo(w*(3+fi)/9, h/4)
+				ctx.LineTo(w*(3+fi)/9, h*0.55)
+			}
+
+			ctx.Stroke()
+			ctx.Stroke()
+			renderHeart(ctx, w/2, h*0.7, CarrierR)
+		}),
+	}
 }
 
 // RenderBonusImage renders a bonus item sprite.
 func (g *Game) RenderBonusImage(bonusType string) {
-	g.BonusImages[bonusType] = RenderToCanvas(BonusR*2, BonusR*2, func(canvas, ctx *js.Object) {
-		w := canvas.Get("width").Float()
-		h := canvas.Get("height").Float()
+	g.BonusImages[bonusType] = g.Ctx.NewImage(BonusR*2, BonusR*2, func(ctx render.Surface) {
+		w := float64(ctx.Width())
+		h := float64(ctx.Height())
 
-		ctx.Set("shadowBlur", Theme.DefaultShadowBlur)
+		ctx.SetShadowBlur(Theme.DefaultShadowBlur)
+		color := Theme.BonusColorPowerup
 		if len(bonusType) > 1 {
-			ctx.Set("fillStyle", Theme.BonusColorPoints)
-		} else {
-			ctx.Set("fillStyle", Theme.BonusColorPowerup)
+			color = Theme.BonusColorPoints
 		}
-		ctx.Set("shadowColor", ctx.Get("fillStyle"))
-		ctx.Call("arc", w/2, h/2, w/2-6, 0, math.Pi*2)
-		ctx.Call("fill")
+		ctx.SetFillStyle(color)
+		ctx.SetShadowColor(color)
+		ctx.Arc(w/2, h/2, w/2-6, 0, math.Pi*2)
+		ctx.Fill()
 
-		ctx.Set("fillStyle", Theme.BonusTextColor)
+		ctx.SetFillStyle(Theme.BonusTextColor)
 		fontSize := int(w/1.8) - len(bonusType)*7 + 7
-		ctx.Set("font", "bold "+strconv.Itoa(fontSize)+"px "+Theme.BonusFont)
-		ctx.Set("textAlign", "center")
-		ctx.Set("textBaseline", "middle")
-		ctx.Call("fillText", bonusType, w/2, h/2)
+		ctx.SetFont("bold " + strconv.Itoa(fontSize) + "px " + Theme.BonusFont)
+		ctx.SetTextAlign("center")
+		ctx.SetTextBaseline("middle")
+		ctx.FillText(bonusType, w/2, h/2, 0)
 	})
 }
 
 // RenderTextImage renders a text message image.
 func (g *Game) RenderTextImage(text string) {
-	width := WIDTH * 10 / 16
-	height := WIDTH / 8
-	g.Level.Text.Image = RenderToCanvas(width, height, func(canvas, ctx *js.Object) {
-		w := canvas.Get("width").Float()
-		h := canvas.Get("height").Float()
+	g.Level.Text.Image = g.Ctx.NewImage(TextImageWidth, TextImageHeight, func(ctx render.Surface) {
+		w := float64(ctx.Width())
+		h := float64(ctx.Height())
 
 		shadowBlur := h / 10
-		ctx.Set("shadowBlur", shadowBlur)
+		ctx.SetShadowBlur(shadowBlur)
 		fontSize := int(h*0.9 - shadowBlur*2)
-		ctx.Set("font", "bold "+strconv.Itoa(fontSize)+"px "+Theme.TextFont)
-		ctx.Set("textAlign", "center")
-		ctx.Set("textBaseline", "middle")
+		ctx.SetFont("bold " + strconv.Itoa(fontSize) + "px " + Theme.TextFont)
+		ctx.SetTextAlign("center")
+		ctx.SetTextBaseline("middle")
 
 		maxWidth := w - shadowBlur*2
 		centerX := w / 2
 		centerY := h / 2
 
 		// Outer glow
-		ctx.Set("fillStyle", Theme.TextPrimaryColor)
-		ctx.Set("shadowColor", Theme.TextGlow)
-		ctx.Call("fillText", text, centerX, centerY, maxWidth)
-		ctx.Call("fillText", text, centerX, centerY, maxWidth)
+		ctx.SetFillStyle(Theme.TextPrimaryColor)
+		ctx.SetShadowColor(Theme.TextGlow)
+		ctx.FillText(text, centerX, centerY, maxWidth)
+		ctx.FillText(text, centerX, centerY, maxWidth)
 
 		// Inner stroke
-		ctx.Set("fillStyle", Theme.TextSecondaryColor)
-		ctx.Set("shadowBlur", shadowBlur/4)
-		ctx.Set("lineWidth", shadowBlur/4)
-		ctx.Set("lineJoin", "round")
-		ctx.Set("strokeStyle", Theme.TextSecondaryColor)
-		ctx.Set("shadowColor", Theme.BackgroundColor)
-		ctx.Call("strokeText", text, centerX, centerY, maxWidth)
+		ctx.SetFillStyle(Theme.TextSecondaryColor)
+		ctx.SetShadowBlur(shadowBlur / 4)
+		ctx.SetLineWidth(shadowBlur / 4)
+		ctx.SetLineJoin("round")
+		ctx.SetStrokeStyle(Theme.TextSecondaryColor)
+		ctx.SetShadowColor(Theme.BackgroundColor)
+		ctx.StrokeText(text, centerX, centerY, maxWidth)
 
 		// Scanline effect
-		ctx.Set("globalAlpha", 0.2)
-		ctx.Set("globalCompositeOperation", "source-atop")
-		ctx.Set("fillStyle", Theme.TextScanlineColor)
+		ctx.SetGlobalAlpha(0.2)
+		ctx.SetCompositeOperation("source-atop")
+		ctx.SetFillStyle(Theme.TextScanlineColor)
 		for i := 0.0; i < h; i += 3 {
-			ctx.Call("fillRect", 0, i, w, 1)
+			ctx.FillRect(0, i, w, 1)
 		}
 	})
 }
 
 // renderHeart renders the diamond-shaped heart indicator.
-func renderHeart(ctx *js.Object, x, y, r float64) {
+func renderHeart(ctx render.Renderer, x, y, r float64) {
 	p := r / 6
 
-	ctx.Call("beginPath")
-	ctx.Call("moveTo", x-p, y)
-	ctx.Call("lineTo", x, y+p)
-	ctx.Call("lineTo", x+p, y)
-	ctx.Call("lineTo", x, y-p)
-	ctx.Call("closePath")
+	ctx.BeginPath()
+	ctx.MoveTo(x-p, y)
+	ctx.LineTo(x, y+p)
+	ctx.LineTo(x+p, y)
+	ctx.LineTo(x, y-p)
+	ctx.ClosePath()
 
-	ctx.Set("globalCompositeOperation", "lighter")
-	ctx.Set("shadowColor", Theme.ShipCenterColor)
-	ctx.Call("stroke")
+	ctx.SetCompositeOperation("lighter")
+	ctx.SetShadowColor(Theme.ShipCenterColor)
+	ctx.Stroke()
 }
@@ -1,76 +1,120 @@
 package game
 
 import (
+	"encoding/json"
+
 	"github.com/gopherjs/gopherjs/js"
 )
 
-// KeyMap maps alternative keys to canonical control codes.
-var KeyMap = map[int]int{
-	27: 80, // Esc => P
-	32: 88, // Space => X
-	48: 88, // 0 => X
-	50: 40, // 2 => Down
-	52: 37, // 4 => Left
-	53: 40, // 5 => Down
-	54: 39, // 6 => Right
-	56: 38, // 8 => Up
-	65: 37, // A => Left
-	67: 88, // C => X
-	68: 39, // D => Right
-	73: 38, // I => Up
-	74: 37, // J => Left
-	75: 40, // K => Down
-	76: 39, // L => Right
-	83: 40, // S => Down
-	87: 38, // W => Up
-	89: 88, // Y => X
-	90: 88, // Z => X
+// bindingsStorageKey is the localStorage key of the saved key bindings.
+const bindingsStorageKey = "sorades.bindings"
+
+// LoadBindings replaces the keyboard bindings with the ones saved in
+// localStorage, if any. Storage can be unavailable (private browsing,
+// blocked cookies), in which case the defaults stay.
+func (g *Game) LoadBindings() {
+	defer func() {
+		if r := recover(); r != nil {
+			DebugWarn("Key bindings not loaded:", r)
+		}
+	}()
+
+	saved := js.Global.Get("localStorage").Call("getItem", bindingsStorageKey)
+	if saved == nil || saved == js.Undefined {
+		return
+	}
+	var b Bindings
+	if err := json.Unmarshal([]byte(saved.String()), &b); err != nil || len(b) == 0 {
+		return
+	}
+	g.Keyboard.Bindings = b
 }
 
-// TranslateKeyCode converts alternative key codes to canonical control codes.
-func TranslateKeyCode(keyCode int) int {
-	if mapped, ok := KeyMap[keyCode]; ok {
-		return mapped
+// SaveBindings stores the keyboard bindings in localStorage.
+func (g *Game) SaveBindings() {
+	defer func() {
+		if r := recover(); r != nil {
+			DebugWarn("Key bindings not saved:", r)
+		}
+	}()
+
+	data, err := json.Marshal(g.Keyboard.Bindings)
+	if err != nil {
+		return
 	}
-	return keyCode
+	js.Global.Get("localStorage").Call("setItem", bindingsStorageKey, string(data))
 }
 
-// SetupInputHandlers initializes keyboard event handlers.
+// SetupInputHandlers initializes keyboard, gamepad and touch event handlers.
 func (g *Game) SetupInputHandlers() {
+	g.LoadBindings()
+
 	// Keydown handler
 	js.Global.Get("document").Call("addEventListener", "keydown",
 		func(event *js.Object) {
-			keyCode := TranslateKeyCode(event.Get("keyCode").Int())
-			g.Keys[keyCode] = true
+			keyCode := event.Get("keyCode").Int()
 
-			// Stats overlay toggle (F10 = 121)
-			if keyCode == 121 {
-				g.StatsOverlay.Toggle()
+			// The rebinding screen takes every key while it is open
+			if g.BindingsScreen.Visible {
+				if g.BindingsScreen.HandleKey(g.Keyboard.Bindings, keyCode) {
+					g.SaveBindings()
+				}
 				event.Call("preventDefault")
 				return
 			}
 
-			// Pause toggle (P = 80, also mapped from Esc = 27)
-			if keyCode == 80 {
-				g.Ship.Paused = !g.Ship.Paused
+			action, bound := g.Keyboard.Bindings.Lookup(keyCode)
+
+			// So does the shop, which also closes with its own key
+			if g.Shop.Visible {
+				if bound && action == ActionShop {
+					g.Shop.Visible = false
+				} else {
+					g.Shop.HandleKey(keyCode)
+				}
 				event.Call("preventDefault")
 				return
 			}
 
-			// Prevent default for game keys
-			if keyCode >= 37 && keyCode <= 40 || keyCode == 88 {
+			g.Keyboard.KeyDown(keyCode)
+
+			// Stats overlay toggle
+			if bound && action == ActionStats {
+				g.StatsOverlay.Toggle()
 				event.Call("preventDefault")
+				return
 			}
 
-			// Target lock on 'T' key (84)
-			if keyCode == 84 {
-				g.Ship.InitiateTargetLock(g)
+			// The title, pause and game over screens come first
+			if g.HandleStateAction(action, bound) {
 				event.Call("preventDefault")
 				return
 			}
 
-			// Fullscreen toggle on 'F' key (70)
-			if keyCode == 70 {
+			// Unbound keys keep their browser function
+			if !bound {
+				return
+			}
+			event.Call("preventDefault")
+
+			// Replay playback controls take over while a replay runs
+			if g.Playback != nil && g.Playback.HandleAction(action) {
+				return
+			}
+
+			switch action {
+			case ActionMouseSteering:
+				g.Mouse.ToggleSteering()
+			case ActionBindings:
+				g.Keyboard.ReleaseAll()
+				g.BindingsScreen.Open()
+			case ActionShop:
+				if g.OpenShop() {
+					g.Keyboard.ReleaseAll()
+				}
+			case ActionRadar:
+				g.Radar.Cycle()
+			case ActionFullscreen:
 				canvas := js.Global.Get("document").Call("getElementById", "c")
 				if canvas.Get("requestFullscreen") != nil && canvas.Get("requestFullscreen") != js.Undefined {
 					canvas.Call("requestFullscreen")
@@ -80,12 +124,148 @@ func (g *Game) SetupInputHandlers() {
 					canvas.Call("mozRequestFullScreen")
 				}
 			}
+			// Ship controls, including target lock, are applied by the next Step
 		})
 
 	// Keyup handler
 	js.Global.Get("document").Call("addEventListener", "keyup",
 		func(event *js.Object) {
-			keyCode := TranslateKeyCode(event.Get("keyCode").Int())
-			g.Keys[keyCode] = false
+			g.Keyboard.KeyUp(event.Get("keyCode").Int())
 		})
+
+	// Gamepad hot-plugging; PollGamepad also picks up controllers that were
+	// connected before the page loaded
+	js.Global.Get("window").Call("addEventListener", "gamepadconnected",
+		func(event *js.Object) {
+			pad := event.Get("gamepad")
+			g.Gamepad.Connect(pad.Get("index").Int(), pad.Get("id").String())
+		})
+	js.Global.Get("window").Call("addEventListener", "gamepaddisconnected",
+		func(event *js.Object) {
+			g.Gamepad.Disconnect(event.Get("gamepad").Get("index").Int())
+		})
+
+	g.setupTouchHandlers()
+	g.setupMouseHandlers()
+}
+
+// canvasPoint converts the client position of a mouse event or touch to
+// canvas coordinates, which differ from CSS pixels when the canvas is
+// scaled to fit the window.
+func (g *Game) canvasPoint(p *js.Object) (x, y float64) {
+	rect := g.Canvas.Call("getBoundingClientRect")
+	x = (p.Get("clientX").Float() - rect.Get("left").Float()) * WIDTH / rect.Get("width").Float()
+	y = (p.Get("clientY").Float() - rect.Get("top").Float()) * HEIGHT / rect.Get("height").Float()
+	return x, y
+}
+
+// setupMouseHandlers feeds the cursor position and left clicks on the
+// canvas to g.Mouse. The right button stays with the audio control panel.
+func (g *Game) setupMouseHandlers() {
+	if g.Canvas == nil {
+		return
+	}
+	g.Canvas.Get("style").Set("cursor", "crosshair")
+
+	g.Canvas.Call("addEventListener", "mousemove", func(event *js.Object) {
+		g.Mouse.Move(g.canvasPoint(event))
+	})
+	g.Canvas.Call("addEventListener", "mouseleave", func(event *js.Object) {
+		g.Mouse.Leave()
+	})
+	g.Canvas.Call("addEventListener", "mousedown", func(event *js.Object) {
+		if event.Get("button").Int() != 0 {
+			return
+		}
+		// A click starts or restarts the game like fire
+		if g.State != StatePlaying && g.HandleStateAction(ActionFire, true) {
+			return
+		}
+		g.Mouse.Click(g.canvasPoint(event))
+	})
+}
+
+// setupTouchHandlers feeds the touches on the canvas to g.Touch. The
+// on-screen controls are shown right away on touch devices and otherwise
+// appear with the first touch.
+func (g *Game) setupTouchHandlers() {
+	if g.Canvas == nil {
+		return
+	}
+	window := js.Global.Get("window")
+	if window.Get("ontouchstart") != js.Undefined || js.Global.Get("navigator").Get("maxTouchPoints").Int() > 0 {
+		g.Touch.Enabled = true
+	}
+
+	// forEachTouch calls f with the identifier and canvas position of every
+	// touch that changed in event
+	forEachTouch := func(event *js.Object, f func(id int, x, y float64)) {
+		event.Call("preventDefault")
+		touches := event.Get("changedTouches")
+		for i := 0; i < touches.Length(); i++ {
+			touch := touches.Index(i)
+			x, y := g.canvasPoint(touch)
+			f(touch.Get("identifier").Int(), x, y)
+		}
+	}
+
+	// Non-passive listeners, so preventDefault stops scrolling and zooming
+	options := js.M{"passive": false}
+	g.Canvas.Call("addEventListener", "touchstart", func(event *js.Object) {
+		// A touch starts or restarts the game like fire
+		if g.State == StateTitle || g.State == StateGameOver || g.State == StateVictory {
+			g.HandleStateAction(ActionFire, true)
+		}
+		forEachTouch(event, g.Touch.TouchStart)
+	}, options)
+	g.Canvas.Call("addEventListener", "touchmove", func(event *js.Object) {
+		forEachTouch(event, g.Touch.TouchMove)
+	}, options)
+	release := func(event *js.Object) {
+		forEachTouch(event, func(id int, x, y float64) { g.Touch.TouchEnd(id) })
+	}
+	g.Canvas.Call("addEventListener", "touchend", release, options)
+	g.Canvas.Call("addEventListener", "touchcancel", release, options)
+}
+
+// PollGamepad reads the active controller from the browser. Browsers only
+// expose gamepad state as snapshots, so this runs once per frame.
+func (g *Game) PollGamepad() {
+	navigator := js.Global.Get("navigator")
+	if navigator.Get("getGamepads") == js.Undefined {
+		return
+	}
+	pads := navigator.Call("getGamepads")
+
+	if !g.Gamepad.Connected() {
+		for i := 0; i < pads.Length(); i++ {
+			if pad := pads.Index(i); pad != nil && pad != js.Undefined && pad.Get("connected").Bool() {
+				g.Gamepad.Connect(i, pad.Get("id").String())
+				break
+			}
+		}
+		if !g.Gamepad.Connected() {
+			return
+		}
+	}
+
+	pad := pads.Index(g.Gamepad.Index)
+	if pad == nil || pad == js.Undefined || !pad.Get("connected").Bool() {
+		g.UpdateGamepad(GamepadState{}, false)
+		return
+	}
+
+	axes := pad.Get("axes")
+	buttons := pad.Get("buttons")
+	state := GamepadState{
+		Axes:    make([]float64, axes.Length()),
+		Buttons: make([]float64, buttons.Length()),
+	}
+	for i := range state.Axes {
+		state.Axes[i] = axes.Index(i).Float()
+	}
+	for i := range state.Buttons {
+		state.Buttons[i] = buttons.Index(i).Get("value").Float()
+	}
+	g.UpdateGamepad(state, true)
 }
@@ -1,6 +1,7 @@
 package game
 
 import (
+	"math"
 	"strconv"
 
 	"github.com/gopherjs/gopherjs/js"
@@ -21,21 +22,78 @@ func (g *Game) GameLoopRAF(currentTime float64) {
 
 	g.LastFrameTime = currentTime
 
+	g.PollGamepad()
 	g.GameLoop()
 }
 
-// GameLoop is the core game logic.
+// GameLoop is the core game logic: one network exchange, one frame of
+// simulation and one rendered frame.
 func (g *Game) GameLoop() {
 	// Network update (send/receive)
 	if g.Network != nil {
 		g.Network.Update()
 	}
 
-	// Determine if we should run simulation (host) or just render (client)
-	isNetworkClient := g.Network != nil && g.Network.IsConnected() && !g.Network.IsHost()
+	g.AdvanceFrame()
+	g.Render()
+}
+
+// AdvanceFrame runs one simulation step driven by the ships' input sources
+// (or the ticks of the replay being played back) unless the game is
+// paused, followed by the state transitions that result from it.
+//
+// Pause only freezes single player games. In multiplayer the other players
+// play on, so the simulation keeps stepping and only the local ship is left
+// without input.
+func (g *Game) AdvanceFrame() {
+	switch {
+	case g.State == StatePaused && g.Network == nil:
+		// Frozen; the pause screen is drawn over the last frame
+	case g.State == StatePaused:
+		inputs := g.PollInputs()
+		for i := range inputs {
+			if g.Ships[i] == g.Ship {
+				inputs[i] = ControlState{}
+			}
+		}
+		g.Step(inputs)
+	case g.Playback != nil:
+		g.Playback.Advance(g)
+	default:
+		g.Step(g.PollInputs())
+	}
+
+	g.UpdateState()
+}
+
+// Step advances the simulation by exactly one tick. inputs holds the
+// controls of each ship in g.Ships order (see PollInputs); ships without an
+// entry receive no input. Step never touches the canvas, so it
+// can run headless for tests, tools and server-side simulation.
+//
+// Network clients only move their own ship; bullets, explosions and enemies
+// are simulated by the host and arrive through state sync.
+func (g *Game) Step(inputs []ControlState) {
+	isNetworkClient := g.IsNetworkClient()
+
+	// Record the local ship's input for replays
+	if g.Recording != nil {
+		var c ControlState
+		if len(inputs) > 0 {
+			c = inputs[0]
+		}
+		g.Recording.Record(c)
+	}
 
 	// Player Input Processing (always process for local movement feel)
-	g.ProcessInput()
+	for i, s := range g.Ships {
+		if i < len(inputs) {
+			s.ApplyInput(g, inputs[i], !isNetworkClient)
+		}
+	}
+
+	// Generate the world around the camera and ships
+	g.UpdateWorld()
 
 	// Update targeting system
 	g.Ship.UpdateTargeting(g)
@@ -43,61 +101,94 @@ func (g *Game) GameLoop() {
 	// Update shield audio filter based on ship position
 	g.UpdateShieldAudioFilter()
 
-	// Background Rendering
-	g.RenderBackground()
-
 	// Populate spatial grids for collision detection
 	g.PopulateSpatialGrids()
 
-	// Bullet Update and Rendering
-	// Clients just render bullets received from host, host runs full simulation
-	if isNetworkClient {
-		g.RenderBullets()
-	} else {
+	// Bullet Update (host only - clients receive bullet state from host)
+	if !isNetworkClient {
 		g.UpdateBullets()
 	}
 
-	// Screen Flash Effect
+	// Screen flash fades out
 	if g.Level.Bomb > 0 {
-		alpha := float64(g.Level.Bomb) / float64(MaxBomb) / 2
-		g.Ctx.Set("fillStyle", Theme.BombFlashColor+strconv.FormatFloat(alpha, 'f', 2, 64)+")")
-		g.Ctx.Call("fillRect", 0, 0, WIDTH, HEIGHT)
 		g.Level.Bomb--
 	}
 
-	// Enable additive blending
-	g.Ctx.Set("globalCompositeOperation", "lighter")
-
-	// Base Rendering (render before other entities)
-	g.RenderBases()
-
 	// Bonus Item Update
 	g.UpdateBonuses()
 
-	// Explosion Update - clients just render, host runs full simulation
-	if isNetworkClient {
-		g.RenderExplosions()
-	} else {
+	// Explosion Update (host only)
+	if !isNetworkClient {
 		g.UpdateExplosions()
 	}
 
-	// Player Ship Rendering
-	g.RenderShip()
+	// Player Ship Update
+	g.UpdateShips()
 
-	// Wave Spawning (host only - clients receive enemy state from host)
+	// Wave Spawning and Enemy Update (host only - clients receive enemy state from host)
 	if !isNetworkClient {
-		g.CheckWaveSpawn()
+		if g.Mode == ModeCampaign {
+			g.UpdateCampaign()
+		} else {
+			g.CheckWaveSpawn()
+		}
+		g.UpdateEnemies()
 	}
 
-	// Enemy Update - clients just render, host runs full simulation
-	if isNetworkClient {
-		g.RenderEnemies()
-	} else {
-		g.UpdateEnemies()
+	// Text message drifts and fades
+	g.UpdateText()
+
+	// Base shield animation
+	g.UpdateBases()
+
+	// Advance torpedo animation
+	g.TorpedoFrame = (g.TorpedoFrame + 1) % TorpedoFrameCount
+
+	g.Tick++
+}
+
+// Render draws the current simulation state to the canvas. It does not
+// change any simulation state.
+func (g *Game) Render() {
+	// Background Rendering
+	g.RenderBackground()
+
+	// Landmarks and asteroid belts
+	g.RenderWorld()
+
+	// Bullet Rendering
+	g.RenderBullets()
+
+	// Screen Flash Effect
+	if g.Level.Bomb > 0 {
+		alpha := float64(g.Level.Bomb) / float64(MaxBomb) / 2
+		g.Ctx.SetFillStyle(Theme.BombFlashColor + strconv.FormatFloat(alpha, 'f', 2, 64) + ")")
+		g.Ctx.FillRect(0, 0, WIDTH, HEIGHT)
 	}
 
+	// Enable additive blending
+	g.Ctx.SetCompositeOperation("lighter")
+
+	// Base Rendering (render before other entities)
+	g.RenderBases()
+
+	// Bonus Item Rendering
+	g.RenderBonuses()
+
+	// Explosion Rendering
+	g.RenderExplosions()
+
+	// Player Ship Rendering
+	g.RenderShips()
+
+	// Enemy Rendering
+	g.RenderEnemies()
+
 	// Disable additive blending
-	g.Ctx.Set("globalCompositeOperation", "source-over")
+	g.Ctx.SetCompositeOperation("source-over")
+
+	// Wave titles and other messages
+	g.RenderText()
 
 	// Off-screen base indicators (render after blending disabled for visibility)
 	g.RenderBaseIndicators()
@@ -105,16 +196,34 @@ func (g *Game) GameLoop() {
 	// Ship HUD overlay (velocity, angle, position)
 	g.ShipHUD.Render(g.Ctx, g.Ship)
 
+	// Radar minimap next to the ship HUD
+	g.Radar.Render(g.Ctx, g)
+
 	// Stats overlay
 	g.StatsOverlay.Render(g.Ctx, g)
+
+	// On-screen touch controls
+	g.Touch.Render(g.Ctx)
+
+	// Title, pause, game over and victory screens
+	g.RenderStateScreen(g.Ctx)
+
+	// Base shop while docked
+	g.Shop.Render(g.Ctx, g)
+
+	// Key rebinding screen
+	g.BindingsScreen.Render(g.Ctx, g.Keyboard.Bindings)
+
+	// Replay playback status
+	g.RenderReplayStatus()
+
 }
 
-// UpdateBullets updates and renders bullets.
+// UpdateBullets moves bullets and resolves their collisions.
 func (g *Game) UpdateBullets() {
 	g.Bullets.ForEachReverse(func(bullet *Bullet, bulletIdx int) {
-		// Within the render the projectile checks for collisions
-		// with other ships
-		if !bullet.Render(g) {
+		// The update checks for collisions with ships, enemies and bases
+		if !bullet.Update(g) {
 			g.Bullets.Release(bulletIdx)
 			return
 		}
@@ -124,37 +233,13 @@ func (g *Game) UpdateBullets() {
 			g.CheckBulletTorpedoCollision(bullet)
 		}
 	})
-
-	// Advance torpedo animation
-	g.TorpedoFrame = (g.TorpedoFrame + 1) % len(g.TorpedoImages)
 }
 
-// RenderBullets renders bullets without simulation (for network clients).
+// RenderBullets renders all active bullets and torpedos.
 func (g *Game) RenderBullets() {
 	g.Bullets.ForEachReverse(func(bullet *Bullet, bulletIdx int) {
-		var image *js.Object
-		var projectileR float64
-
-		if bullet.Kind == StandardBullet {
-			image = g.BulletImage
-			projectileR = BulletR
-		}
-		if bullet.Kind == TorpedoBullet {
-			image = g.TorpedoImages[g.TorpedoFrame]
-			projectileR = TorpedoR
-		}
-
-		// Convert world position to screen position
-		screenX, screenY := g.Camera.WorldToScreen(bullet.X, bullet.Y)
-
-		// Only render if on screen
-		if g.Camera.IsOnScreen(bullet.X, bullet.Y, projectileR) {
-			g.Ctx.Call("drawImage", image, screenX-projectileR, screenY-projectileR)
-		}
+		bullet.Render(g)
 	})
-
-	// Advance torpedo animation
-	g.TorpedoFrame = (g.TorpedoFrame + 1) % len(g.TorpedoImages)
 }
 
 // PopulateSpatialGrids clears and repopulates the spatial hash grids.
@@ -179,7 +264,8 @@ func (g *Game) PopulateSpatialGrids() {
 	}
 }
 
-// CheckBulletTorpedoCollision checks if a standard bullet hits any nearby torpedos.
+// CheckBulletTorpedoCollision checks if a standard bullet hits any nearby
+// torpedos or mines.
 func (g *Game) CheckBulletTorpedoCollision(bullet *Bullet) {
 	// Convert bullet to screen coordinates for spatial grid lookup
 	screenX, screenY := g.Camera.WorldToScreen(bullet.X, bullet.Y)
@@ -189,7 +275,7 @@ func (g *Game) CheckBulletTorpedoCollision(bullet *Bullet) {
 
 	for _, obj := range nearby {
 		torpedo, ok := obj.(*Bullet)
-		if !ok || torpedo.Kind != TorpedoBullet {
+		if !ok || !torpedo.Hostile() {
 			continue
 		}
 
@@ -214,7 +300,8 @@ func (g *Game) CheckBulletTorpedoCollision(bullet *Bullet) {
 	}
 }
 
-// UpdateBonuses updates and renders bonus items.
+// UpdateBonuses moves bonus items and hands them to the ships that pick
+// them up.
 func (g *Game) UpdateBonuses() {
 	g.Bonuses.ForEachReverse(func(item *Bonus, idx int) {
 		claimed := false
@@ -235,13 +322,6 @@ func (g *Game) UpdateBonuses() {
 		item.X += item.XAcc
 		item.Y += item.YAcc
 
-		// Convert to screen coordinates and render if visible
-		screenX, screenY := g.Camera.WorldToScreen(item.X, item.Y)
-		if g.Camera.IsOnScreen(item.X, item.Y, BonusR*2) {
-			g.Ctx.Call("drawImage", g.BonusImages[item.Type],
-				int(screenX)-BonusR, int(screenY)-BonusR)
-		}
-
 		// Remove items too far from camera (infinite world cleanup)
 		dx := item.X - g.Camera.X
 		dy := item.Y - g.Camera.Y
@@ -251,23 +331,26 @@ func (g *Game) UpdateBonuses() {
 	})
 }
 
-// UpdateExplosions updates and renders explosions.
-func (g *Game) UpdateExplosions() {
-	g.Explosions.ForEachReverse(func(exp *Explosion, idx int) {
-		// Convert to screen coordinates
-		screenX, screenY := g.Camera.WorldToScreen(exp.X, exp.Y)
+// RenderBonuses renders bonus items that are on screen.
+func (g *Game) RenderBonuses() {
+	g.Bonuses.ForEachReverse(func(item *Bonus, idx int) {
+		// Lazy render bonus image if not cached
+		if _, ok := g.BonusImages[item.Type]; !ok {
+			g.RenderBonusImage(item.Type)
+		}
 
-		// Only render if on screen
-		if g.Camera.IsOnScreen(exp.X, exp.Y, exp.Size) {
-			g.Ctx.Call("save")
-			g.Ctx.Set("globalAlpha", exp.Alpha)
-			g.Ctx.Call("translate", screenX, screenY)
-			g.Ctx.Call("rotate", exp.Angle)
-			g.Ctx.Call("drawImage", g.ExplosionImage,
-				-exp.Size/2, -exp.Size/2, exp.Size, exp.Size)
-			g.Ctx.Call("restore")
+		// Convert to screen coordinates and render if visible
+		screenX, screenY := g.Camera.WorldToScreen(item.X, item.Y)
+		if g.Camera.IsOnScreen(item.X, item.Y, BonusR*2) {
+			g.Ctx.DrawImage(g.BonusImages[item.Type],
+				math.Floor(screenX)-BonusR, math.Floor(screenY)-BonusR)
 		}
+	})
+}
 
+// UpdateExplosions animates explosions and releases the faded ones.
+func (g *Game) UpdateExplosions() {
+	g.Explosions.ForEachReverse(func(exp *Explosion, idx int) {
 		// Animate explosion
 		exp.Size += 16
 		exp.Angle += exp.D
@@ -279,7 +362,7 @@ func (g *Game) UpdateExplosions() {
 	})
 }
 
-// RenderExplosions renders explosions without simulation (for network clients).
+// RenderExplosions renders explosions that are on screen.
 func (g *Game) RenderExplosions() {
 	g.Explosions.ForEachReverse(func(exp *Explosion, idx int) {
 		// Convert to screen coordinates
@@ -287,95 +370,82 @@ func (g *Game) RenderExplosions() {
 
 		// Only render if on screen
 		if g.Camera.IsOnScreen(exp.X, exp.Y, exp.Size) {
-			g.Ctx.Call("save")
-			g.Ctx.Set("globalAlpha", exp.Alpha)
-			g.Ctx.Call("translate", screenX, screenY)
-			g.Ctx.Call("rotate", exp.Angle)
-			g.Ctx.Call("drawImage", g.ExplosionImage,
+			g.Ctx.Save()
+			g.Ctx.SetGlobalAlpha(exp.Alpha)
+			g.Ctx.Translate(screenX, screenY)
+			g.Ctx.Rotate(exp.Angle)
+			g.Ctx.DrawImageScaled(g.ExplosionImage,
 				-exp.Size/2, -exp.Size/2, exp.Size, exp.Size)
-			g.Ctx.Call("restore")
+			g.Ctx.Restore()
 		}
 	})
 }
 
-// RenderShip renders the player ship using the Entity interface.
-func (g *Game) RenderShip() {
+// UpdateShips updates every ship using the Entity interface.
+func (g *Game) UpdateShips() {
 	for _, s := range g.Ships {
 		s.Update(g)
 	}
 }
 
-// CheckWaveSpawn spawns enemies continuously near each ship.
-// Enemy count and strength scale with each ship's points.
+// RenderShips renders every ship using the Entity interface.
+func (g *Game) RenderShips() {
+	for _, s := range g.Ships {
+		s.Render(g)
+	}
+}
+
+// CheckWaveSpawn spawns enemies continuously near each ship following the
+// wave rules of the spawn table. Enemy count and strength scale with each
+// ship's points.
 func (g *Game) CheckWaveSpawn() {
+	waves := &g.Spawns.Waves
+
 	// Calculate how many enemies should exist based on all ships' points
 	targetEnemies := 0
 	for _, ship := range g.Ships {
 		// Base enemies + scaling with points
-		targetEnemies += 5 + ship.Points/500
+		targetEnemies += waves.TargetBase
+		if waves.TargetPerPoints > 0 {
+			targetEnemies += ship.Points / waves.TargetPerPoints
+		}
 	}
 
 	// Spawn new enemies when count drops below target
 	if len(g.Enemies) < targetEnemies {
 		// Spawn enemies near each ship
 		for _, ship := range g.Ships {
-			// Small fighters - always spawn
-			g.SpawnEnemyNearShip(SmallFighter, ship)
-
-			// Medium fighters - spawn after 1000 points
-			if ship.Points > 1000 {
-				g.SpawnEnemyNearShip(MediumFighter, ship)
-			}
-
-			// Turrets - spawn after 3000 points
-			if ship.Points > 3000 {
-				g.SpawnEnemyNearShip(TurretFighter, ship)
-			}
-
-			// Boss - rare spawn after 5000 points
-			if ship.Points > 5000 && g.GameRNG.Random() > 0.9 {
-				g.SpawnEnemyNearShip(Boss, ship)
+			for _, rule := range waves.Rules {
+				if rule.applies(g, ship) {
+					g.SpawnEnemyNearShip(rule.Kind, ship)
+				}
 			}
 		}
 	}
 }
 
-// UpdateEnemies updates and renders enemies using the Entity interface.
+// UpdateEnemies updates enemies using the Entity interface.
+// Iterates in reverse so swap-and-pop removal never skips an enemy.
 func (g *Game) UpdateEnemies() {
-	for i, e := range g.Enemies {
-		if !e.Update(g) {
+	for i := len(g.Enemies) - 1; i >= 0; i-- {
+		if !g.Enemies[i].Update(g) {
 			// enemy no longer exists
 			g.RemoveEnemy(i)
 		}
 	}
 }
 
-// RenderEnemies renders enemies without simulation (for network clients).
+// RenderEnemies renders enemies using the Entity interface.
 func (g *Game) RenderEnemies() {
 	for _, e := range g.Enemies {
-		// Just render, no AI/movement since that comes from host
-		enemyY := e.Y + e.YOffset
-
-		// Convert world position to screen position for rendering
-		screenX, screenY := g.Camera.WorldToScreen(e.X, enemyY)
-
-		// Only render if on screen
-		if !g.Camera.IsOnScreen(e.X, enemyY, e.Radius*2) {
-			continue
-		}
+		e.Render(g)
+	}
+}
 
-		// Render enemy
-		g.Ctx.Call("save")
-		g.Ctx.Call("translate", screenX, screenY)
-		g.Ctx.Call("rotate", e.Angle)
-		g.Ctx.Call("drawImage", e.Image,
-			-e.Radius, -e.Radius, e.Radius*2, e.Radius*2)
-		g.Ctx.Call("restore")
-
-		// Render health bar if recently hit
-		if e.OSD > 0 {
-			e.RenderHealthBar(g, screenX, screenY)
-		}
+// UpdateBases advances the shield animation of all bases.
+func (g *Game) UpdateBases() {
+	for _, base := range g.Bases {
+		base.Update()
 	}
 }
 
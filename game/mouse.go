@@ -0,0 +1,62 @@
+package game
+
+import "math"
+
+// MouseInput lets the player point at the game with the mouse. A left click
+// locks onto the enemy under the cursor instead of the nearest one, and
+// with Steering on the ship turns toward the cursor.
+//
+// The cursor is converted to world coordinates when polled and sent as the
+// aim point of the controls, so the hit test runs inside the simulation and
+// replays and multiplayer see the same lock.
+type MouseInput struct {
+	Steering bool    // Rotate the ship toward the cursor
+	X, Y     float64 // Cursor position in canvas coordinates
+	Over     bool    // The cursor is over the canvas
+	clicked  bool    // A click is waiting for the next Poll
+}
+
+// NewMouseInput creates a mouse source with steering off.
+func NewMouseInput() *MouseInput {
+	return &MouseInput{}
+}
+
+// Move records the cursor position in canvas coordinates.
+func (m *MouseInput) Move(x, y float64) {
+	m.X, m.Y = x, y
+	m.Over = true
+}
+
+// Leave records that the cursor left the canvas.
+func (m *MouseInput) Leave() {
+	m.Over = false
+}
+
+// Click records a left click at canvas position (x, y).
+func (m *MouseInput) Click(x, y float64) {
+	m.Move(x, y)
+	m.clicked = true
+}
+
+// ToggleSteering switches rotating toward the cursor on or off.
+func (m *MouseInput) ToggleSteering() {
+	m.Steering = !m.Steering
+}
+
+// Poll implements InputSource. A click is reported for a single tick.
+func (m *MouseInput) Poll(g *Game, s *Ship) ControlState {
+	var c ControlState
+	if m.clicked {
+		c.Keys |= KeyLockAt
+		m.clicked = false
+	}
+	if m.Steering && m.Over {
+		c.Keys |= KeyAim
+	}
+
+	if c.HasAim() {
+		x, y := g.Camera.ScreenToWorld(m.X, m.Y)
+		c.AimX, c.AimY = int32(math.Round(x)), int32(math.Round(y))
+	}
+	return c
+}
@@ -2,11 +2,12 @@ package game
 
 import (
 	"encoding/json"
-	"math"
+	"hash/fnv"
 	"strconv"
 	"time"
 
 	"github.com/gopherjs/gopherjs/js"
+	"github.com/simukka/starship-sorades-13k/common"
 )
 
 // Network constants
@@ -42,11 +43,16 @@ type NetworkMessage struct {
 
 // PlayerInputData contains player input state
 type PlayerInputData struct {
-	Keys     uint16  `json:"k"`  // Bitmask of pressed keys
-	Angle    float64 `json:"a"`  // Ship angle
-	Firing   bool    `json:"f"`  // Is firing
-	TargetID int     `json:"ti"` // Target enemy index (-1 if none)
-	SeqNum   uint32  `json:"s"`  // Sequence number for reconciliation
+	Keys     uint16  `json:"k"`            // Bitmask of pressed keys
+	Turn     int8    `json:"tu,omitempty"` // Analog rotation, see ControlState
+	Thrust   int8    `json:"th,omitempty"` // Analog throttle, see ControlState
+	AimX     int32   `json:"ax,omitempty"` // Aim point, see ControlState
+	AimY     int32   `json:"ay,omitempty"`
+	Buy      Upgrade `json:"b,omitempty"` // Upgrade bought with KeyBuy
+	Angle    float64 `json:"a"`           // Ship angle
+	Firing   bool    `json:"f"`           // Is firing
+	TargetID int     `json:"ti"`          // Target enemy index (-1 if none)
+	SeqNum   uint32  `json:"s"`           // Sequence number for reconciliation
 }
 
 // Key bitmasks for compact input encoding
@@ -57,8 +63,23 @@ const (
 	KeyDown  uint16 = 1 << 3
 	KeyFire  uint16 = 1 << 4
 	KeyLock  uint16 = 1 << 5
+	// KeyNextTarget moves the target lock to the next enemy on screen
+	KeyNextTarget uint16 = 1 << 6
+	// KeyLockAt locks onto the enemy at the aim point (mouse click)
+	KeyLockAt uint16 = 1 << 7
+	// KeyAim rotates the ship toward the aim point (mouse steering)
+	KeyAim uint16 = 1 << 8
+	// KeySwitchWeapon selects the next weapon the ship carries
+	KeySwitchWeapon uint16 = 1 << 9
+	// KeyBuy buys the upgrade in Buy (base shop)
+	KeyBuy uint16 = 1 << 10
 )
 
+// Controls returns the ship controls carried by the message.
+func (p *PlayerInputData) Controls() ControlState {
+	return ControlState{Keys: p.Keys, Turn: p.Turn, Thrust: p.Thrust, AimX: p.AimX, AimY: p.AimY, Buy: p.Buy}
+}
+
 // ShipState contains networked ship state
 type ShipState struct {
 	ID       string  `json:"id"`
@@ -72,7 +93,12 @@ type ShipState struct {
 	Weapons  int     `json:"w"`
 	Points   int     `json:"pt"`
 	InBase   bool    `json:"ib"`
-	TargetID int     `json:"ti"` // Target enemy index
+	TargetID int     `json:"ti"`          // Target enemy index
+	Upgrades []int   `json:"u,omitempty"` // Level of each shop upgrade
+
+	Weapon  WeaponKind `json:"wk"`           // Selected weapon
+	Arsenal uint8      `json:"ar,omitempty"` // Weapons collected besides the spread gun
+	Charge  int        `json:"c,omitempty"`  // Ticks the charge shot has been charged
 }
 
 // EnemyState contains networked enemy state
@@ -85,6 +111,7 @@ type EnemyState struct {
 	VelY   float64   `json:"vy"`
 	Health int       `json:"h"`
 	Angle  float64   `json:"a"`
+	Parts  []int     `json:"p,omitempty"` // Health of each boss part
 }
 
 // BulletState contains networked bullet state
@@ -109,21 +136,34 @@ type ExplosionState struct {
 	Alpha float64 `json:"alpha"`
 }
 
+// ObstacleState contains the damage of a damaged obstacle. Obstacles are
+// generated from the world seed on every peer, so only damage is synced.
+type ObstacleState struct {
+	X      int `json:"x"` // Chunk coordinates
+	Y      int `json:"y"`
+	Index  int `json:"i"` // Index among the chunk's obstacles
+	Damage int `json:"d"`
+}
+
 // WorldStateData contains the full world state from host
 type WorldStateData struct {
-	Tick       uint32           `json:"t"`  // Server tick number
-	Ships      []ShipState      `json:"s"`  // All player ships
-	Enemies    []EnemyState     `json:"e"`  // All enemies
-	Bullets    []BulletState    `json:"b"`  // Active bullets/torpedos
-	Explosions []ExplosionState `json:"ex"` // Active explosions
-	InputAck   uint32           `json:"ia"` // Last processed input seq for this player
+	Tick       uint32           `json:"t"`            // Server tick number
+	Ships      []ShipState      `json:"s"`            // All player ships
+	Enemies    []EnemyState     `json:"e"`            // All enemies
+	Bullets    []BulletState    `json:"b"`            // Active bullets/torpedos
+	Explosions []ExplosionState `json:"ex"`           // Active explosions
+	Bomb       int              `json:"bm,omitempty"` // Bomb screen flash (Level.Bomb)
+	Obstacles  []ObstacleState  `json:"o,omitempty"`  // Damaged obstacles
+	InputAck   uint32           `json:"ia"`           // Last processed input seq for this player
 }
 
 // PlayerJoinData contains info about a joining player
 type PlayerJoinData struct {
-	PlayerID string `json:"id"`
-	Name     string `json:"name"`
-	IsHost   bool   `json:"host"`
+	PlayerID   string     `json:"id"`
+	Name       string     `json:"name"`
+	IsHost     bool       `json:"host"`
+	Seed       uint32     `json:"seed"`       // World seed of the host
+	Difficulty Difficulty `json:"difficulty"` // Difficulty of the host
 }
 
 // SpawnEnemyData contains enemy spawn info from host
@@ -246,13 +286,15 @@ func GeneratePlayerID() string {
 	return string(id)
 }
 
-// JoinRoom connects to a game room
+// JoinRoom connects to a game room. The room is tagged with the difficulty,
+// so the signaling server turns away players of another difficulty.
 func (nm *NetworkManager) JoinRoom(roomID string) {
 	nm.roomID = roomID
 	nm.playerID = GeneratePlayerID()
 
 	// Connect to signaling server via SSE
-	url := "/api/signal?room=" + roomID + "&peer=" + nm.playerID
+	url := "/api/signal?room=" + roomID + "&peer=" + nm.playerID +
+		"&difficulty=" + nm.game.Difficulty.String()
 	nm.signaling = js.Global.Get("EventSource").New(url)
 
 	nm.signaling.Set("onmessage", func(event *js.Object) {
@@ -426,9 +468,11 @@ func (nm *NetworkManager) setupDataChannel(peer *PeerConnection, channel *js.Obj
 
 		// When data channel opens, send join message to announce ourselves
 		joinData, _ := json.Marshal(PlayerJoinData{
-			PlayerID: nm.playerID,
-			Name:     "Player " + nm.playerID[:4],
-			IsHost:   nm.isHost,
+			PlayerID:   nm.playerID,
+			Name:       "Player " + nm.playerID[:4],
+			IsHost:     nm.isHost,
+			Seed:       nm.game.GameSeed,
+			Difficulty: nm.game.Difficulty,
 		})
 		msg := &NetworkMessage{
 			Type:      MsgPlayerJoin,
@@ -450,10 +494,11 @@ func (nm *NetworkManager) setupDataChannel(peer *PeerConnection, channel *js.Obj
 			}
 
 			if !shipExists {
+				x, y := nm.joinPosition(peer.ID)
 				ship := &Ship{
 					NetworkID: peer.ID,
-					X:         nm.game.Ship.X + (js.Global.Get("Math").Call("random").Float()-0.5)*200,
-					Y:         nm.game.Ship.Y + (js.Global.Get("Math").Call("random").Float()-0.5)*200,
+					X:         x,
+					Y:         y,
 					E:         100,
 					local:     false,
 					Shield:    Shield{MaxT: ShipMaxShield},
@@ -715,55 +760,13 @@ func (nm *NetworkManager) handlePlayerInput(peerID string, data json.RawMessage)
 		return
 	}
 
-	// Apply input to ship
-	nm.applyInputToShip(ship, &input)
-}
-
-// applyInputToShip applies network input to a ship
-func (nm *NetworkManager) applyInputToShip(ship *Ship, input *PlayerInputData) {
-	// Rotation
-	if input.Keys&KeyLeft != 0 {
-		ship.Angle -= ShipRotationSpeed
-	}
-	if input.Keys&KeyRight != 0 {
-		ship.Angle += ShipRotationSpeed
-	}
-
-	// Thrust
-	if input.Keys&KeyUp != 0 {
-		ship.VelX += math.Sin(ship.Angle) * ShipThrustAcc
-		ship.VelY -= math.Cos(ship.Angle) * ShipThrustAcc
-	}
-	if input.Keys&KeyDown != 0 {
-		ship.VelX -= math.Sin(ship.Angle) * ShipThrustAcc * 0.5
-		ship.VelY += math.Cos(ship.Angle) * ShipThrustAcc * 0.5
-	}
-
-	// Clamp velocity
-	speed := math.Sqrt(ship.VelX*ship.VelX + ship.VelY*ship.VelY)
-	if speed > ShipMaxSpeed {
-		scale := ShipMaxSpeed / speed
-		ship.VelX *= scale
-		ship.VelY *= scale
-	}
-
-	// Apply velocity
-	ship.X += ship.VelX
-	ship.Y += ship.VelY
-
-	// Apply drag
-	ship.VelX *= ShipACCFactor
-	ship.VelY *= ShipACCFactor
-
-	// Handle firing (host processes client fire input)
-	if input.Firing {
-		ship.Fire(nm.game)
-	}
-
-	// Handle target lock (T key)
-	if input.Keys&KeyLock != 0 {
-		ship.InitiateTargetLock(nm.game)
+	// The host applies the latest input on every tick
+	src, ok := ship.Input.(*NetworkInput)
+	if !ok {
+		src = &NetworkInput{}
+		ship.Input = src
 	}
+	src.Receive(&input)
 
 	// Set target if provided
 	if input.TargetID >= 0 && input.TargetID < len(nm.game.Enemies) {
@@ -798,6 +801,14 @@ func (nm *NetworkManager) handleWorldState(data json.RawMessage) {
 
 	// Update explosions (so clients see all explosions)
 	nm.updateExplosions(&state)
+
+	// Break the debris the host broke
+	nm.updateObstacles(&state)
+
+	// Flash the screen for bombs set off by any player
+	if state.Bomb > nm.game.Level.Bomb {
+		nm.game.Level.Bomb = state.Bomb
+	}
 }
 
 // reconcileLocalShip handles server reconciliation for local player
@@ -832,10 +843,15 @@ func (nm *NetworkManager) reconcileLocalShip(state *WorldStateData) {
 	nm.game.Ship.Angle = serverShip.Angle
 	nm.game.Ship.E = serverShip.Health
 	nm.game.Ship.Shield.T = serverShip.Shield
+	// Purchases are made on the host
+	nm.game.Ship.Points = serverShip.Points
+	nm.game.Ship.SetUpgrades(serverShip.Upgrades)
+	// Pickups are collected on the host
+	nm.game.Ship.SetArsenal(serverShip.Weapon, serverShip.Arsenal, serverShip.Charge)
 
-	// Re-apply unacknowledged inputs
+	// Re-apply unacknowledged inputs; only the host spawns bullets
 	for _, input := range nm.pendingInputs {
-		nm.applyInputToShip(nm.game.Ship, &input)
+		nm.game.Ship.ApplyInput(nm.game, input.Controls(), false)
 	}
 }
 
@@ -878,6 +894,9 @@ func (nm *NetworkManager) updateRemoteShips(state *WorldStateData) {
 		ship.E = shipState.Health
 		ship.Shield.T = shipState.Shield
 		ship.InBase = shipState.InBase
+		ship.Points = shipState.Points
+		ship.SetUpgrades(shipState.Upgrades)
+		ship.SetArsenal(shipState.Weapon, shipState.Arsenal, shipState.Charge)
 	}
 }
 
@@ -910,6 +929,7 @@ func (nm *NetworkManager) updateEnemies(state *WorldStateData) {
 		enemy.VelY = es.VelY
 		enemy.Health = es.Health
 		enemy.Angle = es.Angle
+		nm.updateParts(enemy, es.Parts)
 		delete(existing, es.ID)
 	}
 
@@ -917,6 +937,9 @@ func (nm *NetworkManager) updateEnemies(state *WorldStateData) {
 	for id := range existing {
 		for i, e := range nm.game.Enemies {
 			if e.NetworkID == id {
+				if len(e.Parts) > 0 {
+					nm.game.endBossMusic(e)
+				}
 				nm.game.Enemies = append(nm.game.Enemies[:i], nm.game.Enemies[i+1:]...)
 				break
 			}
@@ -924,18 +947,90 @@ func (nm *NetworkManager) updateEnemies(state *WorldStateData) {
 	}
 }
 
+// upgradeLevels returns the level of every shop upgrade of a ship, or nil
+// for ships without upgrades.
+func upgradeLevels(s *Ship) []int {
+	if !s.Upgraded() {
+		return nil
+	}
+	return append([]int(nil), s.Upgrades[:]...)
+}
+
+// obstacleDamage returns the damage of every damaged obstacle in the loaded
+// chunks (the world forgets the rest), or nil when nothing is damaged.
+func obstacleDamage(w *World) []ObstacleState {
+	ids := w.Damaged()
+	if len(ids) == 0 {
+		return nil
+	}
+	states := make([]ObstacleState, len(ids))
+	for i, id := range ids {
+		states[i] = ObstacleState{X: id.Chunk.X, Y: id.Chunk.Y, Index: id.Index, Damage: w.Damage[id]}
+	}
+	return states
+}
+
+// updateObstacles syncs obstacle damage from host (clients only)
+func (nm *NetworkManager) updateObstacles(state *WorldStateData) {
+	damage := make(map[ObstacleID]int, len(state.Obstacles))
+	for _, o := range state.Obstacles {
+		damage[ObstacleID{Chunk: ChunkCoord{o.X, o.Y}, Index: o.Index}] = o.Damage
+	}
+	nm.game.World.SetDamage(damage)
+}
+
+// partHealth returns the health of every part of a boss, or nil for
+// enemies without parts.
+func partHealth(e *Enemy) []int {
+	if len(e.Parts) == 0 {
+		return nil
+	}
+	health := make([]int, len(e.Parts))
+	for i, p := range e.Parts {
+		health[i] = p.Health
+	}
+	return health
+}
+
+// updateParts syncs the part health of a boss from host, laying out its
+// parts the first time, and shows destroyed parts and phase changes.
+func (nm *NetworkManager) updateParts(e *Enemy, health []int) {
+	if len(health) == 0 {
+		return
+	}
+	if len(e.Parts) != len(health) {
+		e.Parts = newBossParts(e.Radius, e.MaxHealth)
+		e.Phase = -1
+		if len(e.Parts) != len(health) {
+			e.Parts = nil
+			return
+		}
+	}
+	for i, p := range e.Parts {
+		if health[i] < p.Health {
+			p.OSD = ShipMaxOSD
+		} else if p.OSD > 0 {
+			p.OSD--
+		}
+		p.Health = health[i]
+		if p.Health > p.MaxHealth {
+			p.MaxHealth = p.Health
+		}
+	}
+	nm.game.UpdateBossPhase(e)
+}
+
 // updateBullets syncs bullet state from host (clients only)
 func (nm *NetworkManager) updateBullets(state *WorldStateData) {
 	// Clear all bullets and replace with server state
 	nm.game.Bullets.Clear()
 
 	for _, bs := range state.Bullets {
-		var bullet *Bullet
-		if bs.Kind == StandardBullet {
-			bullet = nm.game.Bullets.AcquireKind(StandardBullet)
-		} else {
-			bullet = nm.game.Bullets.AcquireKind(TorpedoBullet)
+		kind := bs.Kind
+		if kind < 0 || kind >= BulletKindCount {
+			kind = TorpedoBullet
 		}
+		bullet := nm.game.Bullets.AcquireKind(kind)
 		if bullet == nil {
 			continue
 		}
@@ -969,6 +1064,17 @@ func (nm *NetworkManager) updateExplosions(state *WorldStateData) {
 	}
 }
 
+// joinPosition returns where the ship of player id appears: up to 100 pixels
+// from the local ship, at an offset derived from the ID. Network events
+// arrive at any tick, so they must not draw from the gameplay RNG, which
+// only advances inside Step.
+func (nm *NetworkManager) joinPosition(id string) (x, y float64) {
+	h := fnv.New32a()
+	h.Write([]byte(id))
+	rng := common.NewSeededRNG(h.Sum32())
+	return nm.game.Ship.X + rng.RandomFloat(-100, 100), nm.game.Ship.Y + rng.RandomFloat(-100, 100)
+}
+
 // handlePlayerJoin processes a new player joining
 func (nm *NetworkManager) handlePlayerJoin(peerID string, data json.RawMessage) {
 	var joinData PlayerJoinData
@@ -976,12 +1082,19 @@ func (nm *NetworkManager) handlePlayerJoin(peerID string, data json.RawMessage)
 		return
 	}
 
+	// Generate the same world chunks as the host and play at its difficulty
+	if joinData.IsHost && !nm.isHost {
+		nm.game.SetWorldSeed(joinData.Seed)
+		nm.game.Difficulty = joinData.Difficulty
+	}
+
 	// Create ship for new player if host
 	if nm.isHost {
+		x, y := nm.joinPosition(peerID)
 		ship := &Ship{
 			NetworkID: peerID,
-			X:         nm.game.Ship.X + (js.Global.Get("Math").Call("random").Float()-0.5)*200,
-			Y:         nm.game.Ship.Y + (js.Global.Get("Math").Call("random").Float()-0.5)*200,
+			X:         x,
+			Y:         y,
 			E:         100,
 			local:     false,
 			Shield:    Shield{MaxT: ShipMaxShield},
@@ -1043,32 +1156,19 @@ func (nm *NetworkManager) sendLocalInput() {
 		return
 	}
 
-	// Encode current keys
-	var keys uint16
-	if nm.game.Keys[37] {
-		keys |= KeyLeft
-	}
-	if nm.game.Keys[39] {
-		keys |= KeyRight
-	}
-	if nm.game.Keys[38] {
-		keys |= KeyUp
-	}
-	if nm.game.Keys[40] {
-		keys |= KeyDown
-	}
-	if nm.game.Keys[88] {
-		keys |= KeyFire
-	}
-	if nm.game.Keys[84] {
-		keys |= KeyLock
-	}
+	// Send the controls the local ship applied last, whatever its input source
+	c := nm.game.Ship.prevInput
 
 	nm.inputSeqNum++
 	input := PlayerInputData{
-		Keys:   keys,
+		Keys:   c.Keys,
+		Turn:   c.Turn,
+		Thrust: c.Thrust,
+		AimX:   c.AimX,
+		AimY:   c.AimY,
+		Buy:    c.Buy,
 		Angle:  nm.game.Ship.Angle,
-		Firing: nm.game.Keys[88],
+		Firing: c.Keys&KeyFire != 0,
 		SeqNum: nm.inputSeqNum,
 	}
 	if nm.game.Ship.Target != nil {
@@ -1125,6 +1225,10 @@ func (nm *NetworkManager) broadcastWorldState() {
 			Points:   ship.Points,
 			InBase:   ship.InBase,
 			TargetID: targetID,
+			Upgrades: upgradeLevels(ship),
+			Weapon:   ship.Weapon,
+			Arsenal:  ship.Arsenal,
+			Charge:   ship.Charge,
 		})
 	}
 
@@ -1141,6 +1245,7 @@ func (nm *NetworkManager) broadcastWorldState() {
 			VelY:   enemy.VelY,
 			Health: enemy.Health,
 			Angle:  enemy.Angle,
+			Parts:  partHealth(enemy),
 		})
 	}
 
@@ -1180,6 +1285,8 @@ func (nm *NetworkManager) broadcastWorldState() {
 		Enemies:    enemies,
 		Bullets:    bullets,
 		Explosions: explosions,
+		Bomb:       nm.game.Level.Bomb,
+		Obstacles:  obstacleDamage(nm.game.World),
 	}
 
 	stateData, _ := json.Marshal(state)
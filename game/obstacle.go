@@ -0,0 +1,408 @@
+package game
+
+import (
+	"math"
+	"sort"
+
+	"github.com/simukka/starship-sorades-13k/common"
+)
+
+// ObstacleKind is the kind of an obstacle.
+type ObstacleKind uint8
+
+const (
+	// ObstacleAsteroid is a rock of an asteroid belt. Asteroids cannot be
+	// destroyed.
+	ObstacleAsteroid ObstacleKind = iota
+	// ObstacleDebris is a piece of a wreck. It breaks after DebrisHealth
+	// damage.
+	ObstacleDebris
+)
+
+// Obstacle tuning.
+const (
+	// AsteroidMinR and AsteroidMaxR bound the size of an asteroid.
+	AsteroidMinR = 20.0
+	AsteroidMaxR = 70.0
+	// DebrisMinR and DebrisMaxR bound the size of a debris piece.
+	DebrisMinR = 12.0
+	DebrisMaxR = 30.0
+	// DebrisHealth is the damage a debris piece takes before it breaks.
+	DebrisHealth = 4
+	// DebrisFieldScale is the radius of the debris field around a wreck,
+	// relative to the size of the wreck.
+	DebrisFieldScale = 3.0
+	// ObstacleVertices is the number of corners of an obstacle outline.
+	ObstacleVertices = 9
+
+	// ShipObstacleR is the radius of the ship's hull against obstacles.
+	ShipObstacleR = float64(ShipR) / 2
+	// ObstacleBounce is the share of its speed into an obstacle that a ship
+	// keeps when it bounces off.
+	ObstacleBounce = 0.6
+	// ObstacleHurtSpeed is the impact speed from which a ship is damaged.
+	ObstacleHurtSpeed = 4.0
+	// ObstacleDamage is the damage of an impact at ShipMaxSpeed.
+	ObstacleDamage = 20
+
+	// obstacleCell is the cell size of the chunk obstacle grids. Lookups
+	// find every obstacle within obstacleCell of the lookup point, which
+	// covers the largest enemy plus the largest asteroid.
+	obstacleCell = 384.0
+)
+
+// ObstacleID identifies an obstacle the same way on every peer: by its
+// chunk and its index among the chunk's obstacles.
+type ObstacleID struct {
+	Chunk ChunkCoord
+	Index int
+}
+
+// less orders obstacle IDs by chunk, then by index.
+func (id ObstacleID) less(o ObstacleID) bool {
+	if id.Chunk != o.Chunk {
+		return id.Chunk.less(o.Chunk)
+	}
+	return id.Index < o.Index
+}
+
+// Obstacle is an asteroid or a piece of debris. Obstacles never move; they
+// stop bullets, bounce ships off and make enemies steer around them.
+type Obstacle struct {
+	ID     ObstacleID
+	Kind   ObstacleKind
+	X, Y   float64                   // World position
+	R      float64                   // Radius
+	Angle  float64                   // Rotation of the outline
+	Shape  [ObstacleVertices]float64 // Radius of each corner, relative to R
+	Health int                       // Damage debris can still take
+}
+
+// GetPosition implements Collidable.
+func (o *Obstacle) GetPosition() (x, y float64) {
+	return o.X, o.Y
+}
+
+// GetRadius implements Collidable.
+func (o *Obstacle) GetRadius() float64 {
+	return o.R
+}
+
+// Solid reports whether the obstacle is in the way. Broken debris is not.
+func (o *Obstacle) Solid() bool {
+	return o.Kind != ObstacleDebris || o.Health > 0
+}
+
+// addObstacle adds o to the chunk's obstacles and gives it its ID.
+func (c *Chunk) addObstacle(o *Obstacle) {
+	o.ID = ObstacleID{Chunk: c.Coord, Index: len(c.Obstacles)}
+	c.Obstacles = append(c.Obstacles, o)
+}
+
+// newObstacle draws the size and outline of an obstacle at (x, y). The
+// smaller jag is, the more ragged the outline.
+func newObstacle(rng *common.SeededRNG, kind ObstacleKind, x, y, minR, maxR, jag float64) *Obstacle {
+	o := &Obstacle{
+		Kind:  kind,
+		X:     x,
+		Y:     y,
+		R:     rng.RandomFloat(minR, maxR),
+		Angle: rng.RandomFloat(0, 2*math.Pi),
+	}
+	for v := range o.Shape {
+		o.Shape[v] = rng.RandomFloat(jag, 1)
+	}
+	if kind == ObstacleDebris {
+		o.Health = DebrisHealth
+	}
+	return o
+}
+
+// addAsteroids scatters the asteroids of belt along its length, thinning
+// out toward the edges.
+func (c *Chunk) addAsteroids(rng *common.SeededRNG, belt AsteroidBelt) {
+	count := int(belt.Length * belt.Width / 20000)
+	dx, dy := math.Cos(belt.Angle), math.Sin(belt.Angle)
+	for i := 0; i < count; i++ {
+		along := rng.RandomFloat(-0.5, 0.5) * belt.Length
+		// The sum of two draws bunches the rocks around the middle line
+		across := (rng.Random() + rng.Random() - 1) * belt.Width / 2
+		x := belt.X + dx*along - dy*across
+		y := belt.Y + dy*along + dx*across
+		c.addObstacle(newObstacle(rng, ObstacleAsteroid, x, y, AsteroidMinR, AsteroidMaxR, 0.7))
+	}
+}
+
+// addDebris scatters the debris of a wreck around it.
+func (c *Chunk) addDebris(rng *common.SeededRNG, wreck Landmark) {
+	field := wreck.Radius*DebrisFieldScale - DebrisMaxR
+	for i := rng.RandomInt(4, 9); i > 0; i-- {
+		a := rng.RandomFloat(0, 2*math.Pi)
+		d := rng.RandomFloat(wreck.Radius, field)
+		x, y := wreck.X+math.Cos(a)*d, wreck.Y+math.Sin(a)*d
+		c.addObstacle(newObstacle(rng, ObstacleDebris, x, y, DebrisMinR, DebrisMaxR, 0.4))
+	}
+}
+
+// index puts the chunk's obstacles into its grid. Generation keeps all of
+// them inside the chunk.
+func (c *Chunk) index() {
+	c.grid = NewSpatialGrid(int(ChunkSize), int(ChunkSize), obstacleCell)
+	x0, y0 := c.corner()
+	for _, o := range c.Obstacles {
+		c.grid.InsertAt(o, o.X-x0, o.Y-y0)
+	}
+}
+
+// corner returns the world position of the chunk's top left corner.
+func (c *Chunk) corner() (x, y float64) {
+	cx, cy := c.Coord.Center()
+	return cx - ChunkSize/2, cy - ChunkSize/2
+}
+
+// chunksIn returns the loaded chunks overlapping the rectangle from
+// (x0, y0) to (x1, y1), which must be smaller than a chunk.
+func (w *World) chunksIn(x0, y0, x1, y1 float64) []*Chunk {
+	var chunks []*Chunk
+	for _, c := range [...]ChunkCoord{ChunkAt(x0, y0), ChunkAt(x1, y0), ChunkAt(x0, y1), ChunkAt(x1, y1)} {
+		chunk := w.Chunks[c]
+		if chunk == nil {
+			continue
+		}
+		seen := false
+		for _, other := range chunks {
+			seen = seen || other == chunk
+		}
+		if !seen {
+			chunks = append(chunks, chunk)
+		}
+	}
+	return chunks
+}
+
+// ObstaclesNear returns the obstacles of the loaded chunks near (x, y),
+// including every obstacle within obstacleCell. Broken debris is included.
+func (w *World) ObstaclesNear(x, y float64) []*Obstacle {
+	var near []*Obstacle
+	for _, c := range w.chunksIn(x-obstacleCell, y-obstacleCell, x+obstacleCell, y+obstacleCell) {
+		x0, y0 := c.corner()
+		for _, obj := range c.grid.GetNearby(x-x0, y-y0) {
+			near = append(near, obj.(*Obstacle))
+		}
+	}
+	return near
+}
+
+// ObstacleAt returns a solid obstacle that overlaps the circle of radius r
+// around (x, y), or nil.
+func (g *Game) ObstacleAt(x, y, r float64) *Obstacle {
+	for _, o := range g.World.ObstaclesNear(x, y) {
+		if o.Solid() && math.Hypot(x-o.X, y-o.Y) < o.R+r {
+			return o
+		}
+	}
+	return nil
+}
+
+// ObstacleOnRay returns the first solid obstacle on the ray of the given
+// length from (x, y) along the unit vector (dx, dy), and the distance to
+// it. Without a hit it returns nil and length.
+func (g *Game) ObstacleOnRay(x, y, dx, dy, length float64) (*Obstacle, float64) {
+	var hit *Obstacle
+	best := length
+	ex, ey := x+dx*length, y+dy*length
+	chunks := g.World.chunksIn(math.Min(x, ex), math.Min(y, ey), math.Max(x, ex), math.Max(y, ey))
+	for _, c := range chunks {
+		for _, o := range c.Obstacles {
+			if !o.Solid() {
+				continue
+			}
+			if t, ok := rayCircle(x, y, dx, dy, o.X, o.Y, o.R); ok && t < best {
+				hit, best = o, t
+			}
+		}
+	}
+	return hit, best
+}
+
+// obstacleInWay returns the solid obstacle that a move by (vx, vy) takes
+// something of radius r at (x, y) into, or nil. Moves that get out of an
+// obstacle are never blocked, so nothing can get stuck inside one.
+func (g *Game) obstacleInWay(x, y, r, vx, vy float64) *Obstacle {
+	nx, ny := x+vx, y+vy
+	for _, o := range g.World.ObstaclesNear(nx, ny) {
+		if !o.Solid() {
+			continue
+		}
+		d := math.Hypot(nx-o.X, ny-o.Y)
+		if d < o.R+r && d < math.Hypot(x-o.X, y-o.Y) {
+			return o
+		}
+	}
+	return nil
+}
+
+// steerAroundObstacles returns the move (vx, vy) of something of radius r
+// at (x, y), turned just far enough to pass the obstacle in its way. It
+// turns away from the side the obstacle is on first, and reports false
+// when every direction is blocked.
+func (g *Game) steerAroundObstacles(x, y, r, vx, vy float64) (float64, float64, bool) {
+	o := g.obstacleInWay(x, y, r, vx, vy)
+	if o == nil {
+		return vx, vy, true
+	}
+	side := 1.0
+	if vx*(o.Y-y)-vy*(o.X-x) > 0 {
+		side = -1
+	}
+	for _, turn := range [...]float64{math.Pi / 4, math.Pi / 2, math.Pi * 3 / 4} {
+		for _, sign := range [...]float64{side, -side} {
+			sin, cos := math.Sincos(turn * sign)
+			tx, ty := vx*cos-vy*sin, vx*sin+vy*cos
+			if g.obstacleInWay(x, y, r, tx, ty) == nil {
+				return tx, ty, true
+			}
+		}
+	}
+	return 0, 0, false
+}
+
+// bounceOffObstacles pushes the ship out of an obstacle it flew into and
+// reflects its velocity off the surface. Hard impacts damage the ship when
+// hurt is set; network clients only predict the bounce and leave the damage
+// to the host.
+func (s *Ship) bounceOffObstacles(g *Game, hurt bool) {
+	o := g.ObstacleAt(s.X, s.Y, ShipObstacleR)
+	if o == nil {
+		return
+	}
+	nx, ny := s.X-o.X, s.Y-o.Y
+	d := math.Hypot(nx, ny)
+	if d == 0 {
+		nx, ny, d = 0, -1, 1
+	}
+	nx, ny = nx/d, ny/d
+
+	s.X = o.X + nx*(o.R+ShipObstacleR)
+	s.Y = o.Y + ny*(o.R+ShipObstacleR)
+	if s.local {
+		g.Camera.X, g.Camera.Y = s.X, s.Y
+	}
+
+	impact := -(s.VelX*nx + s.VelY*ny)
+	if impact <= 0 {
+		return // Already moving away
+	}
+	s.VelX += nx * impact * (1 + ObstacleBounce)
+	s.VelY += ny * impact * (1 + ObstacleBounce)
+	if s.local {
+		g.Audio.PlayLocal(11, math.Min(impact/ShipMaxSpeed, 1))
+	}
+	if hurt && impact >= ObstacleHurtSpeed {
+		s.Hurt(g, maxInt(int(ObstacleDamage*impact/ShipMaxSpeed), 1))
+	}
+}
+
+// hitObstacle resolves a bullet flying into an obstacle. Torpedoes blow up
+// like on a base shield; player bullets damage debris.
+func (b *Bullet) hitObstacle(g *Game, o *Obstacle) {
+	if b.Hostile() {
+		g.Explode(b.X, b.Y, 0)
+		g.Audio.PlayWithPan(11, b.AudioPan(), b.DistanceVolume(g.Ship.X, g.Ship.Y, float64(HEIGHT)))
+		return
+	}
+	g.DamageObstacle(o, b.Damage())
+}
+
+// DamageObstacle takes damage from a debris piece and breaks it when its
+// health runs out. Asteroids are not damaged.
+func (g *Game) DamageObstacle(o *Obstacle, damage int) {
+	if o.Kind != ObstacleDebris || !o.Solid() {
+		return
+	}
+	o.Health = maxInt(o.Health-damage, 0)
+	g.World.Damage[o.ID] = DebrisHealth - o.Health
+	if o.Health > 0 {
+		return
+	}
+	g.Explode(o.X, o.Y, o.R*3)
+	if g.Camera.IsOnScreen(o.X, o.Y, o.R) {
+		g.Audio.PlayLocal(10, 0.5)
+	}
+}
+
+// applyDamage sets the health of the chunk's debris from the world's
+// damage.
+func (w *World) applyDamage(c *Chunk) {
+	for _, o := range c.Obstacles {
+		if o.Kind == ObstacleDebris {
+			o.Health = DebrisHealth - w.Damage[o.ID]
+		}
+	}
+}
+
+// forgetDamage drops the damage of the obstacles of chunk c.
+func (w *World) forgetDamage(c ChunkCoord) {
+	for id := range w.Damage {
+		if id.Chunk == c {
+			delete(w.Damage, id)
+		}
+	}
+}
+
+// SetDamage replaces the damage of all obstacles, as synced from the host.
+// Damage in chunks that are not loaded here is ignored.
+func (w *World) SetDamage(damage map[ObstacleID]int) {
+	for id := range w.Damage {
+		delete(w.Damage, id)
+	}
+	for id, d := range damage {
+		if _, ok := w.Chunks[id.Chunk]; ok {
+			w.Damage[id] = maxInt(0, min(d, DebrisHealth))
+		}
+	}
+	for _, c := range w.Chunks {
+		w.applyDamage(c)
+	}
+}
+
+// Damaged returns the IDs of the damaged obstacles in a fixed order.
+func (w *World) Damaged() []ObstacleID {
+	ids := make([]ObstacleID, 0, len(w.Damage))
+	for id := range w.Damage {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i].less(ids[j]) })
+	return ids
+}
+
+// Render draws the obstacle outline. Broken debris is not drawn.
+func (o *Obstacle) Render(g *Game) {
+	if !o.Solid() || !g.Camera.IsOnScreen(o.X, o.Y, o.R) {
+		return
+	}
+	x, y := g.Camera.WorldToScreen(o.X, o.Y)
+
+	if o.Kind == ObstacleDebris {
+		g.Ctx.SetFillStyle(Theme.DebrisColor)
+		g.Ctx.SetStrokeStyle(Theme.WreckColor)
+	} else {
+		g.Ctx.SetFillStyle(Theme.AsteroidColor)
+		g.Ctx.SetStrokeStyle(Theme.AsteroidBorder)
+	}
+	g.Ctx.SetLineWidth(Theme.AsteroidLineWidth)
+	g.Ctx.BeginPath()
+	for v, r := range o.Shape {
+		angle := o.Angle + float64(v)*2*math.Pi/ObstacleVertices
+		px := x + math.Cos(angle)*o.R*r
+		py := y + math.Sin(angle)*o.R*r
+		if v == 0 {
+			g.Ctx.MoveTo(px, py)
+		} else {
+			g.Ctx.LineTo(px, py)
+		}
+	}
+	g.Ctx.ClosePath()
+	g.Ctx.Fill()
+	g.Ctx.Stroke()
+}
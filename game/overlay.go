@@ -3,16 +3,17 @@ package game
 import (
 	"math"
 	"strconv"
+	"strings"
 
-	"github.com/gopherjs/gopherjs/js"
+	"github.com/simukka/starship-sorades-13k/render"
 )
 
 // ShipHUD displays ship velocity, angle, and position
 type ShipHUD struct {
 	Visible    bool
-	PanelX     int
-	PanelY     int
-	LineHeight int
+	PanelX     float64
+	PanelY     float64
+	LineHeight float64
 }
 
 // NewShipHUD creates a new ship HUD instance
@@ -31,7 +32,7 @@ func (h *ShipHUD) Toggle() {
 }
 
 // Render draws the ship HUD overlay
-func (h *ShipHUD) Render(ctx *js.Object, ship *Ship) {
+func (h *ShipHUD) Render(ctx render.Renderer, ship *Ship) {
 	if !h.Visible || ship == nil {
 		return
 	}
@@ -45,77 +46,86 @@ func (h *ShipHUD) Render(ctx *js.Object, ship *Ship) {
 	angleDeg := math.Mod(ship.Angle*180/math.Pi+360, 360)
 
 	// Set text style
-	ctx.Set("font", "bold 12px monospace")
-	ctx.Set("textAlign", "left")
-	ctx.Set("shadowBlur", 4)
-	ctx.Set("shadowColor", "#000000")
+	ctx.SetFont("bold 12px monospace")
+	ctx.SetTextAlign("left")
+	ctx.SetShadowBlur(4)
+	ctx.SetShadowColor("#000000")
 
 	// Position
-	ctx.Set("fillStyle", "#00ffff")
-	ctx.Call("fillText", "POS: "+strconv.FormatFloat(ship.X, 'f', 1, 64)+", "+strconv.FormatFloat(ship.Y, 'f', 1, 64), h.PanelX, y)
+	ctx.SetFillStyle("#00ffff")
+	ctx.FillText("POS: "+strconv.FormatFloat(ship.X, 'f', 1, 64)+", "+strconv.FormatFloat(ship.Y, 'f', 1, 64), h.PanelX, y, 0)
 	y += h.LineHeight
 
 	// Velocity
-	ctx.Set("fillStyle", "#ffff00")
-	ctx.Call("fillText", "VEL: "+strconv.FormatFloat(velocity, 'f', 2, 64)+" ("+strconv.FormatFloat(ship.VelX, 'f', 1, 64)+", "+strconv.FormatFloat(ship.VelY, 'f', 1, 64)+")", h.PanelX, y)
+	ctx.SetFillStyle("#ffff00")
+	ctx.FillText("VEL: "+strconv.FormatFloat(velocity, 'f', 2, 64)+" ("+strconv.FormatFloat(ship.VelX, 'f', 1, 64)+", "+strconv.FormatFloat(ship.VelY, 'f', 1, 64)+")", h.PanelX, y, 0)
 	y += h.LineHeight
 
 	// Angle
-	ctx.Set("fillStyle", "#ff88ff")
-	ctx.Call("fillText", "ANG: "+strconv.FormatFloat(angleDeg, 'f', 1, 64)+"°", h.PanelX, y)
+	ctx.SetFillStyle("#ff88ff")
+	ctx.FillText("ANG: "+strconv.FormatFloat(angleDeg, 'f', 1, 64)+"°", h.PanelX, y, 0)
 	y += h.LineHeight
 
 	// Targeting status
 	if ship.Target != nil {
-		ctx.Set("fillStyle", "#ff0000")
-		ctx.Call("fillText", "TGT: LOCKED", h.PanelX, y)
+		ctx.SetFillStyle("#ff0000")
+		ctx.FillText("TGT: LOCKED", h.PanelX, y, 0)
 	} else if ship.LockingOn != nil {
 		// Show lock progress
 		progress := 100 - (ship.LockTimer * 100 / ship.LockMaxTime)
-		ctx.Set("fillStyle", "#ff8800")
-		ctx.Call("fillText", "TGT: LOCKING "+strconv.Itoa(progress)+"%", h.PanelX, y)
+		ctx.SetFillStyle("#ff8800")
+		ctx.FillText("TGT: LOCKING "+strconv.Itoa(progress)+"%", h.PanelX, y, 0)
 	} else {
-		ctx.Set("fillStyle", "#888888")
-		ctx.Call("fillText", "TGT: NONE [T]", h.PanelX, y)
+		ctx.SetFillStyle("#888888")
+		ctx.FillText("TGT: NONE [T]", h.PanelX, y, 0)
 	}
+	y += h.LineHeight
+
+	// Selected weapon, with the charge while charging
+	weapon := "WPN: " + ship.Weapon.String()
+	if ship.Charge > 0 {
+		weapon += " " + strconv.Itoa(ship.Charge*100/ChargeMax) + "%"
+	}
+	ctx.SetFillStyle("#FF5B24")
+	ctx.FillText(weapon, h.PanelX, y, 0)
 
 	// Reset shadow
-	ctx.Set("shadowBlur", 0)
+	ctx.SetShadowBlur(0)
 
 	// Render weapon indicator UI
 	h.RenderWeaponIndicator(ctx, ship)
 }
 
 // RenderWeaponIndicator draws a circular UI showing weapon placements and aim angles
-func (h *ShipHUD) RenderWeaponIndicator(ctx *js.Object, ship *Ship) {
+func (h *ShipHUD) RenderWeaponIndicator(ctx render.Renderer, ship *Ship) {
 	if len(ship.Weapons) == 0 {
 		return
 	}
 
 	// Position the weapon indicator in bottom-left corner
-	centerX := float64(h.PanelX + 60)
+	centerX := h.PanelX + 60
 	centerY := float64(HEIGHT - 80)
 	radius := 50.0
 	weaponDotRadius := 6.0
 
-	ctx.Call("save")
+	ctx.Save()
 
 	// Draw outer ring (ship representation)
-	ctx.Set("strokeStyle", "#444444")
-	ctx.Set("lineWidth", 2)
-	ctx.Call("beginPath")
-	ctx.Call("arc", centerX, centerY, radius, 0, math.Pi*2)
-	ctx.Call("stroke")
+	ctx.SetStrokeStyle("#444444")
+	ctx.SetLineWidth(2)
+	ctx.BeginPath()
+	ctx.Arc(centerX, centerY, radius, 0, math.Pi*2)
+	ctx.Stroke()
 
 	// Draw ship direction indicator (forward arrow)
-	ctx.Set("strokeStyle", "#666666")
-	ctx.Set("lineWidth", 1)
-	ctx.Call("beginPath")
-	ctx.Call("moveTo", centerX, centerY-radius-5)
-	ctx.Call("lineTo", centerX-5, centerY-radius+5)
-	ctx.Call("moveTo", centerX, centerY-radius-5)
-	ctx.Call("lineTo", centerX+5, centerY-radius+5)
-	ctx.Call("stroke")
+	ctx.SetStrokeStyle("#666666")
+	ctx.SetLineWidth(1)
+	ctx.BeginPath()
+	ctx.MoveTo(centerX, centerY-radius-5)
+	ctx.LineTo(centerX-5, centerY-radius+5)
+	ctx.MoveTo(centerX, centerY-radius-5)
+	ctx.LineTo(centerX+5, centerY-radius+5)
+	ctx.Stroke()
 
 	// Calculate angle to target if we have one
 	var targetAngle float64
@@ -137,13 +147,13 @@ func (h *ShipHUD) RenderWeaponIndicator(ctx *js.Object, ship *Ship) {
 
 		// Draw weapon dot
 		if hasTarget {
-			ctx.Set("fillStyle", "#FF5B24") // Vipps orange when targeting
+			ctx.SetFillStyle("#FF5B24") // Vipps orange when targeting
 		} else {
-			ctx.Set("fillStyle", "#888888") // Gray when no target
+			ctx.SetFillStyle("#888888") // Gray when no target
 		}
-		ctx.Call("beginPath")
-		ctx.Call("arc", wx, wy, weaponDotRadius, 0, math.Pi*2)
-		ctx.Call("fill")
+		ctx.BeginPath()
+		ctx.Arc(wx, wy, weaponDotRadius, 0, math.Pi*2)
+		ctx.Fill()
 
 		// Draw aim line toward target
 		if hasTarget {
@@ -152,14 +162,14 @@ func (h *ShipHUD) RenderWeaponIndicator(ctx *js.Object, ship *Ship) {
 			aimX := wx + math.Sin(targetAngle)*aimLength
 			aimY := wy - math.Cos(targetAngle)*aimLength
 
-			ctx.Set("strokeStyle", "#FF5B24")
-			ctx.Set("lineWidth", 1.5)
-			ctx.Set("globalAlpha", 0.7)
-			ctx.Call("beginPath")
-			ctx.Call("moveTo", wx, wy)
-			ctx.Call("lineTo", aimX, aimY)
-			ctx.Call("stroke")
-			ctx.Set("globalAlpha", 1)
+			ctx.SetStrokeStyle("#FF5B24")
+			ctx.SetLineWidth(1.5)
+			ctx.SetGlobalAlpha(0.7)
+			ctx.BeginPath()
+			ctx.MoveTo(wx, wy)
+			ctx.LineTo(aimX, aimY)
+			ctx.Stroke()
+			ctx.SetGlobalAlpha(1)
 		}
 	}
 
@@ -168,32 +178,32 @@ func (h *ShipHUD) RenderWeaponIndicator(ctx *js.Object, ship *Ship) {
 		targetX := centerX + math.Sin(targetAngle)*radius
 		targetY := centerY - math.Cos(targetAngle)*radius
 
-		ctx.Set("fillStyle", "#ff0000")
-		ctx.Set("shadowBlur", 6)
-		ctx.Set("shadowColor", "#ff0000")
-		ctx.Call("beginPath")
-		ctx.Call("arc", targetX, targetY, 5, 0, math.Pi*2)
-		ctx.Call("fill")
-		ctx.Set("shadowBlur", 0)
+		ctx.SetFillStyle("#ff0000")
+		ctx.SetShadowBlur(6)
+		ctx.SetShadowColor("#ff0000")
+		ctx.BeginPath()
+		ctx.Arc(targetX, targetY, 5, 0, math.Pi*2)
+		ctx.Fill()
+		ctx.SetShadowBlur(0)
 
 		// Draw crosshair at target position
-		ctx.Set("strokeStyle", "#ff0000")
-		ctx.Set("lineWidth", 1)
-		ctx.Call("beginPath")
-		ctx.Call("moveTo", targetX-8, targetY)
-		ctx.Call("lineTo", targetX+8, targetY)
-		ctx.Call("moveTo", targetX, targetY-8)
-		ctx.Call("lineTo", targetX, targetY+8)
-		ctx.Call("stroke")
+		ctx.SetStrokeStyle("#ff0000")
+		ctx.SetLineWidth(1)
+		ctx.BeginPath()
+		ctx.MoveTo(targetX-8, targetY)
+		ctx.LineTo(targetX+8, targetY)
+		ctx.MoveTo(targetX, targetY-8)
+		ctx.LineTo(targetX, targetY+8)
+		ctx.Stroke()
 	}
 
 	// Draw center dot (ship position)
-	ctx.Set("fillStyle", "#FF5B24")
-	ctx.Call("beginPath")
-	ctx.Call("arc", centerX, centerY, 4, 0, math.Pi*2)
-	ctx.Call("fill")
+	ctx.SetFillStyle("#FF5B24")
+	ctx.BeginPath()
+	ctx.Arc(centerX, centerY, 4, 0, math.Pi*2)
+	ctx.Fill()
 
-	ctx.Call("restore")
+	ctx.Restore()
 }
 
 // StatsOverlay displays real-time game statistics
@@ -206,11 +216,11 @@ type StatsOverlay struct {
 	CurrentFPS    float64
 
 	// Position and styling
-	PanelX      int
-	PanelY      int
-	LineHeight  int
-	PanelWidth  int
-	PanelHeight int
+	PanelX      float64
+	PanelY      float64
+	LineHeight  float64
+	PanelWidth  float64
+	PanelHeight float64
 }
 
 // NewStatsOverlay creates a new stats overlay instance
@@ -246,35 +256,35 @@ func (s *StatsOverlay) UpdateFPS(currentTime float64) {
 }
 
 // Render draws the stats overlay
-func (s *StatsOverlay) Render(ctx *js.Object, g *Game) {
+func (s *StatsOverlay) Render(ctx render.Renderer, g *Game) {
 	if !s.Visible {
 		return
 	}
 
 	// Draw stats panel background
-	ctx.Set("fillStyle", "rgba(0, 0, 0, 0.75)")
-	ctx.Call("fillRect", s.PanelX, s.PanelY, s.PanelWidth, s.PanelHeight)
+	ctx.SetFillStyle("rgba(0, 0, 0, 0.75)")
+	ctx.FillRect(s.PanelX, s.PanelY, s.PanelWidth, s.PanelHeight)
 
 	// Draw panel border
-	ctx.Set("strokeStyle", "#00aaff")
-	ctx.Set("lineWidth", 1)
-	ctx.Call("strokeRect", s.PanelX, s.PanelY, s.PanelWidth, s.PanelHeight)
+	ctx.SetStrokeStyle("#00aaff")
+	ctx.SetLineWidth(1)
+	ctx.StrokeRect(s.PanelX, s.PanelY, s.PanelWidth, s.PanelHeight)
 
 	// Title
-	ctx.Set("fillStyle", "#00aaff")
-	ctx.Set("font", "bold 14px monospace")
-	ctx.Set("textAlign", "left")
-	ctx.Call("fillText", "GAME STATS [F10]", s.PanelX+10, s.PanelY+20)
+	ctx.SetFillStyle("#00aaff")
+	ctx.SetFont("bold 14px monospace")
+	ctx.SetTextAlign("left")
+	ctx.FillText("GAME STATS [F10]", s.PanelX+10, s.PanelY+20, 0)
 
 	// Separator
-	ctx.Set("strokeStyle", "#444444")
-	ctx.Call("beginPath")
-	ctx.Call("moveTo", s.PanelX+10, s.PanelY+28)
-	ctx.Call("lineTo", s.PanelX+s.PanelWidth-10, s.PanelY+28)
-	ctx.Call("stroke")
+	ctx.SetStrokeStyle("#444444")
+	ctx.BeginPath()
+	ctx.MoveTo(s.PanelX+10, s.PanelY+28)
+	ctx.LineTo(s.PanelX+s.PanelWidth-10, s.PanelY+28)
+	ctx.Stroke()
 
 	// Stats content
-	ctx.Set("font", "12px monospace")
+	ctx.SetFont("12px monospace")
 	y := s.PanelY + 48
 
 	// Performance stats
@@ -283,8 +293,8 @@ func (s *StatsOverlay) Render(ctx *js.Object, g *Game) {
 
 	// Separator - Performance
 	y += 5
-	ctx.Set("fillStyle", "#666666")
-	ctx.Call("fillText", "── Game State ──", s.PanelX+10, y)
+	ctx.SetFillStyle("#666666")
+	ctx.FillText("── Game State ──", s.PanelX+10, y, 0)
 	y += s.LineHeight
 
 	s.drawStatLine(ctx, "Game Seed", strconv.FormatUint(uint64(g.GameSeed), 10), "#aaaaaa", y)
@@ -292,8 +302,8 @@ func (s *StatsOverlay) Render(ctx *js.Object, g *Game) {
 
 	// Separator - Objects
 	y += 5
-	ctx.Set("fillStyle", "#666666")
-	ctx.Call("fillText", "── Object Pools ──", s.PanelX+10, y)
+	ctx.SetFillStyle("#666666")
+	ctx.FillText("── Object Pools ──", s.PanelX+10, y, 0)
 	y += s.LineHeight
 
 	// Object counts
@@ -314,8 +324,8 @@ func (s *StatsOverlay) Render(ctx *js.Object, g *Game) {
 
 	// Separator - Multiplayer
 	y += 5
-	ctx.Set("fillStyle", "#666666")
-	ctx.Call("fillText", "── Multiplayer ──", s.PanelX+10, y)
+	ctx.SetFillStyle("#666666")
+	ctx.FillText("── Multiplayer ──", s.PanelX+10, y, 0)
 	y += s.LineHeight
 
 	// Multiplayer stats
@@ -334,14 +344,14 @@ func (s *StatsOverlay) Render(ctx *js.Object, g *Game) {
 }
 
 // drawStatLine draws a single stat line with label and value
-func (s *StatsOverlay) drawStatLine(ctx *js.Object, label, value, valueColor string, y int) {
-	ctx.Set("fillStyle", "#cccccc")
-	ctx.Call("fillText", label+":", s.PanelX+15, y)
-
-	ctx.Set("fillStyle", valueColor)
-	ctx.Set("textAlign", "right")
-	ctx.Call("fillText", value, s.PanelX+s.PanelWidth-15, y)
-	ctx.Set("textAlign", "left")
+func (s *StatsOverlay) drawStatLine(ctx render.Renderer, label, value, valueColor string, y float64) {
+	ctx.SetFillStyle("#cccccc")
+	ctx.FillText(label+":", s.PanelX+15, y, 0)
+
+	ctx.SetFillStyle(valueColor)
+	ctx.SetTextAlign("right")
+	ctx.FillText(value, s.PanelX+s.PanelWidth-15, y, 0)
+	ctx.SetTextAlign("left")
 }
 
 // healthColor returns a color based on health percentage
@@ -356,3 +366,301 @@ func (s *StatsOverlay) healthColor(health int) string {
 		return "#ff0000"
 	}
 }
+
+// Render draws the on-screen joystick and buttons on touch devices.
+func (t *TouchInput) Render(ctx render.Renderer) {
+	if !t.Enabled {
+		return
+	}
+
+	ctx.Save()
+	ctx.SetLineWidth(4)
+	ctx.SetStrokeStyle(Theme.TouchControlColor)
+
+	// Joystick ring and knob
+	ctx.BeginPath()
+	ctx.Arc(TouchStickX, TouchStickY, TouchStickRadius, 0, 2*math.Pi)
+	ctx.Stroke()
+	x, y := t.Stick()
+	t.renderControl(ctx, TouchStickX+x*TouchStickRadius, TouchStickY+y*TouchStickRadius,
+		TouchKnobRadius, "", t.holding(touchStick))
+
+	// Buttons
+	t.renderControl(ctx, TouchFireX, TouchFireY, TouchFireRadius, "FIRE", t.holding(touchFire))
+	t.renderControl(ctx, TouchLockX, TouchLockY, TouchLockRadius, "LOCK", t.holding(touchLock))
+	ctx.Restore()
+}
+
+// renderControl draws a round touch control, highlighted while pressed.
+func (t *TouchInput) renderControl(ctx render.Renderer, x, y, r float64, label string, pressed bool) {
+	color := Theme.TouchControlColor
+	if pressed {
+		color = Theme.TouchControlActiveColor
+	}
+	ctx.SetFillStyle(color)
+	ctx.BeginPath()
+	ctx.Arc(x, y, r, 0, 2*math.Pi)
+	ctx.Fill()
+
+	if label != "" {
+		ctx.SetFont("bold 24px monospace")
+		ctx.SetTextAlign("center")
+		ctx.SetTextBaseline("middle")
+		ctx.SetFillStyle("#ffffff")
+		ctx.FillText(label, x, y, 0)
+	}
+}
+
+// Rebinding screen layout
+const (
+	bindingsPanelWidth = 640.0
+	bindingsRowHeight  = 32.0
+)
+
+// Render draws the rebinding screen over the game while it is open.
+func (s *BindingsScreen) Render(ctx render.Renderer, b Bindings) {
+	if !s.Visible {
+		return
+	}
+
+	height := float64(ActionCount+4) * bindingsRowHeight
+	left := (WIDTH - bindingsPanelWidth) / 2
+	top := (HEIGHT - height) / 2
+
+	ctx.Save()
+	ctx.SetFillStyle("rgba(0, 0, 0, 0.85)")
+	ctx.FillRect(left, top, bindingsPanelWidth, height)
+	ctx.SetStrokeStyle(Theme.TextPrimaryColor)
+	ctx.SetLineWidth(2)
+	ctx.StrokeRect(left, top, bindingsPanelWidth, height)
+
+	ctx.SetFont("bold 24px monospace")
+	ctx.SetTextAlign("center")
+	ctx.SetFillStyle(Theme.TextSecondaryColor)
+	ctx.FillText("CONTROLS", WIDTH/2, top+bindingsRowHeight*1.25, 0)
+
+	ctx.SetFont("bold 16px monospace")
+	y := top + bindingsRowHeight*2.5
+	for a := Action(0); a < ActionCount; a++ {
+		if a == s.Selected {
+			ctx.SetFillStyle(Theme.TextPrimaryColor)
+			ctx.FillRect(left+8, y-bindingsRowHeight*0.7, bindingsPanelWidth-16, bindingsRowHeight)
+		}
+
+		keys := "-"
+		if a == s.Selected && s.Waiting {
+			keys = "PRESS A KEY"
+		} else if codes := b.KeysFor(a); len(codes) > 0 {
+			keys = ""
+			for i, code := range codes {
+				if i > 0 {
+					keys += ", "
+				}
+				keys += KeyName(code)
+			}
+		}
+
+		ctx.SetFillStyle(Theme.TextSecondaryColor)
+		ctx.SetTextAlign("left")
+		ctx.FillText(a.String(), left+24, y, 0)
+		ctx.SetTextAlign("right")
+		ctx.FillText(keys, left+bindingsPanelWidth-24, y, bindingsPanelWidth/2)
+		y += bindingsRowHeight
+	}
+
+	ctx.SetFont("12px monospace")
+	ctx.SetTextAlign("center")
+	ctx.SetFillStyle("#888888")
+	ctx.FillText("UP/DOWN SELECT  ENTER REBIND  DEL CLEAR  HOME DEFAULTS  ESC CLOSE",
+		WIDTH/2, top+height-bindingsRowHeight*0.6, 0)
+	ctx.Restore()
+}
+
+// Render draws the shop over the game while it is open, and otherwise
+// reminds a docked player that it can be opened.
+func (s *ShopScreen) Render(ctx render.Renderer, g *Game) {
+	ship := g.Ship
+	if !s.Visible {
+		if g.State == StatePlaying && g.Playback == nil && ship.InBase {
+			ctx.Save()
+			renderScreenHint(ctx, "DOCKED - PRESS "+keyHint(g.Keyboard.Bindings, ActionShop)+" FOR THE SHOP")
+			ctx.Restore()
+		}
+		return
+	}
+
+	height := float64(UpgradeCount+5) * bindingsRowHeight
+	left := (WIDTH - bindingsPanelWidth) / 2
+	top := (HEIGHT - height) / 2
+
+	ctx.Save()
+	ctx.SetFillStyle("rgba(0, 0, 0, 0.85)")
+	ctx.FillRect(left, top, bindingsPanelWidth, height)
+	ctx.SetStrokeStyle(Theme.TextPrimaryColor)
+	ctx.SetLineWidth(2)
+	ctx.StrokeRect(left, top, bindingsPanelWidth, height)
+
+	ctx.SetFont("bold 24px monospace")
+	ctx.SetTextAlign("center")
+	ctx.SetFillStyle(Theme.TextSecondaryColor)
+	ctx.FillText("BASE SHOP", WIDTH/2, top+bindingsRowHeight*1.25, 0)
+
+	ctx.SetFont("bold 16px monospace")
+	ctx.FillText("POINTS "+strconv.Itoa(ship.Points), WIDTH/2, top+bindingsRowHeight*2.25, 0)
+
+	y := top + bindingsRowHeight*3.5
+	for u := Upgrade(0); u < UpgradeCount; u++ {
+		if u == s.Selected {
+			ctx.SetFillStyle(Theme.TextPrimaryColor)
+			ctx.FillRect(left+8, y-bindingsRowHeight*0.7, bindingsPanelWidth-16, bindingsRowHeight)
+		}
+
+		level := "LV " + strconv.Itoa(ship.Upgrades[u]) + "/" + strconv.Itoa(UpgradeMaxLevel)
+		price := "MAX"
+		ctx.SetFillStyle(Theme.TextSecondaryColor)
+		if p, ok := ship.UpgradePrice(u); ok {
+			price = strconv.Itoa(p)
+			if p > ship.Points {
+				ctx.SetFillStyle("#888888") // Can't afford
+			}
+		}
+		ctx.SetTextAlign("left")
+		ctx.FillText(u.String(), left+24, y, 0)
+		ctx.FillText(level, left+bindingsPanelWidth*0.55, y, 0)
+		ctx.SetTextAlign("right")
+		ctx.FillText(price, left+bindingsPanelWidth-24, y, 0)
+		y += bindingsRowHeight
+	}
+
+	ctx.SetFont("12px monospace")
+	ctx.SetTextAlign("center")
+	ctx.SetFillStyle("#888888")
+	ctx.FillText("UP/DOWN SELECT  ENTER BUY  ESC CLOSE",
+		WIDTH/2, top+height-bindingsRowHeight*0.6, 0)
+	ctx.Restore()
+}
+
+// RenderStateScreen draws the title, pause, game over or victory screen
+// over the game. Key hints follow the current bindings.
+func (g *Game) RenderStateScreen(ctx render.Renderer) {
+	b := g.Keyboard.Bindings
+
+	switch g.State {
+	case StateTitle:
+		renderScreenTitle(ctx, "SORADES 13K", 0.5)
+
+		modes := []struct {
+			mode  GameMode
+			label string
+		}{
+			{ModeInfinite, "INFINITE WORLD"},
+			{ModeCampaign, "CAMPAIGN - " + strconv.Itoa(len(CampaignWaves)) + " WAVES"},
+		}
+		ctx.SetFont("bold 32px monospace")
+		for i, m := range modes {
+			label := m.label
+			ctx.SetFillStyle("#888888")
+			if m.mode == g.Title.Selected {
+				label = "> " + label + " <"
+				ctx.SetFillStyle(Theme.TextSecondaryColor)
+			}
+			ctx.FillText(label, WIDTH/2, HEIGHT/2+float64(i)*48, 0)
+		}
+
+		// Difficulty, with arrows toward the ones left to pick
+		d := g.Title.Difficulty
+		label := strings.ToUpper(d.String())
+		if d.Easier() != d {
+			label = "< " + label
+		}
+		if d.Harder() != d {
+			label += " >"
+		}
+		ctx.SetFont("bold 24px monospace")
+		ctx.SetFillStyle(Theme.TextPrimaryColor)
+		ctx.FillText(label, WIDTH/2, HEIGHT/2+float64(len(modes))*48+24, 0)
+
+		// Blink once per second
+		if g.Tick%30 < 20 {
+			renderScreenHint(ctx, keyHint(b, ActionThrust)+"/"+keyHint(b, ActionReverse)+" SELECT - "+
+				keyHint(b, ActionRotateLeft)+"/"+keyHint(b, ActionRotateRight)+" DIFFICULTY - ANY KEY TO START")
+		}
+
+	case StatePaused:
+		renderScreenTitle(ctx, "PAUSED", 0.6)
+		renderScreenHint(ctx, "PRESS "+keyHint(b, ActionPause)+" TO RESUME")
+
+	case StateGameOver, StateVictory:
+		title := "GAME OVER"
+		if g.State == StateVictory {
+			title = "VICTORY"
+		}
+		renderScreenTitle(ctx, title, 0.6)
+
+		// Scores only compare within a difficulty
+		result := "SCORE " + strconv.Itoa(g.Ship.Points) + " - " + strings.ToUpper(g.Difficulty.String())
+		if g.Campaign != nil {
+			result = "WAVE " + strconv.Itoa(g.Campaign.Wave+1) + " - " + result
+		}
+		ctx.SetFont("bold 32px monospace")
+		ctx.SetFillStyle(Theme.TextSecondaryColor)
+		ctx.FillText(result, WIDTH/2, HEIGHT/2, 0)
+
+		if g.Tick-g.GameOverTick >= GameOverDelay {
+			renderScreenHint(ctx, "PRESS "+keyHint(b, ActionFire)+" TO RESTART - "+keyHint(b, ActionPause)+" FOR TITLE")
+		}
+	}
+	ctx.SetTextAlign("left")
+}
+
+// renderScreenTitle dims the game and draws a screen's title centered above
+// the middle of the screen, at the given opacity of the dimming.
+func renderScreenTitle(ctx render.Renderer, title string, dim float64) {
+	ctx.SetFillStyle("rgba(0, 0, 0, " + strconv.FormatFloat(dim, 'f', 2, 64) + ")")
+	ctx.FillRect(0, 0, WIDTH, HEIGHT)
+
+	ctx.SetFont("bold 96px " + Theme.TextFont)
+	ctx.SetTextAlign("center")
+	ctx.SetFillStyle(Theme.TextPrimaryColor)
+	ctx.FillText(title, WIDTH/2, HEIGHT/3, 0)
+}
+
+// renderScreenHint draws the controls of a screen near the bottom.
+func renderScreenHint(ctx render.Renderer, hint string) {
+	ctx.SetFont("bold 16px monospace")
+	ctx.SetTextAlign("center")
+	ctx.SetFillStyle("#ffffff")
+	ctx.FillText(hint, WIDTH/2, HEIGHT-80, 0)
+}
+
+// keyHint names the first key bound to a, or "-" if it has none.
+func keyHint(b Bindings, a Action) string {
+	if keys := b.KeysFor(a); len(keys) > 0 {
+		return KeyName(keys[0])
+	}
+	return "-"
+}
+
+// RenderReplayStatus draws the playback position, speed and pause state
+// while a replay is running.
+func (g *Game) RenderReplayStatus() {
+	p := g.Playback
+	if p == nil {
+		return
+	}
+
+	status := "REPLAY " + strconv.FormatUint(uint64(g.Tick), 10) + "/" + strconv.Itoa(p.Replay.Ticks())
+	if p.Finished(g) {
+		status += " END"
+	} else if p.Paused {
+		status += " PAUSED [P] STEP [RIGHT]"
+	} else {
+		status += " x" + strconv.Itoa(p.Speed) + " [UP/DOWN]"
+	}
+
+	g.Ctx.SetFont("bold 12px monospace")
+	g.Ctx.SetTextAlign("center")
+	g.Ctx.SetFillStyle("#ffffff")
+	g.Ctx.FillText(status, WIDTH/2, 20, 0)
+	g.Ctx.SetTextAlign("left")
+}
@@ -141,12 +141,15 @@ func (p *BulletPool) Acquire() *Bullet {
 	return b
 }
 
+// AcquireKind gets an available bullet of the given kind from the pool.
+// The bullet is zeroed, so no state leaks from its previous use and a
+// reset game replays exactly like a fresh one.
 func (p *BulletPool) AcquireKind(kind BulletKind) *Bullet {
 	b := p.Acquire()
 	if b == nil {
 		return nil
 	}
-	b.Kind = kind
+	*b = Bullet{PoolIndex: b.PoolIndex, Kind: kind}
 	return b
 }
 
@@ -1,12 +1,21 @@
 package game
 
-import "github.com/gopherjs/gopherjs/js"
+import (
+	"math"
+
+	"github.com/simukka/starship-sorades-13k/render"
+)
 
 type BulletKind int
 
 const (
 	StandardBullet BulletKind = iota
 	TorpedoBullet
+	MineBullet    // Stationary, dropped by mine layers
+	MissileBullet // Player missile, homes in on enemies
+	LaserBullet   // Player laser beam, shown for a single tick
+	ChargeBullet  // Player charge shot, damage in E
+	BulletKindCount
 )
 
 // Bullet represents a player projectile.
@@ -27,12 +36,39 @@ func (b *Bullet) GetPosition() (x, y float64) {
 
 // GetRadius implements Collidable interface.
 func (b *Bullet) GetRadius() float64 {
-	if b.Kind == TorpedoBullet {
+	switch b.Kind {
+	case TorpedoBullet:
 		return TorpedoR
+	case MineBullet:
+		return MineR
+	case MissileBullet:
+		return MissileR
+	case ChargeBullet:
+		return ChargeR
 	}
 	return BulletR
 }
 
+// Hostile reports whether the bullet was fired by an enemy. Player bullets
+// can shoot hostile bullets down, and bombs clear them.
+func (b *Bullet) Hostile() bool {
+	return b.Kind == TorpedoBullet || b.Kind == MineBullet
+}
+
+// Damage returns the damage a player bullet does to what it hits. Missiles
+// and charge shots carry theirs in E.
+func (b *Bullet) Damage() int {
+	if b.Kind == MissileBullet || b.Kind == ChargeBullet {
+		return b.E
+	}
+	return 1
+}
+
+// Reach returns how much further than a standard bullet the bullet hits.
+func (b *Bullet) Reach() float64 {
+	return b.GetRadius() - BulletR
+}
+
 // AudioPan returns a pan value (-1.0 to 1.0) based on the X position.
 // Left edge = -1.0, center = 0.0, right edge = 1.0
 func (b *Bullet) AudioPan() float64 {
@@ -55,46 +91,40 @@ func (b *Bullet) DistanceVolume(targetX, targetY, maxDist float64) float64 {
 	return 1.0 - (dist/maxDistSq)*0.8
 }
 
-// Render draws the bullet and updates its position each frame.
+// Update moves the bullet and resolves its collisions for one tick.
 //
 // The method handles two bullet kinds with different behaviors:
 //
-// StandardBullet (player projectile):
-//   - Uses the standard bullet image and BulletR radius
-//   - Has a limited lifetime (T frames) and is removed when expired
-//   - Checks collision with enemies; on hit, the bullet is removed
-//   - Removed when off-screen (left, right, or bottom edges)
+// StandardBullet, MissileBullet and ChargeBullet (player projectiles):
+//   - Have a limited lifetime (T frames) and are removed when expired
+//   - Check collision with enemies; on hit, the bullet is removed
+//   - Are stopped by obstacles and damage debris
+//   - Missiles first turn toward the nearest enemy
+//
+// LaserBullet (player laser beam):
+//   - Does not move; it only shows the beam the ship fired this tick
+//   - Is removed after its lifetime (T frames); hits are resolved by the ship
 //
 // TorpedoBullet (enemy projectile):
-//   - Uses animated torpedo images and TorpedoR radius
-//   - Has no lifetime limit; persists until off-screen or collision
+//   - Has no lifetime limit; persists until despawned or collision
+//   - Is stopped by base shields and obstacles
 //   - Checks collision with player ships; on hit, the bullet is removed
-//   - Removed when off-screen (any edge, with extended top boundary for spawning)
 //
+// MineBullet (dropped by mine layers):
+//   - Does not move and is removed when its lifetime (T frames) runs out
+//   - Goes off when a player ship comes close; the mine is then removed
+//
+// All kinds are removed once they travel too far from the camera.
 // Returns true if the bullet should remain active, false if it should be released.
-func (b *Bullet) Render(g *Game) bool {
-	var image *js.Object
-	var projectileR float64
-
-	if b.Kind == StandardBullet {
-		image = g.BulletImage
-		projectileR = BulletR
+func (b *Bullet) Update(g *Game) bool {
+	if b.Kind == MissileBullet {
+		b.steerMissile(g)
 	}
-	if b.Kind == TorpedoBullet {
-		image = g.TorpedoImages[g.TorpedoFrame]
-		projectileR = TorpedoR
-	}
-
-	// Update position
-	b.X += b.XAcc
-	b.Y += b.YAcc
-
-	// Convert world position to screen position
-	screenX, screenY := g.Camera.WorldToScreen(b.X, b.Y)
 
-	// Only render if on screen
-	if g.Camera.IsOnScreen(b.X, b.Y, projectileR) {
-		g.Ctx.Call("drawImage", image, screenX-projectileR, screenY-projectileR)
+	// Update position; the laser beam vector is not a velocity
+	if b.Kind != LaserBullet {
+		b.X += b.XAcc
+		b.Y += b.YAcc
 	}
 
 	// Check if bullet is too far from camera (despawn in infinite world)
@@ -106,6 +136,14 @@ func (b *Bullet) Render(g *Game) bool {
 		return false
 	}
 
+	// Asteroids and debris stop everything that flies
+	if b.Kind != LaserBullet && b.Kind != MineBullet {
+		if o := g.ObstacleAt(b.X, b.Y, b.Reach()); o != nil {
+			b.hitObstacle(g, o)
+			return false
+		}
+	}
+
 	// TODO: refactor the Ship and Enemy objects into a single
 	// interface
 	if b.Kind == TorpedoBullet {
@@ -125,7 +163,26 @@ func (b *Bullet) Render(g *Game) bool {
 		}
 	}
 
-	if b.Kind == StandardBullet {
+	if b.Kind == MineBullet {
+		// remove expired or shot down mines
+		b.T--
+		if b.T < 0 {
+			return false
+		}
+
+		for _, s := range g.Ships {
+			if s.Collision(g, b) {
+				return false
+			}
+		}
+	}
+
+	if b.Kind == LaserBullet {
+		b.T--
+		return b.T >= 0
+	}
+
+	if !b.Hostile() {
 		// remove expired bullets
 		b.T--
 		if b.T < 0 {
@@ -142,6 +199,78 @@ func (b *Bullet) Render(g *Game) bool {
 	return true
 }
 
+// Render draws the bullet sprite if it is on screen.
+// Standard bullets use BulletImage, torpedos the current TorpedoImages frame,
+// mines MineImage, missiles MissileImage turned along their heading and
+// charge shots ChargeImage. Laser beams are drawn as lines.
+func (b *Bullet) Render(g *Game) {
+	var image render.Image
+	var projectileR float64
+
+	if b.Kind == LaserBullet {
+		b.renderBeam(g)
+		return
+	}
+	if b.Kind == MissileBullet {
+		if g.Camera.IsOnScreen(b.X, b.Y, MissileR) {
+			screenX, screenY := g.Camera.WorldToScreen(b.X, b.Y)
+			g.Ctx.Save()
+			g.Ctx.Translate(screenX, screenY)
+			g.Ctx.Rotate(math.Atan2(b.XAcc, -b.YAcc))
+			g.Ctx.DrawImage(g.MissileImage, -MissileR, -MissileR)
+			g.Ctx.Restore()
+		}
+		return
+	}
+	if b.Kind == ChargeBullet {
+		image = g.ChargeImage
+		projectileR = ChargeR
+	}
+
+	if b.Kind == StandardBullet {
+		image = g.BulletImage
+		projectileR = BulletR
+	}
+	if b.Kind == TorpedoBullet {
+		image = g.TorpedoImages[g.TorpedoFrame]
+		projectileR = TorpedoR
+	}
+	if b.Kind == MineBullet {
+		image = g.MineImage
+		projectileR = MineR
+	}
+
+	// Only render if on screen
+	if g.Camera.IsOnScreen(b.X, b.Y, projectileR) {
+		// Convert world position to screen position
+		screenX, screenY := g.Camera.WorldToScreen(b.X, b.Y)
+		g.Ctx.DrawImage(image, screenX-projectileR, screenY-projectileR)
+	}
+}
+
+// renderBeam draws a laser beam from (X, Y) along (XAcc, YAcc).
+func (b *Bullet) renderBeam(g *Game) {
+	length := math.Hypot(b.XAcc, b.YAcc)
+	if !g.Camera.IsOnScreen(b.X+b.XAcc/2, b.Y+b.YAcc/2, length/2) {
+		return
+	}
+	x1, y1 := g.Camera.WorldToScreen(b.X, b.Y)
+	x2, y2 := g.Camera.WorldToScreen(b.X+b.XAcc, b.Y+b.YAcc)
+	g.Ctx.Save()
+	g.Ctx.SetShadowBlur(Theme.BulletShadowBlur)
+	g.Ctx.SetShadowColor(Theme.LaserGlow)
+	g.Ctx.SetStrokeStyle(Theme.LaserColor)
+	g.Ctx.SetLineWidth(Theme.LaserLineWidth)
+	g.Ctx.BeginPath()
+	g.Ctx.MoveTo(x1, y1)
+	g.Ctx.LineTo(x2, y2)
+	g.Ctx.Stroke()
+	g.Ctx.SetStrokeStyle(Theme.LaserCoreColor)
+	g.Ctx.SetLineWidth(Theme.LaserLineWidth / 3)
+	g.Ctx.Stroke()
+	g.Ctx.Restore()
+}
+
 // Has this projection collided with another?
 func (b *Bullet) Collision(g *Game, torpedo *Bullet) bool {
 
@@ -0,0 +1,213 @@
+package game
+
+import (
+	"math"
+
+	"github.com/simukka/starship-sorades-13k/render"
+)
+
+// RadarRanges are the ranges the radar cycles through: the world distance
+// shown at its edge. The default range covers EnemySpawnDistance with some
+// room, so new enemies appear on the radar as they spawn.
+var RadarRanges = [...]float64{
+	EnemySpawnDistance / 2,
+	EnemySpawnDistance * 1.25,
+	EnemySpawnDistance * 2.5,
+}
+
+// radarDefaultRange is the index in RadarRanges the radar starts with.
+const radarDefaultRange = 1
+
+// Radar is a circular minimap next to the ship HUD. It shows enemies in the
+// color of their kind, torpedoes and mines, bases, the other players'
+// ships and the locked target around the local ship.
+type Radar struct {
+	Visible bool
+	Range   float64 // World distance at the edge of the radar
+	X, Y    float64 // Screen position of the center
+	Radius  float64
+}
+
+// NewRadar creates a radar in the bottom left corner, right of the weapon
+// indicator of the ship HUD.
+func NewRadar() *Radar {
+	return &Radar{
+		Visible: true,
+		Range:   RadarRanges[radarDefaultRange],
+		X:       240,
+		Y:       float64(HEIGHT) - 120,
+		Radius:  90,
+	}
+}
+
+// Cycle switches to the next larger range. After the largest range the
+// radar is hidden, and the next cycle shows it again at the smallest range.
+func (r *Radar) Cycle() {
+	if !r.Visible {
+		r.Visible = true
+		r.Range = RadarRanges[0]
+		return
+	}
+	for _, rng := range RadarRanges {
+		if rng > r.Range {
+			r.Range = rng
+			return
+		}
+	}
+	r.Visible = false
+}
+
+// Project returns the radar position of a world position relative to the
+// world position (cx, cy) at the radar's center, and whether it is within
+// range. Positions out of range are pinned to the edge.
+func (r *Radar) Project(cx, cy, x, y float64) (px, py float64, inRange bool) {
+	scale := r.Radius / r.Range
+	dx, dy := (x-cx)*scale, (y-cy)*scale
+	if d := math.Hypot(dx, dy); d > r.Radius {
+		return r.X + dx*r.Radius/d, r.Y + dy*r.Radius/d, false
+	}
+	return r.X + dx, r.Y + dy, true
+}
+
+// enemyColor returns the color of an enemy kind's sprite.
+func enemyColor(kind EnemyKind) string {
+	switch kind {
+	case SmallFighter:
+		return Theme.EnemySmallColor
+	case MediumFighter:
+		return Theme.EnemyMediumColor
+	case TurretFighter:
+		return Theme.EnemyTurretColor
+	case Boss:
+		return Theme.EnemyBossColor
+	case Kamikaze:
+		return Theme.EnemyKamikazeColor
+	case MineLayer:
+		return Theme.EnemyMineLayerColor
+	case Carrier:
+		return Theme.EnemyCarrierColor
+	}
+	return Theme.EnemyColor
+}
+
+// Render draws the radar around the local ship.
+func (r *Radar) Render(ctx render.Renderer, g *Game) {
+	if !r.Visible || g.Ship == nil {
+		return
+	}
+	ship := g.Ship
+
+	ctx.Save()
+
+	// Scope with a ring at half range
+	ctx.SetFillStyle(Theme.RadarBackground)
+	ctx.SetStrokeStyle(Theme.RadarLineColor)
+	ctx.SetLineWidth(2)
+	ctx.BeginPath()
+	ctx.Arc(r.X, r.Y, r.Radius, 0, math.Pi*2)
+	ctx.Fill()
+	ctx.Stroke()
+	ctx.SetLineWidth(1)
+	ctx.BeginPath()
+	ctx.Arc(r.X, r.Y, r.Radius/2, 0, math.Pi*2)
+	ctx.MoveTo(r.X-r.Radius, r.Y)
+	ctx.LineTo(r.X+r.Radius, r.Y)
+	ctx.MoveTo(r.X, r.Y-r.Radius)
+	ctx.LineTo(r.X, r.Y+r.Radius)
+	ctx.Stroke()
+
+	// The nearest base stays on the edge when out of range, to find the way
+	// back
+	nearest := g.FindNearestBase()
+	for _, b := range g.Bases {
+		x, y, in := r.Project(ship.X, ship.Y, b.X, b.Y)
+		if !in && b != nearest {
+			continue
+		}
+		ctx.SetStrokeStyle(Theme.BaseShieldGlowColor)
+		ctx.SetLineWidth(2)
+		ctx.BeginPath()
+		ctx.Arc(x, y, math.Max(b.ShieldRadius*r.Radius/r.Range, 4), 0, math.Pi*2)
+		ctx.Stroke()
+	}
+
+	// Torpedoes and mines
+	for i := 0; i < g.Bullets.ActiveCount; i++ {
+		b := g.Bullets.Pool[i]
+		if !b.Hostile() {
+			continue
+		}
+		x, y, in := r.Project(ship.X, ship.Y, b.X, b.Y)
+		if !in {
+			continue
+		}
+		if b.Kind == MineBullet {
+			ctx.SetFillStyle(Theme.MineColor)
+		} else {
+			ctx.SetFillStyle(Theme.TorpedoColor)
+		}
+		ctx.FillRect(x-1, y-1, 2, 2)
+	}
+
+	// Enemies in the color of their kind
+	for _, e := range g.Enemies {
+		if !e.IsAlive() {
+			continue
+		}
+		x, y, in := r.Project(ship.X, ship.Y, e.X, e.Y+e.YOffset)
+		if !in {
+			continue
+		}
+		size := 3.0
+		if e.Kind == Boss || e.Kind == Carrier {
+			size = 5
+		}
+		ctx.SetFillStyle(enemyColor(e.Kind))
+		ctx.BeginPath()
+		ctx.Arc(x, y, size, 0, math.Pi*2)
+		ctx.Fill()
+	}
+
+	// The locked target, or the one being locked, is ringed
+	target, color := ship.Target, Theme.RadarTargetColor
+	if target == nil {
+		target, color = ship.LockingOn, Theme.RadarLockingColor
+	}
+	if target != nil && target.IsAlive() {
+		x, y, _ := r.Project(ship.X, ship.Y, target.X, target.Y+target.YOffset)
+		ctx.SetStrokeStyle(color)
+		ctx.SetLineWidth(1.5)
+		ctx.BeginPath()
+		ctx.Arc(x, y, 8, 0, math.Pi*2)
+		ctx.Stroke()
+	}
+
+	// The other players' ships, then the local ship on top
+	for _, s := range g.Ships {
+		if s == ship || s.E <= 0 {
+			continue
+		}
+		x, y, _ := r.Project(ship.X, ship.Y, s.X, s.Y)
+		r.renderShip(ctx, x, y, s.Angle, Theme.RadarAllyColor)
+	}
+	r.renderShip(ctx, r.X, r.Y, ship.Angle, Theme.ShipColor)
+
+	ctx.Restore()
+}
+
+// renderShip draws a ship blip as a small arrow pointing along angle.
+func (r *Radar) renderShip(ctx render.Renderer, x, y, angle float64, color string) {
+	sin, cos := math.Sincos(angle)
+	point := func(fx, fy float64) (float64, float64) {
+		// Rotate (fx, fy) of the upward facing arrow by angle
+		return x + fx*cos - fy*sin, y + fx*sin + fy*cos
+	}
+	ctx.SetFillStyle(color)
+	ctx.BeginPath()
+	ctx.MoveTo(point(0, -6))
+	ctx.LineTo(point(4, 4))
+	ctx.LineTo(point(0, 2))
+	ctx.LineTo(point(-4, 4))
+	ctx.ClosePath()
+	ctx.Fill()
+}
@@ -0,0 +1,441 @@
+package game
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// Replay file format (all integers little endian or uvarint):
+//
+//	"SRPL"        magic
+//	version       1 byte
+//	seed          uint32, the GameSeed of the session
+//	difficulty    1 byte Difficulty
+//	ticks         uvarint, number of recorded ticks
+//	runs...       uvarint run length, uvarint key bitmask,
+//	              varint analog turn, varint analog thrust,
+//	              varint aim x, varint aim y (only if the keys use the aim),
+//	              uvarint upgrade (only if the keys buy one)
+//	mode          1 byte GameMode, omitted for infinite world sessions
+//
+// Version 1 files lack the analog values, version 2 files lack the aim
+// point, version 3 files lack the mode (they are infinite world sessions),
+// version 4 files lack purchases and version 5 files lack the difficulty
+// (they are played at normal difficulty); all are still decoded. Held keys
+// repeat for many ticks, so run-length encoding keeps a minute of play in a
+// few hundred bytes.
+const (
+	replayMagic   = "SRPL"
+	replayVersion = 6
+
+	// ReplayMaxTicks bounds decoded replays to four hours at 30 ticks per
+	// second, so a hostile file cannot make the decoder allocate unbounded
+	// memory.
+	ReplayMaxTicks = 30 * 60 * 60 * 4
+)
+
+// Replay decoding errors.
+var (
+	ErrReplayMagic     = errors.New("replay: not a replay file")
+	ErrReplayVersion   = errors.New("replay: unsupported version")
+	ErrReplayCorrupt   = errors.New("replay: corrupt data")
+	ErrReplayTruncated = errors.New("replay: truncated data")
+)
+
+// Replay is a recorded session: the game seed, mode and difficulty plus the
+// controls the local ship received on every simulation tick. Since the
+// simulation is deterministic, stepping a game reset to Seed with Inputs
+// reproduces the session exactly.
+type Replay struct {
+	Seed       uint32
+	Mode       GameMode
+	Difficulty Difficulty
+	Inputs     []ControlState // Inputs[i] is the input applied on tick i
+}
+
+// NewReplay creates an empty recording for a game started with seed.
+func NewReplay(seed uint32) *Replay {
+	return &Replay{
+		Seed:   seed,
+		Inputs: make([]ControlState, 0, 1024),
+	}
+}
+
+// Record appends the input of the next tick. An aim point or upgrade
+// without a key that uses it has no effect and is not kept.
+func (r *Replay) Record(c ControlState) {
+	if !c.HasAim() {
+		c.AimX, c.AimY = 0, 0
+	}
+	if c.Keys&KeyBuy == 0 {
+		c.Buy = 0
+	}
+	r.Inputs = append(r.Inputs, c)
+}
+
+// Ticks returns the number of recorded ticks.
+func (r *Replay) Ticks() int {
+	return len(r.Inputs)
+}
+
+// MarshalBinary encodes the replay in the compact replay file format.
+func (r *Replay) MarshalBinary() ([]byte, error) {
+	buf := make([]byte, 0, 16+len(r.Inputs)/8)
+	buf = append(buf, replayMagic...)
+	buf = append(buf, replayVersion)
+	buf = binary.LittleEndian.AppendUint32(buf, r.Seed)
+	buf = append(buf, byte(r.Difficulty))
+	buf = binary.AppendUvarint(buf, uint64(len(r.Inputs)))
+
+	for i := 0; i < len(r.Inputs); {
+		c := r.Inputs[i]
+		run := 1
+		for i+run < len(r.Inputs) && r.Inputs[i+run] == c {
+			run++
+		}
+		buf = binary.AppendUvarint(buf, uint64(run))
+		buf = binary.AppendUvarint(buf, uint64(c.Keys))
+		buf = binary.AppendVarint(buf, int64(c.Turn))
+		buf = binary.AppendVarint(buf, int64(c.Thrust))
+		if c.HasAim() {
+			buf = binary.AppendVarint(buf, int64(c.AimX))
+			buf = binary.AppendVarint(buf, int64(c.AimY))
+		}
+		if c.Keys&KeyBuy != 0 {
+			buf = binary.AppendUvarint(buf, uint64(c.Buy))
+		}
+		i += run
+	}
+	if r.Mode != ModeInfinite {
+		buf = append(buf, byte(r.Mode))
+	}
+	return buf, nil
+}
+
+// UnmarshalBinary decodes a replay produced by MarshalBinary.
+func (r *Replay) UnmarshalBinary(data []byte) error {
+	header := len(replayMagic) + 1 + 4
+	if len(data) < header {
+		return ErrReplayTruncated
+	}
+	if string(data[:len(replayMagic)]) != replayMagic {
+		return ErrReplayMagic
+	}
+	version := data[len(replayMagic)]
+	if version < 1 || version > replayVersion {
+		return ErrReplayVersion
+	}
+	seed := binary.LittleEndian.Uint32(data[len(replayMagic)+1:])
+	data = data[header:]
+
+	difficulty := DifficultyNormal
+	if version >= 6 {
+		if len(data) == 0 {
+			return ErrReplayTruncated
+		}
+		if difficulty = Difficulty(data[0]); difficulty >= DifficultyCount {
+			return ErrReplayCorrupt
+		}
+		data = data[1:]
+	}
+
+	ticks, n := binary.Uvarint(data)
+	if n <= 0 {
+		return ErrReplayTruncated
+	}
+	if ticks > ReplayMaxTicks {
+		return ErrReplayCorrupt
+	}
+	data = data[n:]
+
+	inputs := make([]ControlState, 0, ticks)
+	for uint64(len(inputs)) < ticks {
+		run, n := binary.Uvarint(data)
+		if n <= 0 {
+			return ErrReplayTruncated
+		}
+		data = data[n:]
+		keys, n := binary.Uvarint(data)
+		if n <= 0 {
+			return ErrReplayTruncated
+		}
+		data = data[n:]
+
+		var turn, thrust int64
+		if version >= 2 {
+			if turn, n = binary.Varint(data); n <= 0 {
+				return ErrReplayTruncated
+			}
+			data = data[n:]
+			if thrust, n = binary.Varint(data); n <= 0 {
+				return ErrReplayTruncated
+			}
+			data = data[n:]
+		}
+
+		if run == 0 || keys > 0xffff || run > ticks-uint64(len(inputs)) ||
+			!validAnalog(turn) || !validAnalog(thrust) {
+			return ErrReplayCorrupt
+		}
+		c := ControlState{Keys: uint16(keys), Turn: int8(turn), Thrust: int8(thrust)}
+
+		if version >= 3 && c.HasAim() {
+			var aimX, aimY int64
+			if aimX, n = binary.Varint(data); n <= 0 {
+				return ErrReplayTruncated
+			}
+			data = data[n:]
+			if aimY, n = binary.Varint(data); n <= 0 {
+				return ErrReplayTruncated
+			}
+			data = data[n:]
+			if aimX != int64(int32(aimX)) || aimY != int64(int32(aimY)) {
+				return ErrReplayCorrupt
+			}
+			c.AimX, c.AimY = int32(aimX), int32(aimY)
+		}
+		if version >= 5 && c.Keys&KeyBuy != 0 {
+			buy, n := binary.Uvarint(data)
+			if n <= 0 {
+				return ErrReplayTruncated
+			}
+			data = data[n:]
+			if buy >= uint64(UpgradeCount) {
+				return ErrReplayCorrupt
+			}
+			c.Buy = Upgrade(buy)
+		}
+		for ; run > 0; run-- {
+			inputs = append(inputs, c)
+		}
+	}
+
+	mode := ModeInfinite
+	if version >= 4 && len(data) == 1 {
+		if mode = GameMode(data[0]); mode == ModeInfinite || mode > ModeCampaign {
+			return ErrReplayCorrupt
+		}
+		data = data[1:]
+	}
+	if len(data) != 0 {
+		return ErrReplayCorrupt
+	}
+
+	r.Seed = seed
+	r.Mode = mode
+	r.Difficulty = difficulty
+	r.Inputs = inputs
+	return nil
+}
+
+func validAnalog(v int64) bool {
+	return v >= -AnalogMax && v <= AnalogMax
+}
+
+// DecodeReplay parses a replay file.
+func DecodeReplay(data []byte) (*Replay, error) {
+	r := &Replay{}
+	if err := r.UnmarshalBinary(data); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// ReplayResult summarizes the final state of a replayed session.
+type ReplayResult struct {
+	Difficulty Difficulty // Scores only compare within one difficulty
+	Score      int        // Level score (Level.P)
+	Points     int        // Points of the local ship
+	Health     int        // Energy of the local ship
+	Ticks      uint32     // Simulated ticks
+	Hash       uint32     // Checksum of the final state
+}
+
+// String formats the result the way cmd/sorades-replay prints it. Replay
+// regression tests compare against this text.
+func (r ReplayResult) String() string {
+	return fmt.Sprintf("difficulty: %s\nscore:      %d\npoints:     %d\nhealth:     %d\nticks:      %d\nhash:       %08x\n",
+		r.Difficulty, r.Score, r.Points, r.Health, r.Ticks, r.Hash)
+}
+
+// RunReplay simulates r headless from its seed to the last recorded tick
+// and returns the final state. Identical replays always produce identical
+// results, so the hash verifies a submitted score.
+func RunReplay(r *Replay) ReplayResult {
+	g := NewHeadlessGame(r.Seed)
+	if r.Mode != ModeInfinite || r.Difficulty != DifficultyNormal {
+		g.Mode = r.Mode
+		g.Difficulty = r.Difficulty
+		g.Reset(r.Seed)
+	}
+	p := NewReplayPlayer(r)
+	for !p.Finished(g) {
+		g.Step([]ControlState{p.Poll(g, g.Ship)})
+	}
+	return ReplayResult{
+		Difficulty: r.Difficulty,
+		Score:      g.Level.P,
+		Points:     g.Ship.Points,
+		Health:     g.Ship.E,
+		Ticks:      g.Tick,
+		Hash:       g.Checksum(),
+	}
+}
+
+// Replay playback speed limits (ticks simulated per rendered frame).
+const (
+	ReplayMinSpeed = 1
+	ReplayMaxSpeed = 16
+)
+
+// ReplayPlayer feeds a recorded replay into the game instead of the
+// keyboard, as the InputSource of the local ship. Playback can be paused,
+// fast-forwarded and stepped one tick at a time while paused.
+type ReplayPlayer struct {
+	Replay *Replay
+	Paused bool
+	Speed  int // Ticks per frame, ReplayMinSpeed..ReplayMaxSpeed
+	steps  int // Ticks requested by Step while paused
+}
+
+// NewReplayPlayer creates a player positioned at the start of r.
+func NewReplayPlayer(r *Replay) *ReplayPlayer {
+	return &ReplayPlayer{
+		Replay: r,
+		Speed:  ReplayMinSpeed,
+	}
+}
+
+// TogglePause pauses or resumes playback.
+func (p *ReplayPlayer) TogglePause() {
+	p.Paused = !p.Paused
+	p.steps = 0
+}
+
+// Step advances a paused replay by a single tick on the next frame.
+func (p *ReplayPlayer) Step() {
+	if p.Paused {
+		p.steps++
+	}
+}
+
+// SetSpeed sets the fast-forward factor, clamped to the supported range.
+func (p *ReplayPlayer) SetSpeed(speed int) {
+	p.Speed = maxInt(ReplayMinSpeed, min(speed, ReplayMaxSpeed))
+}
+
+// Finished reports whether every recorded tick has been played in g.
+func (p *ReplayPlayer) Finished(g *Game) bool {
+	return int(g.Tick) >= p.Replay.Ticks()
+}
+
+// Advance runs the simulation ticks due this frame and returns how many
+// were run.
+func (p *ReplayPlayer) Advance(g *Game) int {
+	ticks := p.Speed
+	if p.Paused {
+		ticks = p.steps
+		p.steps = 0
+	}
+
+	ran := 0
+	for ; ran < ticks && !p.Finished(g); ran++ {
+		g.Step([]ControlState{p.Poll(g, g.Ship)})
+	}
+	return ran
+}
+
+// Poll implements InputSource with the input recorded for the current tick.
+func (p *ReplayPlayer) Poll(g *Game, s *Ship) ControlState {
+	if p.Finished(g) {
+		return ControlState{}
+	}
+	return p.Replay.Inputs[g.Tick]
+}
+
+// HandleAction applies the playback controls: Pause pauses, Rotate Right
+// steps one tick while paused, Thrust and Reverse double or halve the speed.
+// Returns false for actions that are not playback controls.
+func (p *ReplayPlayer) HandleAction(a Action) bool {
+	switch a {
+	case ActionPause:
+		p.TogglePause()
+	case ActionRotateRight:
+		p.Step()
+	case ActionThrust:
+		p.SetSpeed(p.Speed * 2)
+	case ActionReverse:
+		p.SetSpeed(p.Speed / 2)
+	default:
+		return false
+	}
+	return true
+}
+
+// Reset restarts the simulation at tick 0 with the given seed. Sprites,
+// audio and input handlers are kept, so a running browser game can start
+// a replay or a new session without being recreated.
+func (g *Game) Reset(seed uint32) {
+	prev := g.Ship
+
+	g.Enemies = g.Enemies[:0]
+	g.Bullets.Clear()
+	g.Explosions.Clear()
+	g.Bonuses.Clear()
+
+	g.Ships = nil
+	g.initShipDefaults()
+	if prev != nil {
+		g.Ship.Image = prev.Image
+		g.Ship.OriginalImage = prev.OriginalImage
+		g.Ship.Shield.Image = prev.Shield.Image
+		g.Ship.NetworkID = prev.NetworkID
+		g.Ship.Input = prev.Input
+	}
+
+	g.Camera.X, g.Camera.Y = 0, 0
+	g.Bases = g.Bases[:0]
+	g.initBases()
+
+	// Score digits and the background are sprites; keep them
+	points, background := g.Level.Points, g.Level.Background
+	g.initLevelDefaults()
+	g.Level.Points, g.Level.Background = points, background
+
+	g.Tick = 0
+	g.TorpedoFrame = 0
+	g.SetGameSeed(seed)
+	g.Recording = NewReplay(seed)
+	g.Recording.Mode = g.Mode
+	g.Recording.Difficulty = g.Difficulty
+
+	g.Campaign = nil
+	if g.Mode == ModeCampaign {
+		g.startCampaign()
+	}
+}
+
+// StartReplay leaves multiplayer, resets the game to the replay's seed, mode
+// and difficulty and plays its inputs back instead of the keyboard.
+func (g *Game) StartReplay(r *Replay) {
+	g.LeaveMultiplayer()
+	g.Mode = r.Mode
+	g.Difficulty = r.Difficulty
+	g.Reset(r.Seed)
+	g.Demo = nil
+	g.Playback = NewReplayPlayer(r)
+	g.Ship.Input = g.Playback
+	g.State = StatePlaying
+}
+
+// StopReplay ends playback and starts a fresh session with the same seed.
+func (g *Game) StopReplay() {
+	if g.Playback == nil {
+		return
+	}
+	g.Playback = nil
+	g.Reset(g.GameSeed)
+	g.Ship.Input = g.LocalInput
+	g.State = StatePlaying
+}
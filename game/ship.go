@@ -4,7 +4,7 @@ import (
 	"math"
 	"strconv"
 
-	"github.com/gopherjs/gopherjs/js"
+	"github.com/simukka/starship-sorades-13k/render"
 )
 
 // Ship holds player ship state.
@@ -16,18 +16,22 @@ type Ship struct {
 	E             int     // Energy/health
 	Points        int     // Score/points for this ship (determines enemy strength)
 	Timeout       int
-	Weapon        int
+	Weapon        WeaponKind // Selected weapon
+	Arsenal       uint8      // Bit per weapon kind collected besides the spread gun
+	Charge        int        // Ticks the charge shot has been charged
 	Reload        int
 	OSD           int
 	Shield        Shield
-	Image         *js.Object
-	OriginalImage *js.Object
+	Image         render.Image
+	OriginalImage render.Image
 	Weapons       []*Weapon
+	Upgrades      [UpgradeCount]int // Level of each upgrade bought in the shop
 	local         bool
-	Paused        bool   //Ship is paused
-	InBase        bool   // Ship is inside a base shield
-	RepairTimer   int    // Frames until next repair tick while in base
-	NetworkID     string // Unique ID for multiplayer
+	Input         InputSource  // Controls the ship; nil for ships moved by the network
+	prevInput     ControlState // Controls applied on the previous tick
+	InBase        bool         // Ship is inside a base shield
+	RepairTimer   int          // Frames until next repair tick while in base
+	NetworkID     string       // Unique ID for multiplayer
 
 	// Targeting system
 	Target      *Enemy // Currently locked target
@@ -83,15 +87,40 @@ func (s *Ship) IsAlive() bool {
 	return s.E > 0
 }
 
-// Update implements Entity interface - updates the ship state and renders it.
+// Update implements Entity interface - advances the ship's timers, shield and
+// base repair by one tick.
 // Returns false if the ship should be removed (death).
 func (s *Ship) Update(g *Game) bool {
 	if !s.IsAlive() {
 		return false
 	}
 
-	// Render the ship
-	s.Render(g)
+	s.Timeout--
+
+	// Update InBase status and handle repair
+	s.InBase = g.IsShipProtectedByBase(s)
+	if s.InBase && s.E < s.MaxEnergy() {
+		// Repair while in base - 1 health every 55 frames (~1.8 seconds)
+		// Full repair from 1% takes about 3 minutes
+		s.RepairTimer--
+		if s.RepairTimer <= 0 {
+			s.E++
+			s.RepairTimer = 55 // Reset timer
+			s.OSD = ShipMaxOSD // Show energy bar when repairing
+		}
+	}
+
+	if s.Shield.T > 0 {
+		s.Shield.T--
+		if s.Shield.T == 0 {
+			g.Audio.PlayLocal(4, 1.0)
+		}
+	}
+
+	// Energy bar fades out unless energy is critically low
+	if s.OSD > 0 && s.E >= 25 {
+		s.OSD--
+	}
 	return true
 }
 
@@ -100,7 +129,7 @@ func (s *Ship) Pickup(g *Game, item *Bonus) bool {
 		s.X < (item.X+ShipCollisionE) && s.X > (item.X-ShipCollisionE) {
 		switch item.Type {
 		case "+":
-			if len(s.Weapons) < MaxWeapons {
+			if len(s.Weapons) < s.MaxWeapons() {
 				s.AddWeapon()
 				// todo: make audio level reflective of weapon count
 				g.Audio.PlayLocal(5, 1.0)
@@ -108,7 +137,7 @@ func (s *Ship) Pickup(g *Game, item *Bonus) bool {
 				g.Audio.PlayLocal(6, 1.0)
 			}
 		case "E":
-			if s.E < 100 {
+			if s.E < s.MaxEnergy() {
 				s.OSD = ShipMaxOSD
 				// todo: make audio level reflective of energy level
 				g.Audio.PlayLocal(5, 1.0)
@@ -116,36 +145,23 @@ func (s *Ship) Pickup(g *Game, item *Bonus) bool {
 				g.Audio.PlayLocal(6, 1.0)
 			}
 			s.E += 5
-			if s.E > 100 {
-				s.E = 100
+			if s.E > s.MaxEnergy() {
+				s.E = s.MaxEnergy()
 			}
 		case "S":
 			s.Shield.T += s.Shield.MaxT * s.Shield.MaxT * 2 /
 				(s.Shield.T + s.Shield.MaxT*2)
 			g.Audio.PlayLocal(3, 1.0)
 		case "B":
-			// for j := len(g.Enemies) - 1; j >= 0; j-- {
-			// 	g.Enemies[j].Health--
-			// }
-			// v := 0.0
-			// // TODO: only target the nearest torpedos within a distance from the ship.
-			// // As the ship has more weapons, the distance increases.
-			// g.Bullets.ForEachKindReverse(TorpedoBullet, func(b *Bullet, i int) {
-			// 	v += 0.01
-			// 	if v > 0.7 {
-			// 		v = 0.7
-			// 	}
-			// 	g.Explode(b.X, b.Y, 0)
-			// 	g.Audio.PlayWithPan(13, b.AudioPan(), v)
-			// 	g.Bullets.Release(i)
-			// })
-			// for j := 0; j < min(g.Torpedos.ActiveCount, 5); j++ {
-			// 	g.Explode(g.Torpedos.Pool[j].X, g.Torpedos.Pool[j].Y, 0)
-			// }
-			// g.Torpedos.Clear()
-			// g.Level.Bomb = MaxBomb
-			// TODO make audio level reflective of the number of torpedos cleared
-			// g.Audio.PlayWithPan(13, s.AudioPan(), 1.0)
+			// The host owns torpedoes and enemies; clients see the result
+			// through state sync
+			if !g.IsNetworkClient() {
+				s.DetonateBomb(g)
+			}
+		case "M", "L", "C":
+			kind, _ := WeaponForPickup(item.Type)
+			s.GiveWeapon(kind)
+			g.Audio.PlayLocal(5, 1.0)
 		default:
 			g.Audio.PlayLocal(7, 1.0)
 		}
@@ -156,15 +172,103 @@ func (s *Ship) Pickup(g *Game, item *Bonus) bool {
 	return false
 }
 
-func (s *Ship) Move(g *Game, keys map[int]bool) {
+// BombRadius returns the reach of the ship's bomb, which grows with every
+// weapon the ship carries.
+func (s *Ship) BombRadius() float64 {
+	return BombBaseRadius + float64(maxInt(len(s.Weapons)-1, 0))*BombRadiusPerWeapon
+}
+
+// DetonateBomb destroys the torpedoes and mines and damages the enemies within
+// BombRadius of the ship, BombDamage per weapon, and flashes the screen.
+func (s *Ship) DetonateBomb(g *Game) {
+	radius := s.BombRadius()
+	g.Level.Bomb = MaxBomb
+
+	cleared := 0
+	g.Bullets.ForEachReverse(func(b *Bullet, i int) {
+		if !b.Hostile() || math.Hypot(b.X-s.X, b.Y-s.Y) > radius {
+			return
+		}
+		g.Explode(b.X, b.Y, 0)
+		g.Bullets.Release(i)
+		cleared++
+	})
+
+	damage := BombDamage * maxInt(len(s.Weapons), 1)
+	for _, e := range g.Enemies {
+		if !e.IsAlive() || math.Hypot(e.X-s.X, e.Y+e.YOffset-s.Y) > radius+e.Radius {
+			continue
+		}
+		e.Damage(damage)
+		g.Explode(e.X, e.Y+e.YOffset, e.Radius)
+	}
+
+	// Louder the more torpedoes and mines were cleared
+	g.Audio.PlayWithPan(13, s.AudioPan(), math.Min(0.4+float64(cleared)*0.05, 1))
+}
+
+// ApplyInput applies one tick of control input to the ship.
+// Target lock, weapon switching and purchases are edge triggered: holding
+// KeyLock, KeyNextTarget, KeyLockAt, KeySwitchWeapon or KeyBuy acts once.
+// Releasing KeyFire fires a charged shot.
+// canFire is false on network clients, where only the host spawns bullets
+// and sells upgrades; clients see their purchases through state sync.
+func (s *Ship) ApplyInput(g *Game, c ControlState, canFire bool) {
+	keys := c.Keys
+	pressed := keys &^ s.prevInput.Keys
+	if pressed&KeyBuy != 0 && canFire {
+		s.Buy(g, c.Buy)
+	}
+	if pressed&KeySwitchWeapon != 0 {
+		s.NextWeapon(g)
+	}
+	if keys&KeyFire != 0 && canFire {
+		s.Fire(g)
+	} else if s.Charge > 0 {
+		if canFire {
+			s.ReleaseCharge(g)
+		}
+		s.Charge = 0
+	}
+
+	if pressed&KeyLockAt != 0 {
+		if enemy := g.EnemyAt(float64(c.AimX), float64(c.AimY)); enemy != nil {
+			s.startLock(g, enemy)
+		}
+	} else if pressed&KeyLock != 0 {
+		s.InitiateTargetLock(g)
+	} else if pressed&KeyNextTarget != 0 {
+		s.CycleTargetLock(g)
+	}
+
+	s.Move(g, c)
+	s.bounceOffObstacles(g, canFire)
+	s.prevInput = c
+}
+
+// Move rotates and thrusts the ship according to the direction bits and
+// analog values of c and advances its position by one tick.
+func (s *Ship) Move(g *Game, c ControlState) {
+	keys := c.Keys
+
 	// Rotation input (Left/Right arrows rotate the ship)
 	// Left arrow - rotate counter-clockwise
-	if keys[37] {
-		s.Angle -= ShipRotationSpeed
+	rotation := s.RotationSpeed()
+	if keys&KeyLeft != 0 {
+		s.Angle -= rotation
 	}
 	// Right arrow - rotate clockwise
-	if keys[39] {
-		s.Angle += ShipRotationSpeed
+	if keys&KeyRight != 0 {
+		s.Angle += rotation
+	}
+	// Analog stick - rotation speed proportional to deflection
+	if c.Turn != 0 {
+		s.Angle += rotation * float64(c.Turn) / AnalogMax
+	}
+	// Mouse steering - turn toward the aim point at full rotation speed
+	if keys&KeyAim != 0 {
+		diff := math.Remainder(headingTo(s, float64(c.AimX), float64(c.AimY))-s.Angle, 2*math.Pi)
+		s.Angle += math.Max(-rotation, math.Min(diff, rotation))
 	}
 
 	// Track if thrusting this frame
@@ -172,15 +276,26 @@ func (s *Ship) Move(g *Game, keys map[int]bool) {
 
 	// Thrust input (Up/Down arrows control forward/backward)
 	// Up arrow - thrust forward (in direction ship is facing)
-	if keys[38] {
-		s.VelX += math.Sin(s.Angle) * ShipThrustAcc
-		s.VelY -= math.Cos(s.Angle) * ShipThrustAcc
+	thrust := s.ThrustAcc()
+	if keys&KeyUp != 0 {
+		s.VelX += math.Sin(s.Angle) * thrust
+		s.VelY -= math.Cos(s.Angle) * thrust
 		thrusting = true
 	}
 	// Down arrow - thrust backward (reverse)
-	if keys[40] {
-		s.VelX -= math.Sin(s.Angle) * ShipThrustAcc * 0.5
-		s.VelY += math.Cos(s.Angle) * ShipThrustAcc * 0.5
+	if keys&KeyDown != 0 {
+		s.VelX -= math.Sin(s.Angle) * thrust * 0.5
+		s.VelY += math.Cos(s.Angle) * thrust * 0.5
+		thrusting = true
+	}
+	// Analog throttle - reverse is half as strong, like the Down arrow
+	if c.Thrust != 0 {
+		acc := thrust * float64(c.Thrust) / AnalogMax
+		if c.Thrust < 0 {
+			acc *= 0.5
+		}
+		s.VelX += math.Sin(s.Angle) * acc
+		s.VelY -= math.Cos(s.Angle) * acc
 		thrusting = true
 	}
 
@@ -193,7 +308,7 @@ func (s *Ship) Move(g *Game, keys map[int]bool) {
 	}
 
 	// Play thrust sound with volume relative to velocity
-	if thrusting && speed > 1.0 {
+	if thrusting && speed > 1.0 && s.local {
 		// Volume scales from 0.1 at low speed to 0.4 at max speed
 		volume := 0.1 + (speed/ShipMaxSpeed)*0.3
 		g.Audio.PlayLocal(23, volume)
@@ -207,9 +322,11 @@ func (s *Ship) Move(g *Game, keys map[int]bool) {
 	s.VelX *= ShipACCFactor
 	s.VelY *= ShipACCFactor
 
-	// Update camera to follow ship
-	g.Camera.X = s.X
-	g.Camera.Y = s.Y
+	// Update camera to follow the local ship
+	if s.local {
+		g.Camera.X = s.X
+		g.Camera.Y = s.Y
+	}
 }
 
 // WeaponAngleStep defines the angular separation between weapon upgrades in radians.
@@ -220,7 +337,8 @@ const WeaponAngleStep = math.Pi / 12
 const WeaponSpeed = 50.0
 
 // MaxWeapons defines the maximum number of weapons (full 360° coverage at 15° intervals = 24 weapons,
-// but we stop at 180° coverage = 13 weapons: 0°, ±15°, ±30°, ±45°, ±60°, ±75°, ±90°)
+// but we stop at 180° coverage = 13 weapons: 0°, ±15°, ±30°, ±45°, ±60°, ±75°, ±90°).
+// Weapon slot upgrades raise the limit of a ship, see Ship.MaxWeapons.
 const MaxWeapons = 13
 
 // AddWeapon adds a new weapon to the ship's arsenal.
@@ -235,7 +353,7 @@ const MaxWeapons = 13
 // The weapon's X and Y values represent bullet velocity direction.
 func (s *Ship) AddWeapon() {
 	cur := len(s.Weapons)
-	if cur >= MaxWeapons {
+	if cur >= s.MaxWeapons() {
 		return // Already at max weapons
 	}
 
@@ -280,15 +398,27 @@ func (s *Ship) AddWeapon() {
 //   - An explosion effect is spawned at the torpedo's position
 //   - Returns true to signal the torpedo should be removed
 //
+// Mines go off when the ship comes within MineTriggerRadius instead, doing
+// MineDamage.
+//
 // Returns false if no collision occurred.
 // Note: When debug UI (F9) is active, ship is "invisible" to torpedos.
 func (s *Ship) Collision(g *Game, bullet *Bullet) bool {
-	if bullet.Kind != TorpedoBullet {
+	if !bullet.Hostile() {
 		return false
 	}
 	if g.IsShipProtectedByBase(s) {
 		return false
 	}
+	if bullet.Kind == MineBullet {
+		if math.Hypot(bullet.X-s.X, bullet.Y-s.Y) > MineTriggerRadius {
+			return false
+		}
+		s.Hurt(g, MineDamage)
+		g.Explode(bullet.X, bullet.Y, MineR*2)
+		g.Audio.PlayWithPan(11, bullet.AudioPan(), 1)
+		return true
+	}
 	if s.Y < (bullet.Y+ShipCollisionD) && s.Y > (bullet.Y-ShipCollisionD) &&
 		s.X < bullet.X+ShipCollisionE && s.X > (bullet.X-ShipCollisionE) {
 		s.Hurt(g, 10)
@@ -299,42 +429,28 @@ func (s *Ship) Collision(g *Game, bullet *Bullet) bool {
 	return false
 }
 
+// Render implements Entity interface - draws the ship, its shield and the
+// energy bar.
 func (s *Ship) Render(g *Game) {
-	s.Timeout--
-
-	// Update InBase status and handle repair
-	s.InBase = g.IsShipProtectedByBase(s)
-	if s.InBase && s.E < 100 {
-		// Repair while in base - 1 health every 55 frames (~1.8 seconds)
-		// Full repair from 1% takes about 3 minutes
-		s.RepairTimer--
-		if s.RepairTimer <= 0 {
-			s.E++
-			s.RepairTimer = 55 // Reset timer
-			s.OSD = ShipMaxOSD // Show energy bar when repairing
-		}
+	if !s.IsAlive() {
+		return
 	}
 
 	// Convert world position to screen position
 	screenX, screenY := g.Camera.WorldToScreen(s.X, s.Y)
 
-	g.Ctx.Call("save")
-	g.Ctx.Call("translate", screenX, screenY)
-	g.Ctx.Call("rotate", s.Angle) // Use actual rotation angle (radians)
-	g.Ctx.Call("drawImage", s.Image, -ShipR/2, -ShipR)
-	g.Ctx.Call("restore")
+	g.Ctx.Save()
+	g.Ctx.Translate(screenX, screenY)
+	g.Ctx.Rotate(s.Angle) // Use actual rotation angle (radians)
+	g.Ctx.DrawImage(s.Image, -ShipR/2, -ShipR)
+	g.Ctx.Restore()
 
 	// Shield effect
 	if s.Shield.T > 0 {
-		mathRandom := js.Global.Get("Math").Call("random").Float()
-
-		if s.Shield.T > 30 || mathRandom > 0.5 {
-			g.Ctx.Call("drawImage", s.Shield.Image,
-				int(screenX)-ShipR, int(screenY)-ShipR)
-		}
-		s.Shield.T--
-		if s.Shield.T == 0 {
-			g.Audio.PlayLocal(4, 1.0)
+		// Blink while the shield is about to run out
+		if s.Shield.T > 30 || s.Shield.T%4 < 2 {
+			g.Ctx.DrawImage(s.Shield.Image,
+				math.Floor(screenX)-ShipR, math.Floor(screenY)-ShipR)
 		}
 	}
 
@@ -343,11 +459,7 @@ func (s *Ship) Render(g *Game) {
 	}
 }
 
-// Fire creates bullets from all of the ship's equipped weapons.
-// Each weapon in the Weapons slice fires one bullet per call.
-// If a target is locked, all weapons aim at the target.
-// Otherwise, bullet velocity is based on the weapon's angle offset rotated by ship's facing angle.
-// Bullets spawn at the ship's position and travel in the combined direction.
+// Fire fires the selected weapon for one tick of held fire.
 // Cannot fire while inside a base shield (safe zone).
 func (s *Ship) Fire(g *Game) {
 	// Cannot fire while inside base shield
@@ -355,6 +467,24 @@ func (s *Ship) Fire(g *Game) {
 		return
 	}
 
+	switch s.Weapon {
+	case WeaponMissile:
+		s.fireMissiles(g)
+	case WeaponLaser:
+		s.fireLaser(g)
+	case WeaponCharge:
+		s.chargeShot(g)
+	default:
+		s.fireSpread(g)
+	}
+}
+
+// fireSpread creates bullets from all of the ship's equipped weapons.
+// Each weapon in the Weapons slice fires one bullet per call.
+// If a target is locked, all weapons aim at the target.
+// Otherwise, bullet velocity is based on the weapon's angle offset rotated by ship's facing angle.
+// Bullets spawn at the ship's position and travel in the combined direction.
+func (s *Ship) fireSpread(g *Game) {
 	s.Reload--
 
 	weapons := len(s.Weapons)
@@ -417,14 +547,11 @@ func (s *Ship) Fire(g *Game) {
 // TODO rename to Hit
 // Hurt applies damage to the ship and handles damage effects.
 // If the ship has an active shield, damage is blocked and a shield hit sound plays.
-// Otherwise, damage is applied after the invincibility timeout expires.
+// Otherwise, damage scaled by the difficulty is applied after the
+// invincibility timeout expires.
 // When health reaches 0, the ship explodes and the game ends.
 // Taking damage also removes one weapon upgrade.
 func (s *Ship) Hurt(g *Game, damage int) {
-	if s.Paused {
-		return
-	}
-
 	if g.IsShipProtectedByBase(s) {
 		return
 	}
@@ -437,7 +564,7 @@ func (s *Ship) Hurt(g *Game, damage int) {
 
 	// Apply damage only if invincibility has expired (Timeout < 0)
 	if s.Timeout < 0 {
-		s.E -= damage
+		s.E -= g.Difficulty.Damage(damage)
 		if s.E < 0 {
 			s.E = 0
 		}
@@ -471,13 +598,13 @@ func (s *Ship) RenderEnergyBar(g *Game) {
 	// Convert world position to screen position
 	screenX, screenY := g.Camera.WorldToScreen(s.X, s.Y)
 
-	barX := int(screenX) - 32
-	barY := int(screenY) + 63
-	colorValue := s.E * 512 / 100
+	barX := math.Floor(screenX) - 32
+	barY := math.Floor(screenY) + 63
+	colorValue := s.E * 512 / s.MaxEnergy()
 
-	g.Ctx.Set("globalAlpha", float64(s.OSD)/float64(ShipMaxOSD))
-	g.Ctx.Set("fillStyle", Theme.EnergyBarBackground)
-	g.Ctx.Call("fillRect", barX, barY, 64, 4)
+	g.Ctx.SetGlobalAlpha(float64(s.OSD) / float64(ShipMaxOSD))
+	g.Ctx.SetFillStyle(Theme.EnergyBarBackground)
+	g.Ctx.FillRect(barX, barY, 64, 4)
 
 	var r, gr int
 	if colorValue > 255 {
@@ -487,17 +614,13 @@ func (s *Ship) RenderEnergyBar(g *Game) {
 		r = 255
 		gr = colorValue
 	}
-	g.Ctx.Set("fillStyle", "rgb("+strconv.Itoa(r)+","+strconv.Itoa(gr)+",0)")
-	g.Ctx.Call("fillRect", barX, barY, s.E*64/100, 4)
-
-	g.Ctx.Set("lineWidth", Theme.EnergyBarLineWidth)
-	g.Ctx.Set("strokeStyle", Theme.EnergyBarBorder)
-	g.Ctx.Call("strokeRect", barX, barY, 64, 4)
-	g.Ctx.Set("globalAlpha", 1)
+	g.Ctx.SetFillStyle("rgb(" + strconv.Itoa(r) + "," + strconv.Itoa(gr) + ",0)")
+	g.Ctx.FillRect(barX, barY, float64(s.E*64/s.MaxEnergy()), 4)
 
-	if s.E >= 25 {
-		s.OSD--
-	}
+	g.Ctx.SetLineWidth(Theme.EnergyBarLineWidth)
+	g.Ctx.SetStrokeStyle(Theme.EnergyBarBorder)
+	g.Ctx.StrokeRect(barX, barY, 64, 4)
+	g.Ctx.SetGlobalAlpha(1)
 }
 
 // UpdateTargeting handles the targeting lock-on system.
@@ -562,16 +685,71 @@ func (s *Ship) InitiateTargetLock(g *Game) {
 	}
 
 	if nearestEnemy != nil {
-		// Start locking onto this enemy
-		s.LockingOn = nearestEnemy
-		// Random lock time: 5-30 frames (0.17s to 1s at 30 FPS)
-		s.LockTimer = 5 + int(js.Global.Get("Math").Call("random").Float()*25)
-		s.LockMaxTime = s.LockTimer
-		// Clear any existing lock
-		s.Target = nil
-		// Play targeting locking sound
-		g.Audio.PlayLocal(22, 0.6)
+		s.startLock(g, nearestEnemy)
+	}
+}
+
+// CycleTargetLock moves the target lock to the next enemy on screen,
+// ordered by distance from the ship, wrapping around to the nearest one
+// after the farthest. Without a lock it locks onto the nearest enemy.
+func (s *Ship) CycleTargetLock(g *Game) {
+	current := s.Target
+	if current == nil {
+		current = s.LockingOn
 	}
+
+	currentDistSq := -1.0
+	if current != nil && current.IsAlive() {
+		dx := current.X - s.X
+		dy := (current.Y + current.YOffset) - s.Y
+		currentDistSq = dx*dx + dy*dy
+	}
+
+	// The next enemy is the nearest one farther away than the current
+	// target; the nearest overall is the wrap-around choice
+	var next, nearest *Enemy
+	nextDistSq, nearestDistSq := math.MaxFloat64, math.MaxFloat64
+	for _, enemy := range g.Enemies {
+		if !enemy.IsAlive() || enemy == current {
+			continue
+		}
+		if !g.Camera.IsOnScreen(enemy.X, enemy.Y+enemy.YOffset, enemy.Radius) {
+			continue
+		}
+
+		dx := enemy.X - s.X
+		dy := (enemy.Y + enemy.YOffset) - s.Y
+		distSq := dx*dx + dy*dy
+
+		if distSq < nearestDistSq {
+			nearestDistSq = distSq
+			nearest = enemy
+		}
+		if distSq >= currentDistSq && distSq < nextDistSq {
+			nextDistSq = distSq
+			next = enemy
+		}
+	}
+
+	if next == nil {
+		next = nearest
+	}
+	if next != nil {
+		s.startLock(g, next)
+	}
+}
+
+// startLock begins locking onto enemy, replacing any current lock.
+func (s *Ship) startLock(g *Game, enemy *Enemy) {
+	s.LockingOn = enemy
+	// Random lock time: 5-30 frames (0.17s to 1s at 30 FPS), shortened by
+	// lock upgrades
+	s.LockTimer = s.lockTime(g.GameRNG.RandomInt(5, 30))
+	s.LockMaxTime = s.LockTimer
+	// Clear any existing lock
+	s.Target = nil
+	// Play targeting locking sound
+	g.Audio.PlayLocal(22, 0.6)
 }
 
 // ClearTarget removes the current target lock.
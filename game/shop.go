@@ -0,0 +1,210 @@
+package game
+
+// Upgrade is a permanent ship upgrade bought with points in the base shop.
+type Upgrade uint8
+
+// Upgrades in the order the shop lists them.
+const (
+	UpgradeEnergy      Upgrade = iota // More energy to repair up to
+	UpgradeThrust                     // Stronger engine
+	UpgradeRotation                   // Faster turning
+	UpgradeShield                     // Longer shields from every pickup
+	UpgradeWeaponSlots                // More weapon upgrades can be carried
+	UpgradeLock                       // Faster target lock
+
+	// UpgradeCount is the number of upgrades.
+	UpgradeCount
+)
+
+// upgradeInfo describes an upgrade: its label in the shop and the price of
+// its first level. Every further level costs the first price once more.
+var upgradeInfo = [UpgradeCount]struct {
+	name  string
+	price int
+}{
+	UpgradeEnergy:      {"MAX ENERGY", 500},
+	UpgradeThrust:      {"THRUST", 400},
+	UpgradeRotation:    {"ROTATION SPEED", 300},
+	UpgradeShield:      {"SHIELD CAPACITY", 400},
+	UpgradeWeaponSlots: {"WEAPON SLOTS", 600},
+	UpgradeLock:        {"LOCK TIME", 300},
+}
+
+// Upgrade tuning.
+const (
+	// UpgradeMaxLevel is how often each upgrade can be bought.
+	UpgradeMaxLevel = 3
+	// EnergyPerUpgrade is the energy each max energy level adds.
+	EnergyPerUpgrade = 25
+	// ThrustPerUpgrade is the share of ShipThrustAcc each thrust level adds.
+	ThrustPerUpgrade = 0.15
+	// RotationPerUpgrade is the share of ShipRotationSpeed each rotation
+	// level adds.
+	RotationPerUpgrade = 0.15
+	// ShieldPerUpgrade is the shield capacity each shield level adds.
+	ShieldPerUpgrade = ShipMaxShield / 2
+	// WeaponSlotsPerUpgrade is the number of weapon upgrades each weapon
+	// slot level adds to MaxWeapons.
+	WeaponSlotsPerUpgrade = 2
+	// LockPercentPerUpgrade is the share of the lock time in percent each
+	// lock level takes off.
+	LockPercentPerUpgrade = 20
+)
+
+// String returns the label of u in the shop.
+func (u Upgrade) String() string {
+	if u >= UpgradeCount {
+		return "?"
+	}
+	return upgradeInfo[u].name
+}
+
+// Price returns the price of level level+1 of u.
+func (u Upgrade) Price(level int) int {
+	return upgradeInfo[u].price * (level + 1)
+}
+
+// UpgradePrice returns the price of the next level of u for s, and false
+// once u is fully upgraded.
+func (s *Ship) UpgradePrice(u Upgrade) (int, bool) {
+	if u >= UpgradeCount || s.Upgrades[u] >= UpgradeMaxLevel {
+		return 0, false
+	}
+	return u.Price(s.Upgrades[u]), true
+}
+
+// Buy spends the ship's points on the next level of u and reports whether
+// it was bought. Upgrades are only sold while the ship is docked in a base.
+func (s *Ship) Buy(g *Game, u Upgrade) bool {
+	price, ok := s.UpgradePrice(u)
+	if !ok || !s.InBase || s.Points < price {
+		g.Audio.PlayLocal(6, 1.0)
+		return false
+	}
+	s.Points -= price
+	s.Upgrades[u]++
+	s.applyUpgrades()
+	g.Audio.PlayLocal(5, 1.0)
+	return true
+}
+
+// SetUpgrades replaces the upgrade levels of s, as synced from the host.
+// Unknown upgrades are ignored.
+func (s *Ship) SetUpgrades(levels []int) {
+	for u := range s.Upgrades {
+		s.Upgrades[u] = 0
+		if u < len(levels) {
+			s.Upgrades[u] = maxInt(0, min(levels[u], UpgradeMaxLevel))
+		}
+	}
+	s.applyUpgrades()
+}
+
+// applyUpgrades updates the ship stats kept in fields to the upgrade
+// levels.
+func (s *Ship) applyUpgrades() {
+	s.Shield.MaxT = ShipMaxShield + s.Upgrades[UpgradeShield]*ShieldPerUpgrade
+}
+
+// Upgraded reports whether any upgrade has been bought for s.
+func (s *Ship) Upgraded() bool {
+	return s.Upgrades != [UpgradeCount]int{}
+}
+
+// MaxEnergy returns the energy the ship repairs up to.
+func (s *Ship) MaxEnergy() int {
+	return ShipMaxEnergy + s.Upgrades[UpgradeEnergy]*EnergyPerUpgrade
+}
+
+// ThrustAcc returns the acceleration of the ship's engine.
+func (s *Ship) ThrustAcc() float64 {
+	return ShipThrustAcc * (1 + ThrustPerUpgrade*float64(s.Upgrades[UpgradeThrust]))
+}
+
+// RotationSpeed returns how fast the ship turns (radians per tick).
+func (s *Ship) RotationSpeed() float64 {
+	return ShipRotationSpeed * (1 + RotationPerUpgrade*float64(s.Upgrades[UpgradeRotation]))
+}
+
+// MaxWeapons returns the number of weapon upgrades the ship can carry.
+func (s *Ship) MaxWeapons() int {
+	return MaxWeapons + s.Upgrades[UpgradeWeaponSlots]*WeaponSlotsPerUpgrade
+}
+
+// lockTime shortens a lock time of ticks ticks by the ship's lock upgrades.
+func (s *Ship) lockTime(ticks int) int {
+	return maxInt(ticks*(100-LockPercentPerUpgrade*s.Upgrades[UpgradeLock])/100, 1)
+}
+
+// Keys the shop is operated with. They are fixed like the keys of the
+// rebinding screen.
+const (
+	shopKeyUp    = 38 // Up arrow
+	shopKeyDown  = 40 // Down arrow
+	shopKeyBuy   = 13 // Enter
+	shopKeyClose = 27 // Esc
+)
+
+// ShopScreen is the shop of a base. It opens with ActionShop while the ship
+// is docked and lists every upgrade with its level and price; Enter buys
+// the selected upgrade and Esc closes the shop. It closes by itself when
+// the ship leaves the base.
+//
+// The shop is also an input source of the local ship: purchases are sent as
+// KeyBuy controls, so they reach the host like any other input and are
+// recorded in replays.
+type ShopScreen struct {
+	Visible  bool
+	Selected Upgrade
+	pending  []Upgrade // Purchases not yet polled
+	sent     bool      // KeyBuy was polled on the previous tick
+}
+
+// NewShopScreen creates a closed shop.
+func NewShopScreen() *ShopScreen {
+	return &ShopScreen{}
+}
+
+// Open shows the shop with the first upgrade selected.
+func (s *ShopScreen) Open() {
+	s.Visible = true
+	s.Selected = 0
+}
+
+// HandleKey processes a key press while the shop is visible.
+func (s *ShopScreen) HandleKey(keyCode int) {
+	switch keyCode {
+	case shopKeyUp:
+		s.Selected = (s.Selected + UpgradeCount - 1) % UpgradeCount
+	case shopKeyDown:
+		s.Selected = (s.Selected + 1) % UpgradeCount
+	case shopKeyBuy:
+		s.pending = append(s.pending, s.Selected)
+	case shopKeyClose:
+		s.Visible = false
+	}
+}
+
+// Poll implements InputSource. Each purchase is held for one tick, and
+// KeyBuy is released for a tick between purchases, since ApplyInput only
+// buys when the key goes down.
+func (s *ShopScreen) Poll(g *Game, ship *Ship) ControlState {
+	if s.sent || len(s.pending) == 0 {
+		s.sent = false
+		return ControlState{}
+	}
+	u := s.pending[0]
+	s.pending = s.pending[1:]
+	s.sent = true
+	return ControlState{Keys: KeyBuy, Buy: u}
+}
+
+// OpenShop opens the shop if the local ship is docked in a base during play
+// and reports whether it did.
+func (g *Game) OpenShop() bool {
+	if g.State != StatePlaying || g.Playback != nil || !g.Ship.InBase {
+		return false
+	}
+	g.Shop.Open()
+	return true
+}
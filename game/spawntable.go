@@ -0,0 +1,261 @@
+package game
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/gopherjs/gopherjs/js"
+)
+
+// SpawnTableURL is the spawn table served alongside game.js.
+const SpawnTableURL = "waves.json"
+
+// SpawnTable holds everything that decides which enemies appear: the spawn
+// configuration of each enemy kind and the rules of the infinite world
+// waves. It is compiled in (DefaultSpawnTable) and can be replaced by a JSON
+// document, so waves can be tuned without rebuilding the game. Replays only
+// reproduce sessions played with the same table.
+type SpawnTable struct {
+	Enemies map[EnemyKind]EnemySpawnConfig `json:"enemies"`
+	Waves   WaveRules                      `json:"waves"`
+}
+
+// WaveRules decide when CheckWaveSpawn spawns enemies in the infinite world.
+// Enemies are topped up whenever fewer than TargetBase plus one per
+// TargetPerPoints points are alive per ship; each rule then spawns its kind
+// near every ship.
+type WaveRules struct {
+	TargetBase      int         `json:"targetBase"`
+	TargetPerPoints int         `json:"targetPerPoints"` // 0 disables scaling
+	Rules           []SpawnRule `json:"rules"`
+}
+
+// SpawnRule spawns one enemy kind during a wave.
+type SpawnRule struct {
+	Kind        EnemyKind `json:"kind"`
+	AbovePoints int       `json:"abovePoints"` // Only once the ship has more points; 0 always
+	Chance      float64   `json:"chance"`      // Probability per wave, 1 if omitted
+}
+
+// UnmarshalJSON decodes a rule, defaulting Chance to 1.
+func (r *SpawnRule) UnmarshalJSON(data []byte) error {
+	type rule SpawnRule
+	v := rule{Chance: 1}
+	if err := strictUnmarshal(data, &v); err != nil {
+		return err
+	}
+	*r = SpawnRule(v)
+	return nil
+}
+
+// applies reports whether the rule spawns its kind near ship this wave.
+// The RNG is only consulted for rules with a chance, so tables without
+// chances do not change the gameplay random sequence.
+func (r SpawnRule) applies(g *Game, ship *Ship) bool {
+	if r.AbovePoints > 0 && ship.Points <= r.AbovePoints {
+		return false
+	}
+	return r.Chance >= 1 || g.GameRNG.Random() > 1-r.Chance
+}
+
+// enemyKindIDs are the names of the enemy kinds in spawn tables.
+var enemyKindIDs = map[EnemyKind]string{
+	SmallFighter:  "small",
+	MediumFighter: "medium",
+	TurretFighter: "turret",
+	Boss:          "boss",
+	Kamikaze:      "kamikaze",
+	MineLayer:     "minelayer",
+	Carrier:       "carrier",
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (k EnemyKind) MarshalText() ([]byte, error) {
+	id, ok := enemyKindIDs[k]
+	if !ok {
+		return nil, fmt.Errorf("unknown enemy kind %d", int(k))
+	}
+	return []byte(id), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (k *EnemyKind) UnmarshalText(text []byte) error {
+	ids := make([]string, EnemyKindCount)
+	for kind, id := range enemyKindIDs {
+		if id == string(text) {
+			*k = kind
+			return nil
+		}
+		ids[kind] = id
+	}
+	return fmt.Errorf("unknown enemy kind %q (want one of %s)", text, strings.Join(ids, ", "))
+}
+
+// defaultWaveRules are the infinite world rules: small fighters always,
+// medium fighters after 1000 points, kamikazes after 1500, mine layers
+// after 2500, turrets after 3000, an occasional carrier after 4000 and a
+// rare boss after 5000.
+var defaultWaveRules = WaveRules{
+	TargetBase:      5,
+	TargetPerPoints: 500,
+	Rules: []SpawnRule{
+		{Kind: SmallFighter, Chance: 1},
+		{Kind: MediumFighter, AbovePoints: 1000, Chance: 1},
+		{Kind: TurretFighter, AbovePoints: 3000, Chance: 1},
+		{Kind: Boss, AbovePoints: 5000, Chance: 0.1},
+		{Kind: Kamikaze, AbovePoints: 1500, Chance: 1},
+		{Kind: MineLayer, AbovePoints: 2500, Chance: 0.5},
+		{Kind: Carrier, AbovePoints: 4000, Chance: 0.25},
+	},
+}
+
+// DefaultSpawnTable returns a copy of the compiled in spawn table.
+func DefaultSpawnTable() *SpawnTable {
+	t := &SpawnTable{
+		Enemies: make(map[EnemyKind]EnemySpawnConfig, len(enemyConfigs)),
+		Waves:   defaultWaveRules,
+	}
+	for kind, cfg := range enemyConfigs {
+		t.Enemies[kind] = cfg
+	}
+	t.Waves.Rules = append([]SpawnRule(nil), defaultWaveRules.Rules...)
+	return t
+}
+
+// UnmarshalJSON decodes a spawn table on top of t. Each enemy kind given is
+// merged onto the configuration t already has for it, and "waves" onto
+// t.Waves, so fields that are left out keep their values. A "rules" array
+// replaces the rules as a whole.
+func (t *SpawnTable) UnmarshalJSON(data []byte) error {
+	var doc struct {
+		Enemies map[EnemyKind]json.RawMessage `json:"enemies"`
+		Waves   json.RawMessage               `json:"waves"`
+	}
+	if err := strictUnmarshal(data, &doc); err != nil {
+		return err
+	}
+
+	if t.Enemies == nil {
+		t.Enemies = make(map[EnemyKind]EnemySpawnConfig, len(doc.Enemies))
+	}
+	// In kind order, so the same document always reports the same error
+	for kind := SmallFighter; kind < EnemyKindCount; kind++ {
+		raw, ok := doc.Enemies[kind]
+		if !ok {
+			continue
+		}
+		cfg := struct {
+			EnemySpawnConfig
+			HasFireDir bool `json:"hasFireDir"` // Unused; still accepted so older tables load
+		}{EnemySpawnConfig: t.Enemies[kind]}
+		if err := strictUnmarshal(raw, &cfg); err != nil {
+			return fmt.Errorf("enemies.%s: %w", enemyKindIDs[kind], err)
+		}
+		t.Enemies[kind] = cfg.EnemySpawnConfig
+	}
+	if doc.Waves != nil {
+		if err := strictUnmarshal(doc.Waves, &t.Waves); err != nil {
+			return fmt.Errorf("waves: %w", err)
+		}
+	}
+	return nil
+}
+
+// ParseSpawnTable decodes a JSON spawn table on top of the defaults: enemy
+// kinds and "waves" fields that are left out keep their compiled in values,
+// so {"enemies": {"medium": {"healthBase": 20}}} only makes medium fighters
+// tougher. Unknown fields are rejected so that typos do not go unnoticed,
+// and every invalid value is reported, not just the first.
+func ParseSpawnTable(data []byte) (*SpawnTable, error) {
+	t := DefaultSpawnTable()
+	if err := strictUnmarshal(data, t); err != nil {
+		var syntax *json.SyntaxError
+		if errors.As(err, &syntax) {
+			line, col := position(data, syntax.Offset)
+			return nil, fmt.Errorf("spawn table: line %d, column %d: %w", line, col, err)
+		}
+		return nil, fmt.Errorf("spawn table: %w", err)
+	}
+	if err := t.Validate(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// Validate checks every value of the table and returns all problems found.
+func (t *SpawnTable) Validate() error {
+	var errs []error
+	check := func(ok bool, field, msg string) {
+		if !ok {
+			errs = append(errs, fmt.Errorf("spawn table: %s: %s", field, msg))
+		}
+	}
+
+	for kind := SmallFighter; kind < EnemyKindCount; kind++ {
+		cfg, ok := t.Enemies[kind]
+		id := "enemies." + enemyKindIDs[kind]
+		if !ok {
+			check(false, id, "missing")
+			continue
+		}
+		check(cfg.CountBase >= 0, id+".countBase", "must not be negative")
+		check(cfg.CountPerPoints >= 0, id+".countPerPoints", "must not be negative")
+		check(cfg.HealthBase >= 1, id+".healthBase", "must be at least 1")
+		check(cfg.HealthPerPoints >= 0, id+".healthPerPoints", "must not be negative")
+		check(cfg.TBase >= 0, id+".tBase", "must not be negative")
+		check(cfg.TRange >= 0, id+".tRange", "must not be negative")
+		check(cfg.TStart >= 0, id+".tStart", "must not be negative")
+	}
+
+	check(t.Waves.TargetBase >= 0, "waves.targetBase", "must not be negative")
+	check(t.Waves.TargetPerPoints >= 0, "waves.targetPerPoints", "must not be negative")
+	for i, r := range t.Waves.Rules {
+		id := fmt.Sprintf("waves.rules[%d]", i)
+		check(r.AbovePoints >= 0, id+".abovePoints", "must not be negative")
+		check(r.Chance > 0 && r.Chance <= 1, id+".chance", "must be above 0 and at most 1")
+	}
+	return errors.Join(errs...)
+}
+
+// strictUnmarshal decodes JSON into v, rejecting unknown fields.
+func strictUnmarshal(data []byte, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	return dec.Decode(v)
+}
+
+// position converts the offset of a json.SyntaxError, which points just past
+// the offending byte, to the 1-based line and column of that byte.
+func position(data []byte, offset int64) (line, col int) {
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	before := data[:offset]
+	line = bytes.Count(before, []byte("\n")) + 1
+	col = len(before) - bytes.LastIndexByte(before, '\n') - 1
+	return line, col
+}
+
+// LoadSpawnTable fetches the spawn table at url and uses it instead of the
+// compiled in one. A missing file keeps the defaults; an invalid one is
+// reported on the console, so designers see their mistakes, and the
+// defaults stay.
+func (g *Game) LoadSpawnTable(url string) {
+	xhr := js.Global.Get("XMLHttpRequest").New()
+	xhr.Call("open", "GET", url, false) // false = synchronous
+	xhr.Call("send")
+
+	if xhr.Get("status").Int() != 200 {
+		Debug("No spawn table at", url, "- using the defaults")
+		return
+	}
+	t, err := ParseSpawnTable([]byte(xhr.Get("responseText").String()))
+	if err != nil {
+		js.Global.Get("console").Call("error", url+": "+err.Error())
+		return
+	}
+	g.Spawns = t
+}
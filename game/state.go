@@ -0,0 +1,172 @@
+package game
+
+// GameState is the screen the game is on. It decides what the game loop and
+// the input handlers do; the simulation itself (Step) never looks at it, so
+// replays and headless games are not affected.
+type GameState uint8
+
+const (
+	StateTitle    GameState = iota // Title screen over an attract mode demo
+	StatePlaying                   // Player in control
+	StatePaused                    // Simulation frozen, or local ship idle in multiplayer
+	StateGameOver                  // Local ship destroyed; the world keeps moving
+	StateVictory                   // Campaign won; the world keeps moving
+)
+
+// String returns the name of s.
+func (s GameState) String() string {
+	switch s {
+	case StateTitle:
+		return "title"
+	case StatePlaying:
+		return "playing"
+	case StatePaused:
+		return "paused"
+	case StateGameOver:
+		return "game over"
+	case StateVictory:
+		return "victory"
+	}
+	return "?"
+}
+
+// GameOverDelay is how many ticks the game over screen ignores restart
+// input, so that fire held when the ship was hit does not restart at once.
+const GameOverDelay = 30
+
+// TitleScreen is the menu of the title screen: a choice of game mode and
+// difficulty.
+type TitleScreen struct {
+	Selected   GameMode
+	Difficulty Difficulty
+}
+
+// HandleAction moves the selection with Thrust and Reverse (up and down by
+// default), picks an easier or harder difficulty with Rotate Left and Rotate
+// Right, and reports whether a was one of them.
+func (t *TitleScreen) HandleAction(a Action) bool {
+	switch a {
+	case ActionThrust, ActionReverse:
+		if t.Selected == ModeInfinite {
+			t.Selected = ModeCampaign
+		} else {
+			t.Selected = ModeInfinite
+		}
+		return true
+	case ActionRotateLeft:
+		t.Difficulty = t.Difficulty.Easier()
+		return true
+	case ActionRotateRight:
+		t.Difficulty = t.Difficulty.Harder()
+		return true
+	}
+	return false
+}
+
+// HandleStateAction applies a key press, gamepad button or click to the
+// current screen and reports whether it was used up. bound is false for keys
+// without an action, which only start the game from the title screen.
+//
+//   - Title: up and down pick the mode, left and right the difficulty;
+//     anything else but fullscreen and the rebinding screen starts it.
+//   - Playing: Pause pauses, unless a replay is running (it has its own).
+//   - Paused: Pause resumes; ship controls are swallowed. In multiplayer
+//     the game goes on without the local player (see AdvanceFrame).
+//   - Game over and victory: Fire restarts after GameOverDelay, Pause
+//     returns to the title screen.
+func (g *Game) HandleStateAction(a Action, bound bool) bool {
+	passThrough := bound && (a == ActionFullscreen || a == ActionBindings || a == ActionStats)
+
+	switch g.State {
+	case StateTitle:
+		if passThrough {
+			return false
+		}
+		if bound && g.Title.HandleAction(a) {
+			return true
+		}
+		g.StopDemo()
+		return true
+
+	case StatePlaying:
+		if bound && a == ActionPause && g.Playback == nil {
+			g.TogglePause()
+			return true
+		}
+
+	case StatePaused:
+		if bound && a == ActionPause {
+			g.TogglePause()
+			return true
+		}
+		return !passThrough && a != ActionMouseSteering
+
+	case StateGameOver, StateVictory:
+		if !bound {
+			return false
+		}
+		switch a {
+		case ActionFire:
+			if g.Tick-g.GameOverTick >= GameOverDelay {
+				g.Restart()
+			}
+			return true
+		case ActionPause:
+			g.StartDemo()
+			return true
+		}
+	}
+	return false
+}
+
+// TogglePause pauses a running game or resumes a paused one. A paused
+// multiplayer game keeps running for the other players.
+func (g *Game) TogglePause() {
+	switch g.State {
+	case StatePlaying:
+		g.State = StatePaused
+	case StatePaused:
+		g.State = StatePlaying
+	}
+}
+
+// Restart starts a new session in the same mode, difficulty and world.
+func (g *Game) Restart() {
+	g.StartMode(g.Mode)
+}
+
+// UpdateState runs the transitions that follow from the simulation, once per
+// frame after it has been stepped: leaving the base closes its shop, a won
+// campaign shows the victory screen, a lost ship ends the game, and a lost
+// demo ship starts the next demo flight in a different world.
+func (g *Game) UpdateState() {
+	if !g.Ship.InBase || g.State != StatePlaying {
+		g.Shop.Visible = false
+	}
+
+	if g.State == StatePlaying && g.Playback == nil &&
+		g.Campaign != nil && g.Campaign.Phase == CampaignComplete {
+		g.State = StateVictory
+		g.GameOverTick = g.Tick
+		g.Level.Text.T = 0 // The victory screen announces it instead
+		return
+	}
+
+	if g.Ship.IsAlive() {
+		return
+	}
+
+	switch g.State {
+	case StateTitle:
+		if g.Demo != nil {
+			g.Reset(g.GameSeed + 1)
+			g.Demo = NewAutopilot()
+			g.Ship.Input = g.Demo
+		}
+	case StatePlaying:
+		if g.Playback == nil {
+			g.State = StateGameOver
+			g.GameOverTick = g.Tick
+		}
+	}
+}
@@ -48,6 +48,36 @@ var Theme = struct {
 	EnemyBossColor  string
 	EnemyBossGlow   string
 	EnemyBossAccent string
+	// Kamikaze: 2014 Apple Watch Edition gold
+	EnemyKamikazeColor string
+	EnemyKamikazeGlow  string
+	// MineLayer: 2019 Midnight Green
+	EnemyMineLayerColor string
+	EnemyMineLayerGlow  string
+	// Carrier: 2021 Sierra Blue
+	EnemyCarrierColor string
+	EnemyCarrierGlow  string
+
+	// Mine colors
+	MineColor string
+	MineGlow  string
+
+	// Player weapon colors
+	MissileColor   string
+	MissileGlow    string
+	LaserColor     string
+	LaserCoreColor string
+	LaserGlow      string
+	ChargeColor    string
+	ChargeGlow     string
+
+	// World colors
+	AsteroidColor  string
+	AsteroidBorder string
+	NebulaColor    string
+	BeaconColor    string
+	WreckColor     string
+	DebrisColor    string
 
 	// Explosion colors
 	ExplosionColor     string
@@ -63,6 +93,15 @@ var Theme = struct {
 	TextScanlineColor   string
 	EnergyBarBackground string
 	EnergyBarBorder     string
+	RadarBackground     string
+	RadarLineColor      string
+	RadarAllyColor      string
+	RadarTargetColor    string // Ring around the locked target
+	RadarLockingColor   string // Ring around the target being locked
+
+	// Touch control colors
+	TouchControlColor       string
+	TouchControlActiveColor string
 
 	// Bonus item colors
 	BonusColorPoints  string
@@ -82,6 +121,8 @@ var Theme = struct {
 	ShipLineWidth      float64
 	EnemyLineWidth     float64
 	BulletLineWidth    float64
+	LaserLineWidth     float64
+	AsteroidLineWidth  float64
 	TorpedoLineWidth   float64
 	EnergyBarLineWidth float64
 
@@ -137,6 +178,36 @@ var Theme = struct {
 	EnemyBossColor:  "#86868B",
 	EnemyBossGlow:   "#A1A1A6",
 	EnemyBossAccent: "#FF3B30",
+	// Kamikaze: 2014 Apple Watch Edition gold
+	EnemyKamikazeColor: "#E3C08F",
+	EnemyKamikazeGlow:  "#FFD9A0",
+	// MineLayer: 2019 Midnight Green
+	EnemyMineLayerColor: "#4E5851",
+	EnemyMineLayerGlow:  "#7FA38C",
+	// Carrier: 2021 Sierra Blue
+	EnemyCarrierColor: "#9BB5CE",
+	EnemyCarrierGlow:  "#BFD7EE",
+
+	// Mine colors - warning red
+	MineColor: "#FF3B30",
+	MineGlow:  "#FF6961",
+
+	// Player weapon colors - Vipps orange family
+	MissileColor:   "#FF5B24",
+	MissileGlow:    "#FFB38F",
+	LaserColor:     "#FF7A4D",
+	LaserCoreColor: "#FFF",
+	LaserGlow:      "#FF5B24",
+	ChargeColor:    "#FFD166",
+	ChargeGlow:     "#FF7A4D",
+
+	// World colors - dim, so the world stays behind the action
+	AsteroidColor:  "#1A1A22",
+	AsteroidBorder: "#556",
+	NebulaColor:    "rgba(102, 34, 255, 0.05)",
+	BeaconColor:    "#9F0",
+	WreckColor:     "#667",
+	DebrisColor:    "#15151A",
 
 	// Explosion colors - orange/red
 	ExplosionColor:     "#F63",
@@ -152,6 +223,15 @@ var Theme = struct {
 	TextScanlineColor:   "#62F",
 	EnergyBarBackground: "#000",
 	EnergyBarBorder:     "#FFF",
+	RadarBackground:     "rgba(0, 0, 0, 0.5)",
+	RadarLineColor:      "#444",
+	RadarAllyColor:      "#0FF",
+	RadarTargetColor:    "#F00",
+	RadarLockingColor:   "#F80",
+
+	// Touch control colors - translucent so the game shows through
+	TouchControlColor:       "rgba(255, 255, 255, 0.25)",
+	TouchControlActiveColor: "rgba(255, 91, 36, 0.5)",
 
 	// Bonus item colors
 	BonusColorPoints:  "#FEB",
@@ -171,6 +251,8 @@ var Theme = struct {
 	ShipLineWidth:      3.0,
 	EnemyLineWidth:     3.0,
 	BulletLineWidth:    3.0,
+	LaserLineWidth:     6.0,
+	AsteroidLineWidth:  2.0,
 	TorpedoLineWidth:   3.0,
 	EnergyBarLineWidth: 0.5,
 
@@ -0,0 +1,125 @@
+package game
+
+import "math"
+
+// On-screen touch control layout in canvas coordinates. The joystick sits
+// in the bottom left corner under the left thumb, the buttons in the bottom
+// right corner under the right thumb.
+const (
+	TouchStickX      = 260.0
+	TouchStickY      = HEIGHT - 260.0
+	TouchStickRadius = 160.0 // Full deflection distance of the joystick
+	TouchKnobRadius  = 60.0
+	TouchFireX       = WIDTH - 240.0
+	TouchFireY       = HEIGHT - 260.0
+	TouchFireRadius  = 120.0
+	TouchLockX       = WIDTH - 520.0
+	TouchLockY       = HEIGHT - 160.0
+	TouchLockRadius  = 80.0
+)
+
+// touchControl is the on-screen control a touch started on.
+type touchControl int
+
+const (
+	touchStick touchControl = iota
+	touchFire
+	touchLock
+)
+
+// touchPoint is a finger on the screen.
+type touchPoint struct {
+	control touchControl
+	X, Y    float64
+}
+
+// TouchInput reads the controls from the on-screen joystick and buttons of
+// a touch screen. Each finger is tracked by its touch identifier and stays
+// bound to the control it first touched, so steering with one thumb while
+// holding fire with the other works, and sliding a finger off a button
+// keeps it pressed until the finger is lifted.
+//
+// A touch anywhere on the left half of the screen grabs the joystick; the
+// stick deflection is measured from the joystick center, with up thrusting
+// forward like the gamepad's left stick.
+type TouchInput struct {
+	Enabled bool               // A touch screen was detected; controls are drawn
+	touches map[int]touchPoint // Active touches by identifier
+}
+
+// NewTouchInput creates a touch source with no fingers down.
+func NewTouchInput() *TouchInput {
+	return &TouchInput{touches: make(map[int]touchPoint)}
+}
+
+// TouchStart binds a new touch at canvas position (x, y) to the fire or
+// lock button under it. Any other touch on the left half of the screen
+// grabs the joystick, unless a finger already holds it; the rest are
+// ignored.
+func (t *TouchInput) TouchStart(id int, x, y float64) {
+	t.Enabled = true
+
+	switch {
+	case math.Hypot(x-TouchFireX, y-TouchFireY) <= TouchFireRadius:
+		t.touches[id] = touchPoint{touchFire, x, y}
+	case math.Hypot(x-TouchLockX, y-TouchLockY) <= TouchLockRadius:
+		t.touches[id] = touchPoint{touchLock, x, y}
+	case x < WIDTH/2 && !t.holding(touchStick):
+		t.touches[id] = touchPoint{touchStick, x, y}
+	}
+}
+
+// TouchMove updates the position of a touch.
+func (t *TouchInput) TouchMove(id int, x, y float64) {
+	if p, ok := t.touches[id]; ok {
+		p.X, p.Y = x, y
+		t.touches[id] = p
+	}
+}
+
+// TouchEnd releases a lifted or cancelled touch.
+func (t *TouchInput) TouchEnd(id int) {
+	delete(t.touches, id)
+}
+
+// holding reports whether any finger is on control c.
+func (t *TouchInput) holding(c touchControl) bool {
+	for _, p := range t.touches {
+		if p.control == c {
+			return true
+		}
+	}
+	return false
+}
+
+// Stick returns the joystick deflection, each axis in [-1, 1] with the
+// length clamped to 1. Up and left are negative.
+func (t *TouchInput) Stick() (x, y float64) {
+	for _, p := range t.touches {
+		if p.control != touchStick {
+			continue
+		}
+		x = (p.X - TouchStickX) / TouchStickRadius
+		y = (p.Y - TouchStickY) / TouchStickRadius
+		if l := math.Hypot(x, y); l > 1 {
+			x, y = x/l, y/l
+		}
+	}
+	return x, y
+}
+
+// Poll implements InputSource.
+func (t *TouchInput) Poll(g *Game, s *Ship) ControlState {
+	x, y := t.Stick()
+	c := ControlState{
+		Turn:   Analog(applyDeadZone(x)),
+		Thrust: Analog(-applyDeadZone(y)),
+	}
+	if t.holding(touchFire) {
+		c.Keys |= KeyFire
+	}
+	if t.holding(touchLock) {
+		c.Keys |= KeyLock
+	}
+	return c
+}
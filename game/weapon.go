@@ -0,0 +1,346 @@
+package game
+
+import "math"
+
+// WeaponKind selects what the ship fires.
+type WeaponKind uint8
+
+const (
+	// WeaponSpread fires a bullet from every weapon upgrade at the locked
+	// target.
+	WeaponSpread WeaponKind = iota
+	// WeaponMissile launches missiles that home in on the nearest enemy.
+	WeaponMissile
+	// WeaponLaser burns the first enemy in front of the ship.
+	WeaponLaser
+	// WeaponCharge charges while fire is held and fires one heavy shot on
+	// release.
+	WeaponCharge
+	WeaponKindCount
+)
+
+// WeaponKindNames are the HUD names of the weapon kinds.
+var WeaponKindNames = [WeaponKindCount]string{
+	WeaponSpread:  "SPREAD",
+	WeaponMissile: "MISSILE",
+	WeaponLaser:   "LASER",
+	WeaponCharge:  "CHARGE",
+}
+
+// weaponPickups are the bonus types that hand out each weapon kind. The
+// spread gun is always carried.
+var weaponPickups = [WeaponKindCount]string{
+	WeaponMissile: "M",
+	WeaponLaser:   "L",
+	WeaponCharge:  "C",
+}
+
+// String returns the HUD name of the weapon kind.
+func (k WeaponKind) String() string {
+	if k < WeaponKindCount {
+		return WeaponKindNames[k]
+	}
+	return "UNKNOWN"
+}
+
+// WeaponForPickup returns the weapon kind a bonus type hands out.
+func WeaponForPickup(bonusType string) (WeaponKind, bool) {
+	for k, t := range weaponPickups {
+		if t != "" && t == bonusType {
+			return WeaponKind(k), true
+		}
+	}
+	return WeaponSpread, false
+}
+
+// Weapon tuning.
+const (
+	// MissileR is the size of a missile sprite.
+	MissileR = 12
+	// MissileSpeed is the launch speed of a missile.
+	MissileSpeed = 24.0
+	// MissileTurn is the largest angle in radians a missile turns per tick.
+	MissileTurn = 0.2
+	// MissileSeekRange is how far away a missile notices enemies.
+	MissileSeekRange = float64(WIDTH) / 2
+	// MissileMaxT is the lifetime of a missile in ticks.
+	MissileMaxT = 75
+	// MissileDamage is the damage of a missile hit.
+	MissileDamage = 3
+	// MissileReload is the number of ticks from one volley to the next.
+	MissileReload = 20
+	// MissileSpread is the angle between the missiles of a volley.
+	MissileSpread = math.Pi / 8
+
+	// LaserRange is the length of the laser beam.
+	LaserRange = float64(HEIGHT) * 0.6
+	// LaserInterval is the number of ticks from one laser burn to the next.
+	LaserInterval = 3
+	// LaserDamage is the damage of a laser burn.
+	LaserDamage = 1
+
+	// ChargeR is the size of a charge shot sprite.
+	ChargeR = 24
+	// ChargeMax is the number of ticks fire has to be held for a full
+	// charge.
+	ChargeMax = 60
+	// ChargeMaxDamage is the damage of a fully charged shot.
+	ChargeMaxDamage = 24
+	// ChargeSpeed is the speed of a charge shot.
+	ChargeSpeed = 35.0
+	// ChargeMaxT is the lifetime of a charge shot in ticks.
+	ChargeMaxT = 45
+)
+
+// WeaponDrop returns the bonus type an enemy that drops weapons leaves for
+// s: the first weapon s does not carry yet, or a weapon upgrade once it
+// carries them all.
+func WeaponDrop(s *Ship) string {
+	for k := WeaponSpread + 1; k < WeaponKindCount; k++ {
+		if s == nil || !s.HasWeapon(k) {
+			return weaponPickups[k]
+		}
+	}
+	return "+"
+}
+
+// HasWeapon reports whether the ship carries weapon kind k.
+func (s *Ship) HasWeapon(k WeaponKind) bool {
+	return k == WeaponSpread || s.Arsenal&(1<<k) != 0
+}
+
+// GiveWeapon adds weapon kind k to the ship and selects it.
+func (s *Ship) GiveWeapon(k WeaponKind) {
+	if k != WeaponSpread {
+		s.Arsenal |= 1 << k
+	}
+	s.SelectWeapon(k)
+}
+
+// SetArsenal replaces the weapons of s, as synced from the host: the
+// weapons collected besides the spread gun, the selected weapon and the
+// charge of the charge shot. Unknown weapons are dropped, and a selected
+// weapon s does not carry falls back to the spread gun.
+func (s *Ship) SetArsenal(weapon WeaponKind, arsenal uint8, charge int) {
+	s.Arsenal = arsenal & (1<<WeaponKindCount - 1) &^ (1 << WeaponSpread)
+	s.Weapon = WeaponSpread
+	if weapon < WeaponKindCount && s.HasWeapon(weapon) {
+		s.Weapon = weapon
+	}
+	s.Charge = 0
+	if s.Weapon == WeaponCharge {
+		s.Charge = maxInt(0, min(charge, ChargeMax))
+	}
+}
+
+// SelectWeapon selects weapon kind k and drops any charge.
+func (s *Ship) SelectWeapon(k WeaponKind) {
+	s.Weapon = k
+	s.Charge = 0
+}
+
+// NextWeapon selects the next weapon kind the ship carries, wrapping around
+// to the spread gun.
+func (s *Ship) NextWeapon(g *Game) {
+	k := s.Weapon
+	for i := WeaponKind(1); i < WeaponKindCount; i++ {
+		next := (k + i) % WeaponKindCount
+		if s.HasWeapon(next) {
+			s.SelectWeapon(next)
+			g.Audio.PlayLocal(27, 0.5)
+			return
+		}
+	}
+}
+
+// aimAngle returns the angle (0 = up) to fire in: at the locked target when
+// there is one, otherwise straight ahead.
+func (s *Ship) aimAngle() float64 {
+	if s.Target != nil && s.Target.IsAlive() {
+		return math.Atan2(s.Target.X-s.X, -(s.Target.Y + s.Target.YOffset - s.Y))
+	}
+	return s.Angle
+}
+
+// fireMissiles launches a volley of homing missiles, one more for every four
+// weapon upgrades. Missiles do not need a target lock.
+func (s *Ship) fireMissiles(g *Game) {
+	if s.Reload--; s.Reload > 0 {
+		return
+	}
+	s.Reload = MissileReload
+
+	count := 1 + len(s.Weapons)/4
+	angle := s.aimAngle()
+	for i := 0; i < count; i++ {
+		missile := g.Bullets.AcquireKind(MissileBullet)
+		if missile == nil {
+			break
+		}
+		a := angle + (float64(i)-float64(count-1)/2)*MissileSpread
+		missile.X, missile.Y = s.X, s.Y
+		missile.XAcc = math.Sin(a)*MissileSpeed + s.VelX
+		missile.YAcc = -math.Cos(a)*MissileSpeed + s.VelY
+		missile.T = MissileMaxT
+		missile.E = MissileDamage
+	}
+	g.Audio.PlayLocal(24, 0.5)
+}
+
+// fireLaser shows the laser beam for this tick and burns the first enemy,
+// boss part or obstacle it hits every LaserInterval ticks.
+func (s *Ship) fireLaser(g *Game) {
+	beam := g.Bullets.AcquireKind(LaserBullet)
+	if beam == nil {
+		return
+	}
+	angle := s.aimAngle()
+	dx, dy := math.Sin(angle), -math.Cos(angle)
+	e, p, length := g.LaserHit(s.X, s.Y, dx, dy, LaserRange)
+	// Asteroids and debris shade what is behind them
+	o, d := g.ObstacleOnRay(s.X, s.Y, dx, dy, length)
+	if o != nil {
+		e, p, length = nil, nil, d
+	}
+
+	// The beam lasts until the next tick's beam replaces it
+	beam.X, beam.Y = s.X, s.Y
+	beam.XAcc, beam.YAcc = dx*length, dy*length
+	beam.T = 1
+
+	if s.Reload--; s.Reload > 0 {
+		return
+	}
+	s.Reload = LaserInterval
+	g.Audio.PlayLocal(25, 0.3)
+	if o != nil {
+		g.DamageObstacle(o, LaserDamage)
+		return
+	}
+	if e == nil {
+		return
+	}
+	x, y := s.X+dx*length, s.Y+dy*length
+	if p != nil {
+		e.hitPart(g, p, x, y, LaserDamage)
+	} else {
+		e.hitCore(g, x, y, LaserDamage)
+	}
+}
+
+// chargeShot charges the charge shot for one tick while fire is held.
+func (s *Ship) chargeShot(g *Game) {
+	if s.Charge >= ChargeMax {
+		return
+	}
+	if s.Charge == 0 {
+		g.Audio.PlayLocal(26, 0.4)
+	}
+	s.Charge++
+}
+
+// ReleaseCharge fires the charge shot with damage scaled by how long fire
+// was held, up to ChargeMaxDamage.
+func (s *Ship) ReleaseCharge(g *Game) {
+	charge := s.Charge
+	s.Charge = 0
+	if charge == 0 || s.InBase {
+		return
+	}
+	shot := g.Bullets.AcquireKind(ChargeBullet)
+	if shot == nil {
+		return
+	}
+	angle := s.aimAngle()
+	if s.Target != nil && s.Target.IsAlive() {
+		angle = s.PredictTargetAngle(s.Target, ChargeSpeed)
+	}
+	shot.X, shot.Y = s.X, s.Y
+	shot.XAcc = math.Sin(angle)*ChargeSpeed + s.VelX
+	shot.YAcc = -math.Cos(angle)*ChargeSpeed + s.VelY
+	shot.T = ChargeMaxT
+	shot.E = 1 + charge*(ChargeMaxDamage-1)/ChargeMax
+	g.Audio.PlayLocal(16, 0.3+0.7*float64(charge)/ChargeMax)
+}
+
+// LaserHit casts a ray of the given length from (x, y) along the unit
+// vector (dx, dy) and returns the first live enemy it hits, the boss part
+// that was hit if any, and the distance to the hit. Without a hit the
+// distance is length. Like bullets, the beam hits the parts of a boss
+// before its core.
+func (g *Game) LaserHit(x, y, dx, dy, length float64) (*Enemy, *BossPart, float64) {
+	var hit *Enemy
+	var hitPart *BossPart
+	best := length
+	for _, e := range g.Enemies {
+		if !e.IsAlive() {
+			continue
+		}
+		var part *BossPart
+		nearest := math.Inf(1)
+		for _, p := range e.Parts {
+			if !p.IsAlive() {
+				continue
+			}
+			px, py := e.PartPosition(p)
+			if t, ok := rayCircle(x, y, dx, dy, px, py, p.Radius); ok && t < nearest {
+				part, nearest = p, t
+			}
+		}
+		if part == nil {
+			// Same core hitbox as for bullets
+			if t, ok := rayCircle(x, y, dx, dy, e.X, e.Y, e.Radius*0.6); ok {
+				nearest = t
+			}
+		}
+		if nearest < best {
+			hit, hitPart, best = e, part, nearest
+		}
+	}
+	return hit, hitPart, best
+}
+
+// rayCircle returns the distance along the ray from (x, y) in unit direction
+// (dx, dy) to where it enters the circle at (cx, cy) with radius r.
+func rayCircle(x, y, dx, dy, cx, cy, r float64) (float64, bool) {
+	ox, oy := cx-x, cy-y
+	along := ox*dx + oy*dy
+	off := ox*ox + oy*oy - along*along
+	if off > r*r {
+		return 0, false
+	}
+	t := along - math.Sqrt(r*r-off)
+	if t < 0 {
+		if ox*ox+oy*oy > r*r {
+			return 0, false // Behind the ray
+		}
+		t = 0 // Ray starts inside the circle
+	}
+	return t, true
+}
+
+// steerMissile turns a missile toward the nearest live enemy within
+// MissileSeekRange, by at most MissileTurn per tick.
+func (b *Bullet) steerMissile(g *Game) {
+	var target *Enemy
+	best := MissileSeekRange
+	for _, e := range g.Enemies {
+		if !e.IsAlive() {
+			continue
+		}
+		if d := math.Hypot(e.X-b.X, e.Y+e.YOffset-b.Y); d < best {
+			target, best = e, d
+		}
+	}
+	if target == nil {
+		return
+	}
+
+	speed := math.Hypot(b.XAcc, b.YAcc)
+	heading := math.Atan2(b.YAcc, b.XAcc)
+	turn := math.Remainder(math.Atan2(target.Y+target.YOffset-b.Y, target.X-b.X)-heading, 2*math.Pi)
+	turn = math.Max(-MissileTurn, math.Min(MissileTurn, turn))
+	heading += turn
+	b.XAcc = math.Cos(heading) * speed
+	b.YAcc = math.Sin(heading) * speed
+}
@@ -0,0 +1,417 @@
+package game
+
+import (
+	"math"
+	"sort"
+
+	"github.com/simukka/starship-sorades-13k/common"
+)
+
+// World generation constants
+const (
+	// ChunkSize is the edge length of a world chunk. Chunk (0, 0) is
+	// centered on the world origin.
+	ChunkSize = 8192.0
+	// ChunkLoadRadius is how many chunks around the camera and every ship
+	// are generated in each direction.
+	ChunkLoadRadius = 1
+	// ChunkUnloadRadius is how many chunks away from the camera and every
+	// ship a chunk is unloaded. It is larger than ChunkLoadRadius so flying
+	// along a chunk border does not regenerate chunks on every crossing.
+	ChunkUnloadRadius = 2
+
+	// ChunkBaseChance is the chance that a chunk holds a base.
+	ChunkBaseChance = 0.35
+	// ChunkMaxBelts is the largest number of asteroid belts in a chunk.
+	ChunkMaxBelts = 2
+	// ChunkMaxLandmarks is the largest number of landmarks in a chunk.
+	ChunkMaxLandmarks = 3
+	// chunkPlaceTries is how often generation looks for a free spot before
+	// it leaves something out.
+	chunkPlaceTries = 8
+	// chunkSpacing keeps generated things this far apart.
+	chunkSpacing = BaseShieldRadius
+)
+
+// ChunkCoord identifies a world chunk.
+type ChunkCoord struct {
+	X, Y int
+}
+
+// ChunkAt returns the coordinates of the chunk containing a world position.
+func ChunkAt(x, y float64) ChunkCoord {
+	return ChunkCoord{
+		X: int(math.Floor(x/ChunkSize + 0.5)),
+		Y: int(math.Floor(y/ChunkSize + 0.5)),
+	}
+}
+
+// Center returns the world position of the chunk's center.
+func (c ChunkCoord) Center() (x, y float64) {
+	return float64(c.X) * ChunkSize, float64(c.Y) * ChunkSize
+}
+
+// distance returns the chunk distance between c and o (the larger of the
+// distances along the axes).
+func (c ChunkCoord) distance(o ChunkCoord) int {
+	return maxInt(absInt(c.X-o.X), absInt(c.Y-o.Y))
+}
+
+func absInt(a int) int {
+	if a < 0 {
+		return -a
+	}
+	return a
+}
+
+// less orders chunks row by row, so everything built from the loaded
+// chunks comes out in the same order on every peer.
+func (c ChunkCoord) less(o ChunkCoord) bool {
+	if c.Y != o.Y {
+		return c.Y < o.Y
+	}
+	return c.X < o.X
+}
+
+// AsteroidBelt is a band of asteroids. The asteroids themselves are
+// obstacles of the chunk.
+type AsteroidBelt struct {
+	X, Y   float64 // Center of the belt
+	Angle  float64 // Direction the belt runs in
+	Length float64
+	Width  float64
+}
+
+// extent returns the radius of the circle around the belt's center that
+// holds all of its rocks.
+func (b *AsteroidBelt) extent() float64 {
+	return math.Hypot(b.Length/2, b.Width/2) + AsteroidMaxR
+}
+
+// LandmarkKind is the kind of a landmark.
+type LandmarkKind uint8
+
+const (
+	// LandmarkNebula is a colored gas cloud.
+	LandmarkNebula LandmarkKind = iota
+	// LandmarkBeacon is a blinking navigation buoy.
+	LandmarkBeacon
+	// LandmarkWreck is the hull of a derelict ship in a field of debris.
+	LandmarkWreck
+	LandmarkKindCount
+)
+
+// Landmark is a decorative feature that makes places in the world
+// recognizable. Landmarks do not collide with anything, but the debris
+// around a wreck does.
+type Landmark struct {
+	Kind   LandmarkKind
+	X, Y   float64 // World position
+	Radius float64
+	Angle  float64
+}
+
+// Chunk is a generated square of the world.
+type Chunk struct {
+	Coord     ChunkCoord
+	Bases     []*Base
+	Belts     []AsteroidBelt
+	Landmarks []Landmark
+	Obstacles []*Obstacle  // Asteroids and debris, indexed by ObstacleID.Index
+	grid      *SpatialGrid // Obstacles by position relative to the chunk corner
+}
+
+// World generates the infinite world chunk by chunk. The content of a chunk
+// only depends on the seed and the chunk coordinates, so every peer with
+// the same seed generates the same world, and an unloaded chunk comes back
+// unchanged. Generation has its own random stream per chunk and never draws
+// from GameRNG.
+//
+// The only thing that changes a chunk is damage to its debris. It is kept in
+// Damage, by obstacle ID, for the loaded chunks only: the damage of a chunk
+// is forgotten when it unloads, so Damage, and the damage synced to the
+// other players, stays small however far the ships travel.
+type World struct {
+	Seed   uint32
+	Chunks map[ChunkCoord]*Chunk
+	Damage map[ObstacleID]int // Damage taken by each damaged obstacle
+}
+
+// NewWorld creates a world without any loaded chunks.
+func NewWorld(seed uint32) *World {
+	return &World{
+		Seed:   seed,
+		Chunks: make(map[ChunkCoord]*Chunk),
+		Damage: make(map[ObstacleID]int),
+	}
+}
+
+// Clear unloads all chunks and repairs all obstacles.
+func (w *World) Clear() {
+	for c := range w.Chunks {
+		delete(w.Chunks, c)
+	}
+	for id := range w.Damage {
+		delete(w.Damage, id)
+	}
+}
+
+// SetSeed switches the world to another seed. The chunks of the old seed
+// are unloaded.
+func (w *World) SetSeed(seed uint32) {
+	if w.Seed != seed {
+		w.Seed = seed
+		w.Clear()
+	}
+}
+
+// Loaded returns the coordinates of the loaded chunks in a fixed order.
+func (w *World) Loaded() []ChunkCoord {
+	coords := make([]ChunkCoord, 0, len(w.Chunks))
+	for c := range w.Chunks {
+		coords = append(coords, c)
+	}
+	sort.Slice(coords, func(i, j int) bool { return coords[i].less(coords[j]) })
+	return coords
+}
+
+// Update loads the chunks within ChunkLoadRadius of any of the centers and
+// unloads those farther than ChunkUnloadRadius from all of them. It reports
+// whether any chunk was loaded or unloaded.
+func (w *World) Update(centers []ChunkCoord) bool {
+	changed := false
+	for c := range w.Chunks {
+		far := true
+		for _, center := range centers {
+			if c.distance(center) <= ChunkUnloadRadius {
+				far = false
+				break
+			}
+		}
+		if far {
+			delete(w.Chunks, c)
+			w.forgetDamage(c)
+			changed = true
+		}
+	}
+	for _, center := range centers {
+		for y := center.Y - ChunkLoadRadius; y <= center.Y+ChunkLoadRadius; y++ {
+			for x := center.X - ChunkLoadRadius; x <= center.X+ChunkLoadRadius; x++ {
+				c := ChunkCoord{x, y}
+				if _, ok := w.Chunks[c]; !ok {
+					w.Chunks[c] = w.Generate(c)
+					changed = true
+				}
+			}
+		}
+	}
+	return changed
+}
+
+// Generate creates the content of chunk c from the world seed. The origin
+// chunk gets no base of its own, since initBases places the starting base
+// there, and keeps its other content clear of it.
+func (w *World) Generate(c ChunkCoord) *Chunk {
+	rng := common.NewSeededRNG(common.LevelSeed(w.Seed, c.X, c.Y))
+	chunk := &Chunk{Coord: c}
+
+	var taken []circle
+	if c == (ChunkCoord{}) {
+		taken = append(taken, circle{0, 0, BaseShieldRadius})
+	} else if rng.Random() < ChunkBaseChance {
+		if x, y, ok := chunk.place(rng, BaseShieldRadius, taken); ok {
+			base := NewBase(x, y)
+			base.Generated = true
+			chunk.Bases = append(chunk.Bases, base)
+			taken = append(taken, circle{x, y, BaseShieldRadius})
+		}
+	}
+
+	for i := rng.RandomInt(0, ChunkMaxBelts+1); i > 0; i-- {
+		belt := AsteroidBelt{
+			Angle:  rng.RandomFloat(0, math.Pi),
+			Length: rng.RandomFloat(ChunkSize/8, ChunkSize/4),
+			Width:  rng.RandomFloat(AsteroidMaxR*4, AsteroidMaxR*8),
+		}
+		x, y, ok := chunk.place(rng, belt.extent(), taken)
+		if !ok {
+			continue
+		}
+		belt.X, belt.Y = x, y
+		chunk.addAsteroids(rng, belt)
+		chunk.Belts = append(chunk.Belts, belt)
+		taken = append(taken, circle{x, y, belt.extent()})
+	}
+
+	for i := rng.RandomInt(0, ChunkMaxLandmarks+1); i > 0; i-- {
+		l := Landmark{
+			Kind:  LandmarkKind(rng.RandomInt(0, int(LandmarkKindCount))),
+			Angle: rng.RandomFloat(0, 2*math.Pi),
+		}
+		switch l.Kind {
+		case LandmarkNebula:
+			l.Radius = rng.RandomFloat(400, 900)
+		case LandmarkBeacon:
+			l.Radius = 24
+		case LandmarkWreck:
+			l.Radius = rng.RandomFloat(80, 160)
+		}
+		reach := l.Radius
+		if l.Kind == LandmarkWreck {
+			reach *= DebrisFieldScale
+		}
+		x, y, ok := chunk.place(rng, reach, taken)
+		if !ok {
+			continue
+		}
+		l.X, l.Y = x, y
+		if l.Kind == LandmarkWreck {
+			chunk.addDebris(rng, l)
+		}
+		chunk.Landmarks = append(chunk.Landmarks, l)
+		taken = append(taken, circle{x, y, reach})
+	}
+
+	chunk.index()
+	w.applyDamage(chunk)
+	return chunk
+}
+
+// circle is the space taken by something generated in a chunk.
+type circle struct {
+	X, Y, R float64
+}
+
+// place picks a random spot for something of radius r inside the chunk,
+// clear of the taken circles. It gives up after chunkPlaceTries attempts.
+func (c *Chunk) place(rng *common.SeededRNG, r float64, taken []circle) (x, y float64, ok bool) {
+	cx, cy := c.Coord.Center()
+	half := ChunkSize/2 - r
+	for try := 0; try < chunkPlaceTries; try++ {
+		x = cx + rng.RandomFloat(-half, half)
+		y = cy + rng.RandomFloat(-half, half)
+		ok = true
+		for _, t := range taken {
+			if math.Hypot(x-t.X, y-t.Y) < r+t.R+chunkSpacing {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			return x, y, true
+		}
+	}
+	return 0, 0, false
+}
+
+// UpdateWorld loads the chunks around the camera and every ship and unloads
+// the ones left far behind. Ships are included so the host also has the
+// world around remote players.
+func (g *Game) UpdateWorld() {
+	centers := make([]ChunkCoord, 0, len(g.Ships)+1)
+	centers = append(centers, ChunkAt(g.Camera.X, g.Camera.Y))
+	for _, s := range g.Ships {
+		centers = append(centers, ChunkAt(s.X, s.Y))
+	}
+	if g.World.Update(centers) {
+		g.syncChunkBases()
+	}
+}
+
+// syncChunkBases replaces the generated bases in g.Bases with the bases of
+// the loaded chunks. Bases that were not generated, like the starting base,
+// keep their place at the front.
+func (g *Game) syncChunkBases() {
+	bases := g.Bases[:0]
+	for _, b := range g.Bases {
+		if !b.Generated {
+			bases = append(bases, b)
+		}
+	}
+	for _, c := range g.World.Loaded() {
+		bases = append(bases, g.World.Chunks[c].Bases...)
+	}
+	g.Bases = bases
+}
+
+// SetWorldSeed switches the world to the seed of another peer, so both
+// generate the same chunks.
+func (g *Game) SetWorldSeed(seed uint32) {
+	g.World.SetSeed(seed)
+	g.UpdateWorld()
+}
+
+// RenderWorld draws the landmarks and obstacles of the loaded chunks.
+func (g *Game) RenderWorld() {
+	for _, c := range g.World.Loaded() {
+		chunk := g.World.Chunks[c]
+		for i := range chunk.Landmarks {
+			chunk.Landmarks[i].Render(g)
+		}
+		for _, o := range chunk.Obstacles {
+			o.Render(g)
+		}
+	}
+}
+
+// Render draws the landmark.
+func (l *Landmark) Render(g *Game) {
+	if !g.Camera.IsOnScreen(l.X, l.Y, l.Radius) {
+		return
+	}
+	x, y := g.Camera.WorldToScreen(l.X, l.Y)
+
+	g.Ctx.Save()
+	switch l.Kind {
+	case LandmarkNebula:
+		// Overlapping translucent blobs
+		g.Ctx.SetFillStyle(Theme.NebulaColor)
+		for i := 0; i < 4; i++ {
+			a := l.Angle + float64(i)*math.Pi/2
+			g.Ctx.BeginPath()
+			g.Ctx.Arc(x+math.Cos(a)*l.Radius/3, y+math.Sin(a)*l.Radius/3, l.Radius*(0.5+0.1*float64(i)), 0, math.Pi*2)
+			g.Ctx.Fill()
+		}
+
+	case LandmarkBeacon:
+		// Blinks once a second
+		g.Ctx.SetStrokeStyle(Theme.BeaconColor)
+		g.Ctx.SetLineWidth(2.0)
+		g.Ctx.BeginPath()
+		g.Ctx.Arc(x, y, l.Radius, 0, math.Pi*2)
+		g.Ctx.Stroke()
+		if g.Tick%30 < 10 {
+			g.Ctx.SetShadowBlur(Theme.DefaultShadowBlur * 2)
+			g.Ctx.SetShadowColor(Theme.BeaconColor)
+			g.Ctx.SetFillStyle(Theme.BeaconColor)
+			g.Ctx.BeginPath()
+			g.Ctx.Arc(x, y, l.Radius/3, 0, math.Pi*2)
+			g.Ctx.Fill()
+		}
+
+	case LandmarkWreck:
+		// A hull broken in two halves drifting apart
+		g.Ctx.Translate(x, y)
+		g.Ctx.Rotate(l.Angle)
+		g.Ctx.SetStrokeStyle(Theme.WreckColor)
+		g.Ctx.SetLineWidth(Theme.AsteroidLineWidth)
+		r := l.Radius
+		g.Ctx.BeginPath()
+		g.Ctx.MoveTo(-r, -r*0.1)
+		g.Ctx.LineTo(-r*0.6, -r*0.35)
+		g.Ctx.LineTo(-r*0.1, -r*0.3)
+		g.Ctx.LineTo(-r*0.2, r*0.05)
+		g.Ctx.LineTo(-r*0.05, r*0.3)
+		g.Ctx.LineTo(-r*0.6, r*0.35)
+		g.Ctx.ClosePath()
+		g.Ctx.MoveTo(r*0.1, -r*0.35)
+		g.Ctx.LineTo(r*0.7, -r*0.25)
+		g.Ctx.LineTo(r, 0)
+		g.Ctx.LineTo(r*0.7, r*0.25)
+		g.Ctx.LineTo(r*0.15, r*0.4)
+		g.Ctx.LineTo(r*0.05, 0)
+		g.Ctx.ClosePath()
+		g.Ctx.Stroke()
+	}
+	g.Ctx.Restore()
+}
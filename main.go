@@ -4,6 +4,9 @@
 package main
 
 import (
+	"encoding/base64"
+	"strconv"
+
 	"github.com/gopherjs/gopherjs/js"
 	"github.com/simukka/starship-sorades-13k/game"
 )
@@ -19,11 +22,8 @@ func main() {
 	canvas.Set("width", game.WIDTH)
 	canvas.Set("height", game.HEIGHT)
 
-	// Get 2D context
-	ctx := canvas.Call("getContext", "2d")
-
 	// Create the game instance
-	g := game.NewGame(canvas, ctx)
+	g := game.NewGame(canvas)
 
 	// Expose multiplayer API to JavaScript
 	js.Global.Set("StarshipMultiplayer", map[string]interface{}{
@@ -47,6 +47,87 @@ func main() {
 		},
 	})
 
+	// Expose replay recording and playback API to JavaScript
+	js.Global.Set("StarshipReplay", map[string]interface{}{
+		// save returns the current recording as a base64 replay file
+		"save": func() string {
+			data, _ := g.Recording.MarshalBinary()
+			return base64.StdEncoding.EncodeToString(data)
+		},
+		// download saves the current recording as a replay file
+		"download": func() {
+			data, _ := g.Recording.MarshalBinary()
+			blob := js.Global.Get("Blob").New([]interface{}{data},
+				map[string]interface{}{"type": "application/octet-stream"})
+			url := js.Global.Get("URL").Call("createObjectURL", blob)
+			link := doc.Call("createElement", "a")
+			link.Set("href", url)
+			link.Set("download", "sorades-"+strconv.FormatUint(uint64(g.Recording.Seed), 10)+".srpl")
+			link.Call("click")
+			js.Global.Get("URL").Call("revokeObjectURL", url)
+		},
+		// play starts playback of a base64 replay file; returns an error message or ""
+		"play": func(encoded string) string {
+			data, err := base64.StdEncoding.DecodeString(encoded)
+			if err != nil {
+				return err.Error()
+			}
+			r, err := game.DecodeReplay(data)
+			if err != nil {
+				return err.Error()
+			}
+			g.StartReplay(r)
+			return ""
+		},
+		"stop": func() {
+			g.StopReplay()
+		},
+		"togglePause": func() {
+			if g.Playback != nil {
+				g.Playback.TogglePause()
+			}
+		},
+		"step": func() {
+			if g.Playback != nil {
+				g.Playback.Step()
+			}
+		},
+		"setSpeed": func(speed int) {
+			if g.Playback != nil {
+				g.Playback.SetSpeed(speed)
+			}
+		},
+		"isPlaying": func() bool {
+			return g.Playback != nil
+		},
+	})
+
+	// Expose game mode selection to JavaScript
+	js.Global.Set("StarshipGame", map[string]interface{}{
+		// setMode starts a fresh session in "campaign" or "infinite" mode
+		"setMode": func(mode string) {
+			g.StartMode(game.ParseGameMode(mode))
+		},
+		"getMode": func() string {
+			return g.Mode.String()
+		},
+		// setDifficulty picks "easy", "normal", "hard" or "insane" for the
+		// next session, as the title screen does
+		"setDifficulty": func(difficulty string) {
+			g.Title.Difficulty = game.ParseDifficulty(difficulty)
+		},
+		"getDifficulty": func() string {
+			return g.Difficulty.String()
+		},
+		// getWave returns the current campaign wave (1-13), or 0 outside the campaign
+		"getWave": func() int {
+			if g.Campaign == nil {
+				return 0
+			}
+			return g.Campaign.Wave + 1
+		},
+	})
+
 	// Clean up multiplayer connection when browser is closed
 	js.Global.Call("addEventListener", "beforeunload", func() {
 		g.LeaveMultiplayer()
@@ -0,0 +1,126 @@
+package render
+
+import (
+	"github.com/gopherjs/gopherjs/js"
+)
+
+// Canvas2D renders to an HTML canvas element through its 2D context.
+// It is also a Surface, so a Canvas2D created by NewImage can be drawn
+// onto other canvases.
+type Canvas2D struct {
+	canvas   *js.Object
+	ctx      *js.Object
+	patterns map[*Canvas2D]*js.Object // Cached repeat patterns per image
+}
+
+// NewCanvas2D wraps a canvas element.
+func NewCanvas2D(canvas *js.Object) *Canvas2D {
+	return &Canvas2D{
+		canvas:   canvas,
+		ctx:      canvas.Call("getContext", "2d"),
+		patterns: make(map[*Canvas2D]*js.Object),
+	}
+}
+
+// Canvas returns the wrapped canvas element.
+func (c *Canvas2D) Canvas() *js.Object {
+	return c.canvas
+}
+
+// Width implements Image.
+func (c *Canvas2D) Width() int {
+	return c.canvas.Get("width").Int()
+}
+
+// Height implements Image.
+func (c *Canvas2D) Height() int {
+	return c.canvas.Get("height").Int()
+}
+
+// source returns the canvas element behind an image of this backend.
+func source(img Image) *js.Object {
+	return img.(*Canvas2D).canvas
+}
+
+func (c *Canvas2D) Save()                  { c.ctx.Call("save") }
+func (c *Canvas2D) Restore()               { c.ctx.Call("restore") }
+func (c *Canvas2D) Translate(x, y float64) { c.ctx.Call("translate", x, y) }
+func (c *Canvas2D) Rotate(angle float64)   { c.ctx.Call("rotate", angle) }
+func (c *Canvas2D) Scale(x, y float64)     { c.ctx.Call("scale", x, y) }
+
+func (c *Canvas2D) SetFillStyle(style string)    { c.ctx.Set("fillStyle", style) }
+func (c *Canvas2D) SetStrokeStyle(style string)  { c.ctx.Set("strokeStyle", style) }
+func (c *Canvas2D) SetLineWidth(width float64)   { c.ctx.Set("lineWidth", width) }
+func (c *Canvas2D) SetLineJoin(join string)      { c.ctx.Set("lineJoin", join) }
+func (c *Canvas2D) SetMiterLimit(limit float64)  { c.ctx.Set("miterLimit", limit) }
+func (c *Canvas2D) SetShadowBlur(blur float64)   { c.ctx.Set("shadowBlur", blur) }
+func (c *Canvas2D) SetShadowColor(color string)  { c.ctx.Set("shadowColor", color) }
+func (c *Canvas2D) SetGlobalAlpha(alpha float64) { c.ctx.Set("globalAlpha", alpha) }
+func (c *Canvas2D) SetCompositeOperation(op string) {
+	c.ctx.Set("globalCompositeOperation", op)
+}
+func (c *Canvas2D) SetFont(font string)             { c.ctx.Set("font", font) }
+func (c *Canvas2D) SetTextAlign(align string)       { c.ctx.Set("textAlign", align) }
+func (c *Canvas2D) SetTextBaseline(baseline string) { c.ctx.Set("textBaseline", baseline) }
+
+// SetFillPattern implements Renderer. Patterns are created once per image.
+func (c *Canvas2D) SetFillPattern(img Image) {
+	src := img.(*Canvas2D)
+	pattern, ok := c.patterns[src]
+	if !ok {
+		pattern = c.ctx.Call("createPattern", src.canvas, "repeat")
+		c.patterns[src] = pattern
+	}
+	c.ctx.Set("fillStyle", pattern)
+}
+
+func (c *Canvas2D) BeginPath()          { c.ctx.Call("beginPath") }
+func (c *Canvas2D) ClosePath()          { c.ctx.Call("closePath") }
+func (c *Canvas2D) MoveTo(x, y float64) { c.ctx.Call("moveTo", x, y) }
+func (c *Canvas2D) LineTo(x, y float64) { c.ctx.Call("lineTo", x, y) }
+func (c *Canvas2D) Arc(x, y, radius, startAngle, endAngle float64) {
+	c.ctx.Call("arc", x, y, radius, startAngle, endAngle)
+}
+func (c *Canvas2D) Fill()   { c.ctx.Call("fill") }
+func (c *Canvas2D) Stroke() { c.ctx.Call("stroke") }
+
+func (c *Canvas2D) FillRect(x, y, w, h float64)   { c.ctx.Call("fillRect", x, y, w, h) }
+func (c *Canvas2D) StrokeRect(x, y, w, h float64) { c.ctx.Call("strokeRect", x, y, w, h) }
+
+// FillText implements Renderer.
+func (c *Canvas2D) FillText(text string, x, y, maxWidth float64) {
+	if maxWidth > 0 {
+		c.ctx.Call("fillText", text, x, y, maxWidth)
+	} else {
+		c.ctx.Call("fillText", text, x, y)
+	}
+}
+
+// StrokeText implements Renderer.
+func (c *Canvas2D) StrokeText(text string, x, y, maxWidth float64) {
+	if maxWidth > 0 {
+		c.ctx.Call("strokeText", text, x, y, maxWidth)
+	} else {
+		c.ctx.Call("strokeText", text, x, y)
+	}
+}
+
+// DrawImage implements Renderer.
+func (c *Canvas2D) DrawImage(img Image, x, y float64) {
+	c.ctx.Call("drawImage", source(img), x, y)
+}
+
+// DrawImageScaled implements Renderer.
+func (c *Canvas2D) DrawImageScaled(img Image, x, y, w, h float64) {
+	c.ctx.Call("drawImage", source(img), x, y, w, h)
+}
+
+// NewImage implements Renderer by drawing to an offscreen canvas element.
+func (c *Canvas2D) NewImage(width, height int, draw func(s Surface)) Image {
+	canvas := js.Global.Get("document").Call("createElement", "canvas")
+	canvas.Set("width", width)
+	canvas.Set("height", height)
+	img := NewCanvas2D(canvas)
+	draw(img)
+	return img
+}
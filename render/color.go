@@ -0,0 +1,92 @@
+package render
+
+import (
+	"strconv"
+	"strings"
+)
+
+// namedColors are the CSS color keywords understood by parseColor.
+var namedColors = map[string]rgba{
+	"transparent": {0, 0, 0, 0},
+	"black":       {0, 0, 0, 1},
+	"white":       {1, 1, 1, 1},
+	"red":         {1, 0, 0, 1},
+	"green":       {0, 128.0 / 255, 0, 1},
+	"blue":        {0, 0, 1, 1},
+	"yellow":      {1, 1, 0, 1},
+	"cyan":        {0, 1, 1, 1},
+	"magenta":     {1, 0, 1, 1},
+	"orange":      {1, 165.0 / 255, 0, 1},
+	"gray":        {128.0 / 255, 128.0 / 255, 128.0 / 255, 1},
+	"grey":        {128.0 / 255, 128.0 / 255, 128.0 / 255, 1},
+}
+
+// parseColor parses the CSS color forms the game uses: #rgb, #rgba,
+// #rrggbb, #rrggbbaa, rgb(r, g, b), rgba(r, g, b, a) and a few keywords.
+func parseColor(s string) (rgba, bool) {
+	s = strings.ToLower(strings.TrimSpace(s))
+
+	if c, ok := namedColors[s]; ok {
+		return c, true
+	}
+
+	if strings.HasPrefix(s, "#") {
+		hex := s[1:]
+		if len(hex) == 3 || len(hex) == 4 {
+			// Short form: each digit is doubled
+			long := make([]byte, 0, 8)
+			for i := 0; i < len(hex); i++ {
+				long = append(long, hex[i], hex[i])
+			}
+			hex = string(long)
+		}
+		if len(hex) == 6 {
+			hex += "ff"
+		}
+		if len(hex) != 8 {
+			return rgba{}, false
+		}
+		v, err := strconv.ParseUint(hex, 16, 32)
+		if err != nil {
+			return rgba{}, false
+		}
+		return rgba{
+			float64(v>>24&0xff) / 255,
+			float64(v>>16&0xff) / 255,
+			float64(v>>8&0xff) / 255,
+			float64(v&0xff) / 255,
+		}, true
+	}
+
+	open := strings.IndexByte(s, '(')
+	if open < 0 || !strings.HasSuffix(s, ")") {
+		return rgba{}, false
+	}
+	name := s[:open]
+	if name != "rgb" && name != "rgba" {
+		return rgba{}, false
+	}
+	args := strings.Split(s[open+1:len(s)-1], ",")
+	if len(args) != 3 && len(args) != 4 {
+		return rgba{}, false
+	}
+
+	var v [4]float64
+	v[3] = 1
+	for i, arg := range args {
+		arg = strings.TrimSpace(arg)
+		percent := strings.HasSuffix(arg, "%")
+		f, err := strconv.ParseFloat(strings.TrimSuffix(arg, "%"), 64)
+		if err != nil {
+			return rgba{}, false
+		}
+		switch {
+		case percent:
+			f /= 100
+		case i < 3:
+			f /= 255
+		}
+		v[i] = clamp01(f)
+	}
+	return rgba{v[0], v[1], v[2], v[3]}, true
+}
@@ -0,0 +1,797 @@
+package render
+
+import (
+	"image"
+	"image/png"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Raster renders into an in-memory RGBA image using only the standard
+// library. It needs no browser and produces the same pixels on every run,
+// which makes it suitable for golden-image tests and for saving frames as
+// PNG.
+//
+// Raster covers what the game draws: filled and stroked paths, rectangles,
+// sprites, repeat patterns, global alpha and the "lighter" and "source-atop"
+// composite operations. It approximates the browser rather than matching it
+// pixel for pixel:
+//   - shadows (glow) are not drawn
+//   - text uses a built-in 5x7 bitmap font scaled to the font size, and
+//     StrokeText fills the glyphs with the stroke style
+//   - images are sampled nearest-neighbor
+//   - lines have butt caps; "round" joins are drawn round, all others as
+//     miter with bevel fallback
+type Raster struct {
+	img   *image.RGBA
+	state rasterState
+	stack []rasterState
+	path  []subpath
+}
+
+// rasterState is the part of the context saved by Save.
+type rasterState struct {
+	m          matrix
+	fill       paint
+	stroke     paint
+	lineWidth  float64
+	lineJoin   string
+	miterLimit float64
+	alpha      float64
+	op         string
+	fontSize   float64
+	align      string
+	baseline   string
+}
+
+// subpath is a polyline in device coordinates.
+type subpath struct {
+	pts    []point
+	closed bool
+}
+
+type point struct{ x, y float64 }
+
+// rgba is a non-premultiplied color with components in 0..1.
+type rgba struct{ r, g, b, a float64 }
+
+// paint is a solid color or, when pattern is set, a repeating image
+// sampled through inv (device to pattern coordinates).
+type paint struct {
+	color   rgba
+	pattern *Raster
+	inv     matrix
+}
+
+// NewRaster creates a transparent raster of the given size.
+func NewRaster(width, height int) *Raster {
+	return &Raster{
+		img: image.NewRGBA(image.Rect(0, 0, width, height)),
+		state: rasterState{
+			m:          identity,
+			fill:       paint{color: rgba{0, 0, 0, 1}},
+			stroke:     paint{color: rgba{0, 0, 0, 1}},
+			lineWidth:  1,
+			lineJoin:   "miter",
+			miterLimit: 10,
+			alpha:      1,
+			op:         "source-over",
+			fontSize:   10,
+			align:      "start",
+			baseline:   "alphabetic",
+		},
+	}
+}
+
+// RGBA returns the image drawn so far. The image is shared, not copied.
+func (r *Raster) RGBA() *image.RGBA {
+	return r.img
+}
+
+// WritePNG encodes the image drawn so far as PNG.
+func (r *Raster) WritePNG(w io.Writer) error {
+	return png.Encode(w, r.img)
+}
+
+// Width implements Image.
+func (r *Raster) Width() int {
+	return r.img.Rect.Dx()
+}
+
+// Height implements Image.
+func (r *Raster) Height() int {
+	return r.img.Rect.Dy()
+}
+
+// =============================================================================
+// State and transform
+// =============================================================================
+
+func (r *Raster) Save() {
+	r.stack = append(r.stack, r.state)
+}
+
+func (r *Raster) Restore() {
+	if len(r.stack) == 0 {
+		return
+	}
+	r.state = r.stack[len(r.stack)-1]
+	r.stack = r.stack[:len(r.stack)-1]
+}
+
+func (r *Raster) Translate(x, y float64) { r.state.m = r.state.m.translate(x, y) }
+func (r *Raster) Rotate(angle float64)   { r.state.m = r.state.m.rotate(angle) }
+func (r *Raster) Scale(x, y float64)     { r.state.m = r.state.m.scale(x, y) }
+
+// Invalid colors are ignored, like the browser does.
+func (r *Raster) SetFillStyle(style string) {
+	if c, ok := parseColor(style); ok {
+		r.state.fill = paint{color: c}
+	}
+}
+
+func (r *Raster) SetStrokeStyle(style string) {
+	if c, ok := parseColor(style); ok {
+		r.state.stroke = paint{color: c}
+	}
+}
+
+// SetFillPattern implements Renderer. Like a canvas pattern, the image is
+// anchored to the transform in effect when filling.
+func (r *Raster) SetFillPattern(img Image) {
+	r.state.fill = paint{pattern: img.(*Raster)}
+}
+
+func (r *Raster) SetLineWidth(width float64)      { r.state.lineWidth = width }
+func (r *Raster) SetLineJoin(join string)         { r.state.lineJoin = join }
+func (r *Raster) SetMiterLimit(limit float64)     { r.state.miterLimit = limit }
+func (r *Raster) SetShadowBlur(blur float64)      {}
+func (r *Raster) SetShadowColor(color string)     {}
+func (r *Raster) SetGlobalAlpha(alpha float64)    { r.state.alpha = clamp01(alpha) }
+func (r *Raster) SetCompositeOperation(op string) { r.state.op = op }
+func (r *Raster) SetTextAlign(align string)       { r.state.align = align }
+func (r *Raster) SetTextBaseline(baseline string) { r.state.baseline = baseline }
+
+// SetFont implements Renderer. Only the pixel size is used.
+func (r *Raster) SetFont(font string) {
+	for _, field := range strings.Fields(font) {
+		if px := strings.TrimSuffix(field, "px"); px != field {
+			if size, err := strconv.ParseFloat(px, 64); err == nil && size > 0 {
+				r.state.fontSize = size
+			}
+			return
+		}
+	}
+}
+
+// =============================================================================
+// Paths
+// =============================================================================
+
+func (r *Raster) BeginPath() {
+	r.path = r.path[:0]
+}
+
+func (r *Raster) ClosePath() {
+	if len(r.path) == 0 {
+		return
+	}
+	last := &r.path[len(r.path)-1]
+	if len(last.pts) == 0 {
+		return
+	}
+	last.closed = true
+	// Drawing continues from the start of the closed subpath
+	r.path = append(r.path, subpath{pts: []point{last.pts[0]}})
+}
+
+func (r *Raster) MoveTo(x, y float64) {
+	r.moveToDevice(r.state.m.apply(x, y))
+}
+
+func (r *Raster) LineTo(x, y float64) {
+	r.lineToDevice(r.state.m.apply(x, y))
+}
+
+func (r *Raster) moveToDevice(p point) {
+	if n := len(r.path); n > 0 && len(r.path[n-1].pts) <= 1 && !r.path[n-1].closed {
+		r.path[n-1].pts = append(r.path[n-1].pts[:0], p)
+		return
+	}
+	r.path = append(r.path, subpath{pts: []point{p}})
+}
+
+func (r *Raster) lineToDevice(p point) {
+	if len(r.path) == 0 {
+		r.moveToDevice(p)
+		return
+	}
+	last := &r.path[len(r.path)-1]
+	last.pts = append(last.pts, p)
+}
+
+// Arc implements Renderer. The arc runs clockwise on screen (increasing
+// angle) and is joined to the current subpath by a straight line.
+func (r *Raster) Arc(x, y, radius, startAngle, endAngle float64) {
+	sweep := endAngle - startAngle
+	if sweep >= 2*math.Pi {
+		sweep = 2 * math.Pi
+	} else {
+		sweep = math.Mod(sweep, 2*math.Pi)
+		if sweep < 0 {
+			sweep += 2 * math.Pi
+		}
+	}
+
+	// Enough segments to keep the chord error under a tenth of a pixel
+	deviceRadius := radius * r.state.m.scaleFactor()
+	segments := 1
+	if deviceRadius > 0.1 {
+		step := 2 * math.Acos(1-0.1/deviceRadius)
+		segments = int(math.Ceil(sweep / step))
+	}
+	segments = maxInt(segments, 1)
+
+	for i := 0; i <= segments; i++ {
+		a := startAngle + sweep*float64(i)/float64(segments)
+		p := r.state.m.apply(x+math.Cos(a)*radius, y+math.Sin(a)*radius)
+		if i == 0 && (len(r.path) == 0 || len(r.path[len(r.path)-1].pts) == 0) {
+			r.moveToDevice(p)
+		} else {
+			r.lineToDevice(p)
+		}
+	}
+}
+
+func (r *Raster) Fill() {
+	polys := make([][]point, 0, len(r.path))
+	for _, sp := range r.path {
+		if len(sp.pts) >= 3 {
+			polys = append(polys, sp.pts)
+		}
+	}
+	r.fillPolygons(polys, r.resolve(r.state.fill))
+}
+
+func (r *Raster) Stroke() {
+	r.fillPolygons(r.strokePolygons(r.path), r.resolve(r.state.stroke))
+}
+
+// =============================================================================
+// Rectangles, text and images
+// =============================================================================
+
+func (r *Raster) FillRect(x, y, w, h float64) {
+	r.fillPolygons([][]point{r.rect(x, y, w, h)}, r.resolve(r.state.fill))
+}
+
+func (r *Raster) StrokeRect(x, y, w, h float64) {
+	path := []subpath{{pts: r.rect(x, y, w, h), closed: true}}
+	r.fillPolygons(r.strokePolygons(path), r.resolve(r.state.stroke))
+}
+
+func (r *Raster) FillText(text string, x, y, maxWidth float64) {
+	r.fillPolygons(r.textPolygons(text, x, y, maxWidth), r.resolve(r.state.fill))
+}
+
+func (r *Raster) StrokeText(text string, x, y, maxWidth float64) {
+	r.fillPolygons(r.textPolygons(text, x, y, maxWidth), r.resolve(r.state.stroke))
+}
+
+func (r *Raster) DrawImage(img Image, x, y float64) {
+	r.DrawImageScaled(img, x, y, float64(img.Width()), float64(img.Height()))
+}
+
+// DrawImageScaled implements Renderer with nearest-neighbor sampling.
+func (r *Raster) DrawImageScaled(img Image, x, y, w, h float64) {
+	src := img.(*Raster).img
+	if img == Image(r) {
+		// Drawing onto itself reads the pixels from before the draw
+		src = &image.RGBA{
+			Pix:    append([]uint8(nil), src.Pix...),
+			Stride: src.Stride,
+			Rect:   src.Rect,
+		}
+	}
+	if w == 0 || h == 0 || src.Rect.Empty() {
+		return
+	}
+
+	quad := r.rect(x, y, w, h)
+	inv, ok := r.state.m.invert()
+	if !ok {
+		return
+	}
+	sw, sh := float64(src.Rect.Dx()), float64(src.Rect.Dy())
+
+	x0, y0, x1, y1, ok := r.bounds([][]point{quad})
+	if !ok {
+		return
+	}
+	for py := y0; py < y1; py++ {
+		for px := x0; px < x1; px++ {
+			u, v := inv.applyXY(float64(px)+0.5, float64(py)+0.5)
+			u = (u - x) / w * sw
+			v = (v - y) / h * sh
+			if u < 0 || v < 0 || u >= sw || v >= sh {
+				continue
+			}
+			c := pixelAt(src, int(u), int(v))
+			r.blend(px, py, c, r.state.alpha)
+		}
+	}
+}
+
+// NewImage implements Renderer by drawing to a new Raster.
+func (r *Raster) NewImage(width, height int, draw func(s Surface)) Image {
+	img := NewRaster(width, height)
+	draw(img)
+	return img
+}
+
+// =============================================================================
+// Rasterization
+// =============================================================================
+
+// rect returns the corners of a user space rectangle in device space.
+func (r *Raster) rect(x, y, w, h float64) []point {
+	m := r.state.m
+	return []point{m.apply(x, y), m.apply(x+w, y), m.apply(x+w, y+h), m.apply(x, y+h)}
+}
+
+// resolve fixes a pattern paint to the current transform.
+func (r *Raster) resolve(p paint) paint {
+	if p.pattern != nil {
+		p.inv, _ = r.state.m.invert()
+	}
+	return p
+}
+
+// bounds returns the pixel rectangle covering polys, clipped to the image.
+func (r *Raster) bounds(polys [][]point) (x0, y0, x1, y1 int, ok bool) {
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, poly := range polys {
+		for _, p := range poly {
+			minX, maxX = math.Min(minX, p.x), math.Max(maxX, p.x)
+			minY, maxY = math.Min(minY, p.y), math.Max(maxY, p.y)
+		}
+	}
+	x0 = maxInt(0, int(math.Floor(math.Max(minX, -1))))
+	y0 = maxInt(0, int(math.Floor(math.Max(minY, -1))))
+	x1 = minInt(r.Width(), int(math.Ceil(math.Min(maxX, float64(r.Width())+1))))
+	y1 = minInt(r.Height(), int(math.Ceil(math.Min(maxY, float64(r.Height())+1))))
+	return x0, y0, x1, y1, x0 < x1 && y0 < y1
+}
+
+// Vertical samples per pixel row. Horizontal coverage is computed exactly.
+const subSamples = 4
+
+type edge struct {
+	x0, y0, x1, y1 float64
+	dir            int
+}
+
+type crossing struct {
+	x   float64
+	dir int
+}
+
+// fillPolygons fills the union of polys with the non-zero winding rule.
+func (r *Raster) fillPolygons(polys [][]point, p paint) {
+	x0, y0, x1, y1, ok := r.bounds(polys)
+	if !ok {
+		return
+	}
+
+	var edges []edge
+	for _, poly := range polys {
+		for i := range poly {
+			a, b := poly[i], poly[(i+1)%len(poly)]
+			switch {
+			case a.y < b.y:
+				edges = append(edges, edge{a.x, a.y, b.x, b.y, 1})
+			case a.y > b.y:
+				edges = append(edges, edge{b.x, b.y, a.x, a.y, -1})
+			}
+		}
+	}
+	sort.Slice(edges, func(i, j int) bool { return edges[i].y0 < edges[j].y0 })
+
+	width := x1 - x0
+	coverage := make([]float64, width)
+	var crossings []crossing
+
+	for py := y0; py < y1; py++ {
+		for i := range coverage {
+			coverage[i] = 0
+		}
+		touched := false
+
+		for s := 0; s < subSamples; s++ {
+			sy := float64(py) + (float64(s)+0.5)/subSamples
+
+			crossings = crossings[:0]
+			for _, e := range edges {
+				if e.y0 > sy {
+					break
+				}
+				if sy < e.y1 {
+					x := e.x0 + (sy-e.y0)*(e.x1-e.x0)/(e.y1-e.y0)
+					crossings = append(crossings, crossing{x, e.dir})
+				}
+			}
+			sort.Slice(crossings, func(i, j int) bool { return crossings[i].x < crossings[j].x })
+
+			winding := 0
+			for i, c := range crossings {
+				winding += c.dir
+				if winding == 0 || i+1 == len(crossings) {
+					continue
+				}
+				// Inside between this crossing and the next
+				left := math.Max(c.x, float64(x0))
+				right := math.Min(crossings[i+1].x, float64(x1))
+				for px := int(math.Floor(left)); float64(px) < right; px++ {
+					overlap := math.Min(right, float64(px+1)) - math.Max(left, float64(px))
+					if overlap > 0 {
+						coverage[px-x0] += overlap / subSamples
+						touched = true
+					}
+				}
+			}
+		}
+
+		if !touched {
+			continue
+		}
+		for i, cov := range coverage {
+			if cov <= 0 {
+				continue
+			}
+			px := x0 + i
+			r.blend(px, py, r.sample(p, px, py), math.Min(cov, 1)*r.state.alpha)
+		}
+	}
+}
+
+// sample returns the premultiplied paint color at the center of a pixel.
+func (r *Raster) sample(p paint, px, py int) rgba {
+	if p.pattern == nil {
+		c := p.color
+		return rgba{c.r * c.a, c.g * c.a, c.b * c.a, c.a}
+	}
+	src := p.pattern.img
+	u, v := p.inv.applyXY(float64(px)+0.5, float64(py)+0.5)
+	w, h := float64(src.Rect.Dx()), float64(src.Rect.Dy())
+	u = math.Mod(math.Floor(u), w)
+	v = math.Mod(math.Floor(v), h)
+	if u < 0 {
+		u += w
+	}
+	if v < 0 {
+		v += h
+	}
+	return pixelAt(src, int(u), int(v))
+}
+
+// pixelAt returns a premultiplied pixel of img.
+func pixelAt(img *image.RGBA, x, y int) rgba {
+	i := img.PixOffset(img.Rect.Min.X+x, img.Rect.Min.Y+y)
+	pix := img.Pix[i : i+4 : i+4]
+	return rgba{float64(pix[0]) / 255, float64(pix[1]) / 255, float64(pix[2]) / 255, float64(pix[3]) / 255}
+}
+
+// blend composites the premultiplied color c, scaled by coverage, onto a
+// pixel using the current composite operation.
+func (r *Raster) blend(px, py int, c rgba, coverage float64) {
+	if coverage <= 0 || c.a <= 0 {
+		return
+	}
+	i := r.img.PixOffset(px, py)
+	pix := r.img.Pix[i : i+4 : i+4]
+	d := rgba{float64(pix[0]) / 255, float64(pix[1]) / 255, float64(pix[2]) / 255, float64(pix[3]) / 255}
+	s := rgba{c.r * coverage, c.g * coverage, c.b * coverage, c.a * coverage}
+
+	var out rgba
+	switch r.state.op {
+	case "lighter":
+		out = rgba{
+			math.Min(1, s.r+d.r),
+			math.Min(1, s.g+d.g),
+			math.Min(1, s.b+d.b),
+			math.Min(1, s.a+d.a),
+		}
+	case "source-atop":
+		out = rgba{
+			s.r*d.a + d.r*(1-s.a),
+			s.g*d.a + d.g*(1-s.a),
+			s.b*d.a + d.b*(1-s.a),
+			d.a,
+		}
+	default: // source-over
+		out = rgba{
+			s.r + d.r*(1-s.a),
+			s.g + d.g*(1-s.a),
+			s.b + d.b*(1-s.a),
+			s.a + d.a*(1-s.a),
+		}
+	}
+
+	pix[0] = toByte(out.r)
+	pix[1] = toByte(out.g)
+	pix[2] = toByte(out.b)
+	pix[3] = toByte(out.a)
+}
+
+// strokePolygons outlines a path with the current line width and join.
+// The result is a set of same-orientation polygons whose union is the
+// stroke, so overlapping segments are not drawn twice.
+func (r *Raster) strokePolygons(path []subpath) [][]point {
+	hw := r.state.lineWidth / 2 * r.state.m.scaleFactor()
+	if hw <= 0 {
+		return nil
+	}
+
+	var polys [][]point
+	add := func(poly []point) {
+		if signedArea(poly) < 0 {
+			for i, j := 0, len(poly)-1; i < j; i, j = i+1, j-1 {
+				poly[i], poly[j] = poly[j], poly[i]
+			}
+		}
+		polys = append(polys, poly)
+	}
+
+	for _, sp := range path {
+		pts := dedupe(sp.pts, sp.closed)
+		if len(pts) < 2 {
+			continue
+		}
+
+		segments := len(pts) - 1
+		if sp.closed {
+			segments = len(pts)
+		}
+		for i := 0; i < segments; i++ {
+			a, b := pts[i], pts[(i+1)%len(pts)]
+			n := normal(a, b, hw)
+			add([]point{
+				{a.x + n.x, a.y + n.y},
+				{b.x + n.x, b.y + n.y},
+				{b.x - n.x, b.y - n.y},
+				{a.x - n.x, a.y - n.y},
+			})
+		}
+
+		// Joins at interior vertices, and at every vertex of closed paths
+		for i := 0; i < len(pts); i++ {
+			if !sp.closed && (i == 0 || i == len(pts)-1) {
+				continue
+			}
+			prev := pts[(i+len(pts)-1)%len(pts)]
+			next := pts[(i+1)%len(pts)]
+			if join := r.join(prev, pts[i], next, hw); join != nil {
+				add(join)
+			}
+		}
+	}
+	return polys
+}
+
+// join returns the polygon filling the outside corner at v.
+func (r *Raster) join(prev, v, next point, hw float64) []point {
+	if r.state.lineJoin == "round" {
+		return circle(v, hw)
+	}
+
+	n0 := normal(prev, v, hw)
+	n1 := normal(v, next, hw)
+	d0 := point{v.x - prev.x, v.y - prev.y}
+	d1 := point{next.x - v.x, next.y - v.y}
+	cross := d0.x*d1.y - d0.y*d1.x
+	if math.Abs(cross) < 1e-12 {
+		return nil
+	}
+
+	// The outside of the corner is opposite to the turn
+	side := 1.0
+	if cross > 0 {
+		side = -1
+	}
+	a := point{v.x + side*n0.x, v.y + side*n0.y}
+	b := point{v.x + side*n1.x, v.y + side*n1.y}
+
+	// Miter tip along the bisector of the two normals
+	bis := point{n0.x + n1.x, n0.y + n1.y}
+	bisLen := math.Hypot(bis.x, bis.y)
+	if bisLen > 0 {
+		cosHalf := bisLen / (2 * hw)
+		if ratio := 1 / cosHalf; ratio <= r.state.miterLimit {
+			tip := point{v.x + side*bis.x/bisLen*hw*ratio, v.y + side*bis.y/bisLen*hw*ratio}
+			return []point{v, a, tip, b}
+		}
+	}
+	return []point{v, a, b}
+}
+
+// textPolygons returns the glyph pixels of text as rectangles in device
+// space, placed according to the text align and baseline.
+func (r *Raster) textPolygons(text string, x, y, maxWidth float64) [][]point {
+	unit := r.state.fontSize / glyphCell
+	runes := []rune(text)
+	width := (float64(len(runes))*glyphAdvance - 1) * unit
+	scaleX := 1.0
+	if maxWidth > 0 && width > maxWidth {
+		scaleX = maxWidth / width
+		width = maxWidth
+	}
+
+	switch r.state.align {
+	case "center":
+		x -= width / 2
+	case "right", "end":
+		x -= width
+	}
+	top := y - glyphHeight*unit
+	switch r.state.baseline {
+	case "middle":
+		top = y - glyphHeight*unit/2
+	case "top", "hanging":
+		top = y
+	}
+
+	var polys [][]point
+	for i, ch := range runes {
+		rows := glyph(ch)
+		originX := x + float64(i)*glyphAdvance*unit*scaleX
+		for row, bits := range rows {
+			// One rectangle per horizontal run of set bits
+			for col := 0; col < glyphWidth; {
+				if bits&(1<<(glyphWidth-1-col)) == 0 {
+					col++
+					continue
+				}
+				start := col
+				for col < glyphWidth && bits&(1<<(glyphWidth-1-col)) != 0 {
+					col++
+				}
+				polys = append(polys, r.rect(
+					originX+float64(start)*unit*scaleX, top+float64(row)*unit,
+					float64(col-start)*unit*scaleX, unit))
+			}
+		}
+	}
+	return polys
+}
+
+// =============================================================================
+// Geometry helpers
+// =============================================================================
+
+// matrix is a 2D affine transform {a, b, c, d, e, f} mapping (x, y) to
+// (a*x + c*y + e, b*x + d*y + f), as in the canvas API.
+type matrix [6]float64
+
+var identity = matrix{1, 0, 0, 1, 0, 0}
+
+func (m matrix) apply(x, y float64) point {
+	px, py := m.applyXY(x, y)
+	return point{px, py}
+}
+
+func (m matrix) applyXY(x, y float64) (float64, float64) {
+	return m[0]*x + m[2]*y + m[4], m[1]*x + m[3]*y + m[5]
+}
+
+func (m matrix) translate(x, y float64) matrix {
+	m[4] += m[0]*x + m[2]*y
+	m[5] += m[1]*x + m[3]*y
+	return m
+}
+
+func (m matrix) rotate(angle float64) matrix {
+	sin, cos := math.Sincos(angle)
+	return matrix{
+		m[0]*cos + m[2]*sin,
+		m[1]*cos + m[3]*sin,
+		m[2]*cos - m[0]*sin,
+		m[3]*cos - m[1]*sin,
+		m[4],
+		m[5],
+	}
+}
+
+func (m matrix) scale(x, y float64) matrix {
+	m[0] *= x
+	m[1] *= x
+	m[2] *= y
+	m[3] *= y
+	return m
+}
+
+func (m matrix) invert() (matrix, bool) {
+	det := m[0]*m[3] - m[1]*m[2]
+	if det == 0 {
+		return identity, false
+	}
+	return matrix{
+		m[3] / det,
+		-m[1] / det,
+		-m[2] / det,
+		m[0] / det,
+		(m[2]*m[5] - m[3]*m[4]) / det,
+		(m[1]*m[4] - m[0]*m[5]) / det,
+	}, true
+}
+
+// scaleFactor is the average scaling of lengths by m.
+func (m matrix) scaleFactor() float64 {
+	return math.Sqrt(math.Abs(m[0]*m[3] - m[1]*m[2]))
+}
+
+// normal returns the left normal of a->b with length hw.
+func normal(a, b point, hw float64) point {
+	dx, dy := b.x-a.x, b.y-a.y
+	l := math.Hypot(dx, dy)
+	return point{-dy / l * hw, dx / l * hw}
+}
+
+func circle(c point, radius float64) []point {
+	segments := maxInt(8, int(math.Ceil(radius*2)))
+	pts := make([]point, segments)
+	for i := range pts {
+		a := 2 * math.Pi * float64(i) / float64(segments)
+		pts[i] = point{c.x + math.Cos(a)*radius, c.y + math.Sin(a)*radius}
+	}
+	return pts
+}
+
+func signedArea(poly []point) float64 {
+	area := 0.0
+	for i := range poly {
+		a, b := poly[i], poly[(i+1)%len(poly)]
+		area += a.x*b.y - b.x*a.y
+	}
+	return area / 2
+}
+
+// dedupe drops repeated points, including a closing point equal to the
+// first one of a closed path.
+func dedupe(pts []point, closed bool) []point {
+	out := make([]point, 0, len(pts))
+	for _, p := range pts {
+		if len(out) == 0 || out[len(out)-1] != p {
+			out = append(out, p)
+		}
+	}
+	if closed && len(out) > 1 && out[0] == out[len(out)-1] {
+		out = out[:len(out)-1]
+	}
+	return out
+}
+
+func clamp01(v float64) float64 {
+	return math.Max(0, math.Min(1, v))
+}
+
+func toByte(v float64) uint8 {
+	return uint8(math.Round(clamp01(v) * 255))
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
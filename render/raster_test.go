@@ -0,0 +1,182 @@
+package render
+
+import (
+	"bytes"
+	"image/png"
+	"math"
+	"testing"
+)
+
+func TestParseColor(t *testing.T) {
+	tests := []struct {
+		in   string
+		want rgba
+		ok   bool
+	}{
+		{"#FFF", rgba{1, 1, 1, 1}, true},
+		{"#ff0000", rgba{1, 0, 0, 1}, true},
+		{"#00ff0080", rgba{0, 1, 0, 128.0 / 255}, true},
+		{"rgba(0, 0, 0, 0.75)", rgba{0, 0, 0, 0.75}, true},
+		{"rgba(0,0,0,.5)", rgba{0, 0, 0, 0.5}, true},
+		{"rgb(255,255,0)", rgba{1, 1, 0, 1}, true},
+		{"rgb(256,255,0)", rgba{1, 1, 0, 1}, true}, // Clamped like the browser
+		{"white", rgba{1, 1, 1, 1}, true},
+		{"#12", rgba{}, false},
+		{"hsl(0, 0%, 0%)", rgba{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseColor(tt.in)
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("parseColor(%q) = %v, %v; want %v, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+// at returns the RGBA bytes of a pixel.
+func at(r *Raster, x, y int) [4]uint8 {
+	i := r.img.PixOffset(x, y)
+	return [4]uint8(r.img.Pix[i : i+4])
+}
+
+func TestRaster_FillRectCoversWholePixels(t *testing.T) {
+	r := NewRaster(8, 8)
+	r.SetFillStyle("#F00")
+	r.FillRect(2, 2, 4, 4)
+
+	if got := at(r, 2, 2); got != [4]uint8{255, 0, 0, 255} {
+		t.Errorf("Inside pixel = %v, want opaque red", got)
+	}
+	if got := at(r, 1, 2); got != [4]uint8{} {
+		t.Errorf("Outside pixel = %v, want transparent", got)
+	}
+	if got := at(r, 6, 6); got != [4]uint8{} {
+		t.Errorf("Pixel past the right edge = %v, want transparent", got)
+	}
+}
+
+func TestRaster_PartialCoverageIsAntialiased(t *testing.T) {
+	r := NewRaster(4, 4)
+	r.SetFillStyle("#FFF")
+	r.FillRect(0, 0, 1.5, 4)
+
+	if got := at(r, 1, 0)[3]; got != 128 {
+		t.Errorf("Half covered pixel alpha = %d, want 128", got)
+	}
+}
+
+func TestRaster_TransformAndRestore(t *testing.T) {
+	r := NewRaster(8, 8)
+	r.SetFillStyle("#0F0")
+	r.Save()
+	r.Translate(4, 4)
+	r.FillRect(0, 0, 1, 1)
+	r.Restore()
+	r.FillRect(0, 0, 1, 1)
+
+	if at(r, 4, 4)[1] != 255 || at(r, 0, 0)[1] != 255 {
+		t.Error("Translated and restored rectangles should both be drawn")
+	}
+}
+
+func TestRaster_LighterAddsColors(t *testing.T) {
+	r := NewRaster(1, 1)
+	r.SetFillStyle("rgb(100, 0, 0)")
+	r.FillRect(0, 0, 1, 1)
+	r.SetCompositeOperation("lighter")
+	r.SetFillStyle("rgb(100, 50, 0)")
+	r.FillRect(0, 0, 1, 1)
+
+	if got := at(r, 0, 0); got != [4]uint8{200, 50, 0, 255} {
+		t.Errorf("Lighter result = %v, want [200 50 0 255]", got)
+	}
+}
+
+func TestRaster_StrokeOverlapsDrawnOnce(t *testing.T) {
+	r := NewRaster(16, 16)
+	r.SetGlobalAlpha(0.5)
+	r.SetStrokeStyle("#FFF")
+	r.SetLineWidth(4)
+	r.BeginPath()
+	r.MoveTo(2, 8)
+	r.LineTo(14, 8)
+	r.MoveTo(8, 2)
+	r.LineTo(8, 14)
+	r.Stroke()
+
+	// The crossing is covered by two segments but must not be darker
+	if cross, arm := at(r, 8, 8), at(r, 4, 8); cross != arm {
+		t.Errorf("Crossing pixel %v differs from arm pixel %v", cross, arm)
+	}
+}
+
+func TestRaster_ArcFillArea(t *testing.T) {
+	r := NewRaster(64, 64)
+	r.SetFillStyle("#FFF")
+	r.BeginPath()
+	r.Arc(32, 32, 20, 0, math.Pi*2)
+	r.Fill()
+
+	total := 0.0
+	for i := 3; i < len(r.img.Pix); i += 4 {
+		total += float64(r.img.Pix[i]) / 255
+	}
+	if want := math.Pi * 20 * 20; math.Abs(total-want) > want*0.01 {
+		t.Errorf("Circle area = %.1f, want %.1f", total, want)
+	}
+}
+
+func TestRaster_PatternAndSelfDraw(t *testing.T) {
+	r := NewRaster(4, 4)
+	tile := r.NewImage(2, 2, func(s Surface) {
+		s.SetFillStyle("#FFF")
+		s.FillRect(0, 0, 1, 1)
+		s.Translate(2, 0)
+		s.Scale(-1, 1)
+		s.DrawImage(s, 0, 0) // Mirror onto itself
+	})
+	r.SetFillPattern(tile)
+	r.FillRect(0, 0, 4, 4)
+
+	for _, p := range [][2]int{{0, 0}, {1, 0}, {2, 0}, {3, 0}} {
+		if at(r, p[0], p[1])[3] != 255 {
+			t.Errorf("Pixel %v should be set by the mirrored pattern", p)
+		}
+	}
+	if at(r, 0, 1)[3] != 0 {
+		t.Error("Pixel (0,1) should be empty")
+	}
+}
+
+func TestRaster_TextUsesFontSizeAndAlign(t *testing.T) {
+	r := NewRaster(64, 16)
+	r.SetFont("bold 8px monospace")
+	r.SetTextAlign("right")
+	r.SetFillStyle("#FFF")
+	r.FillText("I", 64, 8, 0)
+
+	// 8px font: one pixel per font unit, glyph rows 1..7 above the baseline
+	if at(r, 61, 1)[3] != 255 || at(r, 61, 7)[3] != 255 {
+		t.Error("Right aligned I should have its stem in column 61")
+	}
+	if at(r, 61, 8)[3] != 0 {
+		t.Error("Nothing should be drawn below the alphabetic baseline")
+	}
+}
+
+func TestRaster_WritePNG(t *testing.T) {
+	r := NewRaster(3, 2)
+	r.SetFillStyle("#00F")
+	r.FillRect(0, 0, 3, 2)
+
+	var buf bytes.Buffer
+	if err := r.WritePNG(&buf); err != nil {
+		t.Fatalf("WritePNG: %v", err)
+	}
+	img, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatalf("png.Decode: %v", err)
+	}
+	if _, _, b, _ := img.At(2, 1).RGBA(); b != 0xffff || img.Bounds().Dx() != 3 {
+		t.Errorf("Decoded PNG does not match the raster")
+	}
+}
@@ -0,0 +1,66 @@
+// Package render abstracts the 2D drawing operations the game uses, so the
+// same drawing code can target the browser canvas or other backends.
+//
+// The API mirrors the Canvas 2D context: styles are CSS strings ("#ff8800",
+// "rgba(0, 0, 0, 0.5)", "bold 12px monospace"), angles are radians and
+// paths are built with BeginPath/MoveTo/LineTo/Arc before Fill or Stroke.
+package render
+
+// Image is a sprite or offscreen surface that can be drawn with DrawImage.
+// Images are only valid with the backend that created them.
+type Image interface {
+	Width() int
+	Height() int
+}
+
+// Surface is an offscreen image that is being drawn to.
+type Surface interface {
+	Renderer
+	Image
+}
+
+// Renderer covers the drawing operations of the game.
+type Renderer interface {
+	// State stack and transform
+	Save()
+	Restore()
+	Translate(x, y float64)
+	Rotate(angle float64)
+	Scale(x, y float64)
+
+	// Styles
+	SetFillStyle(style string)
+	SetFillPattern(img Image) // Fill with img repeated in both directions
+	SetStrokeStyle(style string)
+	SetLineWidth(width float64)
+	SetLineJoin(join string)
+	SetMiterLimit(limit float64)
+	SetShadowBlur(blur float64)
+	SetShadowColor(color string)
+	SetGlobalAlpha(alpha float64)
+	SetCompositeOperation(op string) // "source-over", "lighter", "source-atop"
+	SetFont(font string)
+	SetTextAlign(align string)       // "left", "center", "right"
+	SetTextBaseline(baseline string) // "alphabetic", "middle"
+
+	// Paths
+	BeginPath()
+	ClosePath()
+	MoveTo(x, y float64)
+	LineTo(x, y float64)
+	Arc(x, y, radius, startAngle, endAngle float64)
+	Fill()
+	Stroke()
+
+	// Rectangles, text and images
+	FillRect(x, y, w, h float64)
+	StrokeRect(x, y, w, h float64)
+	FillText(text string, x, y, maxWidth float64) // maxWidth <= 0 means unlimited
+	StrokeText(text string, x, y, maxWidth float64)
+	DrawImage(img Image, x, y float64)
+	DrawImageScaled(img Image, x, y, w, h float64)
+
+	// NewImage creates an offscreen image of the given size and draws it
+	// with draw. Used to pre-render sprites once at startup.
+	NewImage(width, height int, draw func(s Surface)) Image
+}
@@ -50,12 +50,20 @@ type Peer struct {
 
 // Room represents a game session
 type Room struct {
-	ID      string
-	Peers   map[string]*Peer
-	Created time.Time
-	mu      sync.RWMutex
+	ID         string
+	Difficulty string // Difficulty preset of the game, set by the first peer
+	Peers      map[string]*Peer
+	Created    time.Time
+	mu         sync.RWMutex
 }
 
+// defaultDifficulty is the difficulty of peers that don't send one
+const defaultDifficulty = "normal"
+
+// difficulties are the difficulty presets of the game; rooms are only
+// created for these.
+var difficulties = map[string]bool{"easy": true, "normal": true, "hard": true, "insane": true}
+
 // SignalingServer manages WebRTC signaling
 type SignalingServer struct {
 	rooms map[string]*Room
@@ -98,8 +106,9 @@ func (s *SignalingServer) cleanup() {
 	}
 }
 
-// GetOrCreateRoom gets or creates a room
-func (s *SignalingServer) GetOrCreateRoom(roomID string) *Room {
+// GetOrCreateRoom gets or creates a room. A new room plays at the given
+// difficulty.
+func (s *SignalingServer) GetOrCreateRoom(roomID, difficulty string) *Room {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -108,22 +117,28 @@ func (s *SignalingServer) GetOrCreateRoom(roomID string) *Room {
 	}
 
 	room := &Room{
-		ID:      roomID,
-		Peers:   make(map[string]*Peer),
-		Created: time.Now(),
+		ID:         roomID,
+		Difficulty: difficulty,
+		Peers:      make(map[string]*Peer),
+		Created:    time.Now(),
 	}
 	s.rooms[roomID] = room
-	log.Printf("Created room %s", roomID)
+	log.Printf("Created room %s (%s)", roomID, difficulty)
 	return room
 }
 
-// AddPeer adds a peer to a room
-func (s *SignalingServer) AddPeer(roomID, peerID string) *Peer {
-	room := s.GetOrCreateRoom(roomID)
+// AddPeer adds a peer to a room. Peers of another difficulty than the
+// room's are turned away, so scores are only compared within a difficulty.
+func (s *SignalingServer) AddPeer(roomID, peerID, difficulty string) (*Peer, error) {
+	room := s.GetOrCreateRoom(roomID, difficulty)
 
 	room.mu.Lock()
 	defer room.mu.Unlock()
 
+	if room.Difficulty != difficulty {
+		return nil, fmt.Errorf("room %s plays at %s difficulty", roomID, room.Difficulty)
+	}
+
 	// Remove existing peer with same ID if exists
 	if existing, exists := room.Peers[peerID]; exists {
 		close(existing.Messages)
@@ -138,7 +153,7 @@ func (s *SignalingServer) AddPeer(roomID, peerID string) *Peer {
 	room.Peers[peerID] = peer
 	log.Printf("Peer %s joined room %s", peerID, roomID)
 
-	return peer
+	return peer, nil
 }
 
 // RemovePeer removes a peer from a room
@@ -247,16 +262,24 @@ func handleSignaling(w http.ResponseWriter, r *http.Request) {
 
 	roomID := r.URL.Query().Get("room")
 	peerID := r.URL.Query().Get("peer")
+	difficulty := r.URL.Query().Get("difficulty")
+	if difficulty == "" {
+		difficulty = defaultDifficulty
+	}
 
 	if roomID == "" || peerID == "" {
 		http.Error(w, "room and peer query parameters required", http.StatusBadRequest)
 		return
 	}
+	if !difficulties[difficulty] {
+		http.Error(w, fmt.Sprintf("unknown difficulty %q", difficulty), http.StatusBadRequest)
+		return
+	}
 
 	switch r.Method {
 	case "GET":
 		// SSE connection for receiving messages
-		handleSSE(w, r, roomID, peerID)
+		handleSSE(w, r, roomID, peerID, difficulty)
 	case "POST":
 		// Send signaling message
 		handleSignalPost(w, r, roomID, peerID)
@@ -266,7 +289,7 @@ func handleSignaling(w http.ResponseWriter, r *http.Request) {
 }
 
 // handleSSE handles Server-Sent Events for a peer
-func handleSSE(w http.ResponseWriter, r *http.Request, roomID, peerID string) {
+func handleSSE(w http.ResponseWriter, r *http.Request, roomID, peerID, difficulty string) {
 	// Set SSE headers
 	w.Header().Set("Content-Type", "text/event-stream")
 	w.Header().Set("Cache-Control", "no-cache")
@@ -279,7 +302,11 @@ func handleSSE(w http.ResponseWriter, r *http.Request, roomID, peerID string) {
 	}
 
 	// Add peer to room
-	peer := signaling.AddPeer(roomID, peerID)
+	peer, err := signaling.AddPeer(roomID, peerID, difficulty)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
 
 	// Send current peers list
 	peers := signaling.GetPeersInRoom(roomID)
@@ -374,9 +401,10 @@ func handleRooms(w http.ResponseWriter, r *http.Request) {
 	for roomID, room := range signaling.rooms {
 		room.mu.RLock()
 		rooms = append(rooms, map[string]interface{}{
-			"id":        roomID,
-			"peerCount": len(room.Peers),
-			"created":   room.Created.Unix(),
+			"id":         roomID,
+			"difficulty": room.Difficulty,
+			"peerCount":  len(room.Peers),
+			"created":    room.Created.Unix(),
 		})
 		room.mu.RUnlock()
 	}
@@ -459,7 +487,7 @@ func main() {
 	log.Printf("Starship Sorades server starting on http://localhost%s", addr)
 	log.Printf("TURN server running on port %d", *turnPort)
 	log.Printf("Serving static files from: %s", *staticDir)
-	log.Printf("WebRTC signaling endpoint: /api/signal?room=ROOM_ID&peer=PEER_ID")
+	log.Printf("WebRTC signaling endpoint: /api/signal?room=ROOM_ID&peer=PEER_ID[&difficulty=DIFFICULTY]")
 	log.Printf("ICE servers endpoint: /api/ice-servers")
 
 	if err := http.ListenAndServe(addr, nil); err != nil {